@@ -6,32 +6,40 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/tektoncd/mcp-server/internal/resources"
+	"github.com/tektoncd/mcp-server/internal/scope"
 	"github.com/tektoncd/mcp-server/internal/tools"
 	"github.com/tektoncd/mcp-server/internal/version"
 	"k8s.io/client-go/tools/clientcmd"
-	filteredinformerfactory "knative.dev/pkg/client/injection/kube/informers/factory/filtered"
 	"knative.dev/pkg/injection"
 	"knative.dev/pkg/signals"
 )
 
-// ManagedByLabelKey is the label key used to mark what is managing this resource
-const ManagedByLabelKey = "app.kubernetes.io/managed-by"
-
 func main() {
 	var transport string
 	var httpAddr string
+	var informerScope scope.Scope
 	flag.StringVar(&transport, "transport", "http", "Transport type (stdio or http)")
 	flag.StringVar(&httpAddr, "address", ":8080", "Address to bind the HTTP server to")
+	flag.StringVar(&informerScope.LabelSelector, "label-selector", "", "Label selector of the Tekton objects cached by the informers, all objects if empty")
+	flag.Func("namespaces", "Comma-separated namespaces the server is restricted to, each with its own informers, all namespaces if not set", func(s string) error {
+		informerScope.Namespaces = append(informerScope.Namespaces, strings.Split(s, ",")...)
+		return nil
+	})
 	flag.Parse()
 
 	if httpAddr == "" && transport == "http" {
 		slog.Error("-address is required when transport is set to 'http'")
 		os.Exit(1)
 	}
+	if err := informerScope.Validate(); err != nil {
+		slog.Error(fmt.Sprintf("invalid scope: %v", err))
+		os.Exit(1)
+	}
 
 	// Create MCP server
 	s := mcp.NewServer("Tekton", version.Version, nil)
@@ -48,8 +56,11 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Start informers through knative injection functions (in context)
-	ctx = filteredinformerfactory.WithSelectors(ctx, ManagedByLabelKey)
+	// Start informers through knative injection functions (in context). The
+	// objects outside of the scope of the informers are read from the API
+	// server.
+	ctx = scope.WithScope(ctx, informerScope)
+	slog.Info("Caching " + informerScope.String())
 	// slog.Info("Registering %d informer factories", len(injection.Default.GetInformerFactories()))
 	// slog.Info("Registering %d informers", len(injection.Default.GetInformers()))
 	ctx, startInformers := injection.EnableInjectionOrDie(ctx, cfg)
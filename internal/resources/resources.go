@@ -8,11 +8,8 @@ import (
 	"strings"
 
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
-	pipelineinformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/pipeline"
-	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/pipelinerun"
-	taskinformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/task"
-	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/taskrun"
-	stepactioninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/stepaction"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	"github.com/tektoncd/mcp-server/internal/trace"
 )
 
 func Add(_ context.Context, s *mcp.Server) {
@@ -31,6 +28,15 @@ func Add(_ context.Context, s *mcp.Server) {
 			},
 			Handler: resourceHandler,
 		},
+		&mcp.ServerResourceTemplate{
+			ResourceTemplate: &mcp.ResourceTemplate{
+				Name:        "PipelineRun trace",
+				URITemplate: "tekton://pipelinerun/{namespace}/{name}/trace",
+				Description: "Chrome Trace Event JSON of the TaskRuns and steps of a PipelineRun, viewable in Perfetto or chrome://tracing",
+				MIMEType:    "application/json",
+			},
+			Handler: traceHandler,
+		},
 		&mcp.ServerResourceTemplate{
 			ResourceTemplate: &mcp.ResourceTemplate{
 				Name:        "Task",
@@ -104,9 +110,30 @@ func resourceHandler(ctx context.Context, _ *mcp.ServerSession, rrp *mcp.ReadRes
 	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}, nil
 }
 
+func traceHandler(ctx context.Context, _ *mcp.ServerSession, rrp *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+	uri := rrp.URI
+	parsed := strings.Split(uri, "/")
+	namespace := parsed[3]
+	name := parsed[4]
+
+	slog.Info(fmt.Sprintf("Resource: trace, %s/%s", namespace, name))
+
+	jsonData, err := trace.PipelineRun(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &mcp.ResourceContents{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     string(jsonData),
+	}
+
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}, nil
+}
+
 func getPipelineRun(ctx context.Context, namespace string, name string) ([]byte, error) {
-	pipelineRunInformer := pipelineruninformer.Get(ctx)
-	pipelineRun, err := pipelineRunInformer.Lister().PipelineRuns(namespace).Get(name)
+	pipelineRun, err := scope.PipelineRun(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
 	}
@@ -120,8 +147,7 @@ func getPipelineRun(ctx context.Context, namespace string, name string) ([]byte,
 }
 
 func getTaskRun(ctx context.Context, namespace string, name string) ([]byte, error) {
-	taskRunInformer := taskruninformer.Get(ctx)
-	taskRun, err := taskRunInformer.Lister().TaskRuns(namespace).Get(name)
+	taskRun, err := scope.TaskRun(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, name, err)
 	}
@@ -134,8 +160,7 @@ func getTaskRun(ctx context.Context, namespace string, name string) ([]byte, err
 }
 
 func getPipeline(ctx context.Context, namespace string, name string) ([]byte, error) {
-	pipelineInformer := pipelineinformer.Get(ctx)
-	pipeline, err := pipelineInformer.Lister().Pipelines(namespace).Get(name)
+	pipeline, err := scope.Pipeline(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Pipeline %s/%s: %w", namespace, name, err)
 	}
@@ -148,8 +173,7 @@ func getPipeline(ctx context.Context, namespace string, name string) ([]byte, er
 }
 
 func getTask(ctx context.Context, namespace string, name string) ([]byte, error) {
-	taskInformer := taskinformer.Get(ctx)
-	task, err := taskInformer.Lister().Tasks(namespace).Get(name)
+	task, err := scope.Task(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Task %s/%s: %w", namespace, name, err)
 	}
@@ -162,8 +186,7 @@ func getTask(ctx context.Context, namespace string, name string) ([]byte, error)
 }
 
 func getStepAction(ctx context.Context, namespace string, name string) ([]byte, error) {
-	stepActionInformer := stepactioninformer.Get(ctx)
-	stepAction, err := stepActionInformer.Lister().StepActions(namespace).Get(name)
+	stepAction, err := scope.StepAction(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get StepAction %s/%s: %w", namespace, name, err)
 	}
@@ -0,0 +1,450 @@
+// Package scope configures the Tekton objects cached by the informers of the
+// server, and reads the objects outside of the caches from the API server.
+package scope
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/informers/externalversions"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	"github.com/tektoncd/pipeline/pkg/client/injection/informers/factory"
+	pipelineinformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/pipeline"
+	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/pipelinerun"
+	taskinformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/task"
+	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/taskrun"
+	stepactioninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/stepaction"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1"
+	listersv1beta1 "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+)
+
+func init() {
+	// Registered after the informer factory of Tekton, which this package
+	// imports, so that the informers are created from the scoped factory
+	injection.Default.RegisterInformerFactory(withInformerFactory)
+	injection.Default.RegisterFilteredInformers(withNamespaceInformers)
+}
+
+// Scope is the set of Tekton objects cached by the informers.
+type Scope struct {
+	// LabelSelector selects the cached objects, all of them when empty
+	LabelSelector string
+	// Namespaces are the only namespaces the server reads and writes objects
+	// in, each with its own informers, all namespaces when empty
+	Namespaces []string
+}
+
+type scopeKey struct{}
+
+// factoriesKey holds the informer factory of each namespace of the scope.
+type factoriesKey struct{}
+
+// WithScope sets the scope of the informers created from the context.
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// Get returns the scope of the informers, every object being cached when
+// none was set.
+func Get(ctx context.Context) Scope {
+	s, _ := ctx.Value(scopeKey{}).(Scope)
+	return s
+}
+
+// Validate checks the label selector and the namespaces of the scope.
+func (s Scope) Validate() error {
+	if _, err := labels.Parse(s.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector %q: %w", s.LabelSelector, err)
+	}
+	for i, ns := range s.Namespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %q: %s", ns, strings.Join(errs, ", "))
+		}
+		if slices.Contains(s.Namespaces[:i], ns) {
+			return fmt.Errorf("duplicate namespace %q", ns)
+		}
+	}
+	return nil
+}
+
+// All reports whether every Tekton object is cached.
+func (s Scope) All() bool {
+	return s.LabelSelector == "" && len(s.Namespaces) == 0
+}
+
+// Check returns an error when a namespace is outside of the namespaces of
+// the scope.
+func (s Scope) Check(namespace string) error {
+	if len(s.Namespaces) == 0 || slices.Contains(s.Namespaces, namespace) {
+		return nil
+	}
+	if namespace == "" {
+		return fmt.Errorf("the server is restricted to the namespaces %s, a namespace is required", strings.Join(s.Namespaces, ", "))
+	}
+	return fmt.Errorf("namespace %s is outside of the namespaces the server is restricted to: %s", namespace, strings.Join(s.Namespaces, ", "))
+}
+
+// Covers reports whether all the objects of a namespace, or of all the
+// namespaces if empty, that match a label selector are cached. The label
+// selector must then include every requirement of the label selector of the
+// scope.
+func (s Scope) Covers(namespace string, selector labels.Selector) bool {
+	if s.Check(namespace) != nil {
+		return false
+	}
+	if s.LabelSelector == "" {
+		return true
+	}
+	scoped, err := labels.Parse(s.LabelSelector)
+	if err != nil {
+		return false
+	}
+	required, _ := scoped.Requirements()
+	requested, _ := selector.Requirements()
+	for _, r := range required {
+		if !slices.ContainsFunc(requested, r.Equal) {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the objects cached by the informers.
+func (s Scope) String() string {
+	description := "the Tekton objects of all namespaces"
+	switch {
+	case len(s.Namespaces) == 1:
+		description = "the Tekton objects of namespace " + s.Namespaces[0]
+	case len(s.Namespaces) > 1:
+		description = "the Tekton objects of namespaces " + strings.Join(s.Namespaces, ", ")
+	}
+	if s.LabelSelector != "" {
+		description += fmt.Sprintf(" matching the label selector %q", s.LabelSelector)
+	}
+	return description
+}
+
+// Source is where a list of objects was read from.
+type Source struct {
+	// Live is true when the objects were read from the API server
+	Live  bool
+	Scope Scope
+}
+
+// String describes the source of a list of objects.
+func (s Source) String() string {
+	if s.Live {
+		return "Listed from the API server, as the informer cache of the server only holds " + s.Scope.String()
+	}
+	return "Listed from the informer cache of the server, which holds " + s.Scope.String()
+}
+
+func withInformerFactory(ctx context.Context) context.Context {
+	s := Get(ctx)
+	if len(s.Namespaces) == 0 {
+		if s.All() {
+			return ctx
+		}
+		namespace := metav1.NamespaceAll
+		if injection.HasNamespaceScope(ctx) {
+			namespace = injection.GetNamespaceScope(ctx)
+		}
+		return context.WithValue(ctx, factory.Key{}, newInformerFactory(ctx, namespace, s.LabelSelector))
+	}
+
+	// Without a cluster-wide list and watch, each namespace has its own
+	// informers. The injected informers are those of the first namespace.
+	factories := make(map[string]externalversions.SharedInformerFactory, len(s.Namespaces))
+	for _, namespace := range s.Namespaces {
+		factories[namespace] = newInformerFactory(ctx, namespace, s.LabelSelector)
+	}
+	ctx = context.WithValue(ctx, factory.Key{}, factories[s.Namespaces[0]])
+	return context.WithValue(ctx, factoriesKey{}, factories)
+}
+
+func newInformerFactory(ctx context.Context, namespace, labelSelector string) externalversions.SharedInformerFactory {
+	opts := []externalversions.SharedInformerOption{externalversions.WithNamespace(namespace)}
+	if labelSelector != "" {
+		opts = append(opts, externalversions.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = labelSelector
+		}))
+	}
+	return externalversions.NewSharedInformerFactoryWithOptions(pipelineclient.Get(ctx), controller.GetResyncPeriod(ctx), opts...)
+}
+
+// withNamespaceInformers creates the informers of the namespaces of the
+// scope other than the first one, so that they are started and synced along
+// with the injected informers.
+func withNamespaceInformers(ctx context.Context) (context.Context, []controller.Informer) {
+	factories, ok := ctx.Value(factoriesKey{}).(map[string]externalversions.SharedInformerFactory)
+	if !ok {
+		return ctx, nil
+	}
+	var informers []controller.Informer
+	for _, namespace := range Get(ctx).Namespaces[1:] {
+		f := factories[namespace]
+		informers = append(informers,
+			f.Tekton().V1().PipelineRuns().Informer(),
+			f.Tekton().V1().TaskRuns().Informer(),
+			f.Tekton().V1().Pipelines().Informer(),
+			f.Tekton().V1().Tasks().Informer(),
+			f.Tekton().V1beta1().StepActions().Informer(),
+		)
+	}
+	return ctx, informers
+}
+
+// namespaceFactory returns the informer factory of a namespace, nil when the
+// injected informers hold its objects.
+func namespaceFactory(ctx context.Context, namespace string) externalversions.SharedInformerFactory {
+	factories, _ := ctx.Value(factoriesKey{}).(map[string]externalversions.SharedInformerFactory)
+	return factories[namespace]
+}
+
+// RunInformers returns the informers of the PipelineRuns and TaskRuns of a
+// namespace.
+func RunInformers(ctx context.Context, namespace string) []cache.SharedIndexInformer {
+	if f := namespaceFactory(ctx, namespace); f != nil {
+		return []cache.SharedIndexInformer{f.Tekton().V1().PipelineRuns().Informer(), f.Tekton().V1().TaskRuns().Informer()}
+	}
+	return []cache.SharedIndexInformer{pipelineruninformer.Get(ctx).Informer(), taskruninformer.Get(ctx).Informer()}
+}
+
+func pipelineRunLister(ctx context.Context, namespace string) listers.PipelineRunLister {
+	if f := namespaceFactory(ctx, namespace); f != nil {
+		return f.Tekton().V1().PipelineRuns().Lister()
+	}
+	return pipelineruninformer.Get(ctx).Lister()
+}
+
+func taskRunLister(ctx context.Context, namespace string) listers.TaskRunLister {
+	if f := namespaceFactory(ctx, namespace); f != nil {
+		return f.Tekton().V1().TaskRuns().Lister()
+	}
+	return taskruninformer.Get(ctx).Lister()
+}
+
+func pipelineLister(ctx context.Context, namespace string) listers.PipelineLister {
+	if f := namespaceFactory(ctx, namespace); f != nil {
+		return f.Tekton().V1().Pipelines().Lister()
+	}
+	return pipelineinformer.Get(ctx).Lister()
+}
+
+func taskLister(ctx context.Context, namespace string) listers.TaskLister {
+	if f := namespaceFactory(ctx, namespace); f != nil {
+		return f.Tekton().V1().Tasks().Lister()
+	}
+	return taskinformer.Get(ctx).Lister()
+}
+
+func stepActionLister(ctx context.Context, namespace string) listersv1beta1.StepActionLister {
+	if f := namespaceFactory(ctx, namespace); f != nil {
+		return f.Tekton().V1beta1().StepActions().Lister()
+	}
+	return stepactioninformer.Get(ctx).Lister()
+}
+
+// get reads an object from the informer cache, and from the API server when
+// it is missing from the cache.
+func get[T any](ctx context.Context, namespace string, cached func() (T, error), live func() (T, error)) (T, error) {
+	var zero T
+	if err := Get(ctx).Check(namespace); err != nil {
+		return zero, err
+	}
+	obj, err := cached()
+	if !apierrors.IsNotFound(err) {
+		return obj, err
+	}
+	// Clients return an empty object along with errors
+	if obj, err = live(); err != nil {
+		return zero, err
+	}
+	return obj, nil
+}
+
+// list reads the objects of a namespace, all namespaces if empty, from the
+// informer cache when it holds all of them, and from the API server otherwise.
+// When the scope is restricted to some namespaces, all namespaces are the
+// namespaces of the scope.
+func list[T any](ctx context.Context, namespace string, selector labels.Selector, cached func(string) ([]*T, error), live func(string) ([]T, error)) ([]*T, Source, error) {
+	source := Source{Scope: Get(ctx)}
+	namespaces := []string{namespace}
+	if namespace == "" && len(source.Scope.Namespaces) > 0 {
+		namespaces = source.Scope.Namespaces
+	} else if err := source.Scope.Check(namespace); err != nil {
+		return nil, source, err
+	}
+
+	var objs []*T
+	for _, ns := range namespaces {
+		if source.Scope.Covers(ns, selector) {
+			cachedObjs, err := cached(ns)
+			if err != nil {
+				return nil, source, err
+			}
+			objs = append(objs, cachedObjs...)
+			continue
+		}
+
+		source.Live = true
+		items, err := live(ns)
+		if err != nil {
+			return nil, source, err
+		}
+		for i := range items {
+			objs = append(objs, &items[i])
+		}
+	}
+	return objs, source, nil
+}
+
+func listOptions(selector labels.Selector) metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: selector.String()}
+}
+
+// PipelineRun returns a PipelineRun from the cache or the API server.
+func PipelineRun(ctx context.Context, namespace, name string) (*v1.PipelineRun, error) {
+	return get(ctx, namespace,
+		func() (*v1.PipelineRun, error) {
+			return pipelineRunLister(ctx, namespace).PipelineRuns(namespace).Get(name)
+		},
+		func() (*v1.PipelineRun, error) {
+			return pipelineclient.Get(ctx).TektonV1().PipelineRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+		})
+}
+
+// TaskRun returns a TaskRun from the cache or the API server.
+func TaskRun(ctx context.Context, namespace, name string) (*v1.TaskRun, error) {
+	return get(ctx, namespace,
+		func() (*v1.TaskRun, error) {
+			return taskRunLister(ctx, namespace).TaskRuns(namespace).Get(name)
+		},
+		func() (*v1.TaskRun, error) {
+			return pipelineclient.Get(ctx).TektonV1().TaskRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+		})
+}
+
+// Pipeline returns a Pipeline from the cache or the API server.
+func Pipeline(ctx context.Context, namespace, name string) (*v1.Pipeline, error) {
+	return get(ctx, namespace,
+		func() (*v1.Pipeline, error) {
+			return pipelineLister(ctx, namespace).Pipelines(namespace).Get(name)
+		},
+		func() (*v1.Pipeline, error) {
+			return pipelineclient.Get(ctx).TektonV1().Pipelines(namespace).Get(ctx, name, metav1.GetOptions{})
+		})
+}
+
+// Task returns a Task from the cache or the API server.
+func Task(ctx context.Context, namespace, name string) (*v1.Task, error) {
+	return get(ctx, namespace,
+		func() (*v1.Task, error) {
+			return taskLister(ctx, namespace).Tasks(namespace).Get(name)
+		},
+		func() (*v1.Task, error) {
+			return pipelineclient.Get(ctx).TektonV1().Tasks(namespace).Get(ctx, name, metav1.GetOptions{})
+		})
+}
+
+// StepAction returns a StepAction from the cache or the API server.
+func StepAction(ctx context.Context, namespace, name string) (*v1beta1.StepAction, error) {
+	return get(ctx, namespace,
+		func() (*v1beta1.StepAction, error) {
+			return stepActionLister(ctx, namespace).StepActions(namespace).Get(name)
+		},
+		func() (*v1beta1.StepAction, error) {
+			return pipelineclient.Get(ctx).TektonV1beta1().StepActions(namespace).Get(ctx, name, metav1.GetOptions{})
+		})
+}
+
+// PipelineRuns lists the PipelineRuns of a namespace, all namespaces if
+// empty, matching a label selector.
+func PipelineRuns(ctx context.Context, namespace string, selector labels.Selector) ([]*v1.PipelineRun, Source, error) {
+	return list(ctx, namespace, selector,
+		func(namespace string) ([]*v1.PipelineRun, error) {
+			return pipelineRunLister(ctx, namespace).PipelineRuns(namespace).List(selector)
+		},
+		func(namespace string) ([]v1.PipelineRun, error) {
+			l, err := pipelineclient.Get(ctx).TektonV1().PipelineRuns(namespace).List(ctx, listOptions(selector))
+			if err != nil {
+				return nil, err
+			}
+			return l.Items, nil
+		})
+}
+
+// TaskRuns lists the TaskRuns of a namespace, all namespaces if empty,
+// matching a label selector.
+func TaskRuns(ctx context.Context, namespace string, selector labels.Selector) ([]*v1.TaskRun, Source, error) {
+	return list(ctx, namespace, selector,
+		func(namespace string) ([]*v1.TaskRun, error) {
+			return taskRunLister(ctx, namespace).TaskRuns(namespace).List(selector)
+		},
+		func(namespace string) ([]v1.TaskRun, error) {
+			l, err := pipelineclient.Get(ctx).TektonV1().TaskRuns(namespace).List(ctx, listOptions(selector))
+			if err != nil {
+				return nil, err
+			}
+			return l.Items, nil
+		})
+}
+
+// Pipelines lists the Pipelines of a namespace, all namespaces if empty,
+// matching a label selector.
+func Pipelines(ctx context.Context, namespace string, selector labels.Selector) ([]*v1.Pipeline, Source, error) {
+	return list(ctx, namespace, selector,
+		func(namespace string) ([]*v1.Pipeline, error) {
+			return pipelineLister(ctx, namespace).Pipelines(namespace).List(selector)
+		},
+		func(namespace string) ([]v1.Pipeline, error) {
+			l, err := pipelineclient.Get(ctx).TektonV1().Pipelines(namespace).List(ctx, listOptions(selector))
+			if err != nil {
+				return nil, err
+			}
+			return l.Items, nil
+		})
+}
+
+// Tasks lists the Tasks of a namespace, all namespaces if empty, matching a
+// label selector.
+func Tasks(ctx context.Context, namespace string, selector labels.Selector) ([]*v1.Task, Source, error) {
+	return list(ctx, namespace, selector,
+		func(namespace string) ([]*v1.Task, error) {
+			return taskLister(ctx, namespace).Tasks(namespace).List(selector)
+		},
+		func(namespace string) ([]v1.Task, error) {
+			l, err := pipelineclient.Get(ctx).TektonV1().Tasks(namespace).List(ctx, listOptions(selector))
+			if err != nil {
+				return nil, err
+			}
+			return l.Items, nil
+		})
+}
+
+// StepActions lists the StepActions of a namespace, all namespaces if empty,
+// matching a label selector.
+func StepActions(ctx context.Context, namespace string, selector labels.Selector) ([]*v1beta1.StepAction, Source, error) {
+	return list(ctx, namespace, selector,
+		func(namespace string) ([]*v1beta1.StepAction, error) {
+			return stepActionLister(ctx, namespace).StepActions(namespace).List(selector)
+		},
+		func(namespace string) ([]v1beta1.StepAction, error) {
+			l, err := pipelineclient.Get(ctx).TektonV1beta1().StepActions(namespace).List(ctx, listOptions(selector))
+			if err != nil {
+				return nil, err
+			}
+			return l.Items, nil
+		})
+}
@@ -0,0 +1,231 @@
+package scope
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCovers(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     Scope
+		namespace string
+		selector  string
+		expected  bool
+	}{
+		{
+			name:     "all objects",
+			expected: true,
+		},
+		{
+			name:      "namespace of the scope",
+			scope:     Scope{Namespaces: []string{"ci"}},
+			namespace: "ci",
+			expected:  true,
+		},
+		{
+			name:      "other namespace",
+			scope:     Scope{Namespaces: []string{"ci"}},
+			namespace: "prod",
+		},
+		{
+			name:  "all namespaces",
+			scope: Scope{Namespaces: []string{"ci"}},
+		},
+		{
+			name:     "selector of the scope",
+			scope:    Scope{LabelSelector: "team=ci"},
+			selector: "team=ci",
+			expected: true,
+		},
+		{
+			name:     "narrower selector",
+			scope:    Scope{LabelSelector: "team=ci"},
+			selector: "app=api,team=ci",
+			expected: true,
+		},
+		{
+			name:     "other selector",
+			scope:    Scope{LabelSelector: "team=ci"},
+			selector: "team=cd",
+		},
+		{
+			name:  "no selector",
+			scope: Scope{LabelSelector: "team=ci"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector, err := labels.Parse(test.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := test.scope.Covers(test.namespace, selector); got != test.expected {
+				t.Errorf("Covers(%q, %q) = %t, want %t", test.namespace, test.selector, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope Scope
+		err   string
+	}{
+		{
+			name:  "valid",
+			scope: Scope{LabelSelector: "team=ci", Namespaces: []string{"ci", "prod"}},
+		},
+		{
+			name:  "invalid label selector",
+			scope: Scope{LabelSelector: "team in (ci"},
+			err:   `invalid label selector "team in (ci"`,
+		},
+		{
+			name:  "invalid namespace",
+			scope: Scope{Namespaces: []string{"ci", ""}},
+			err:   `invalid namespace ""`,
+		},
+		{
+			name:  "duplicate namespace",
+			scope: Scope{Namespaces: []string{"ci", "prod", "ci"}},
+			err:   `duplicate namespace "ci"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.scope.Validate()
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := Scope{Namespaces: []string{"ci", "prod"}}
+	if err := s.Check("ci"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := s.Check("default")
+	if diff := cmp.Diff("namespace default is outside of the namespaces the server is restricted to: ci, prod", fmt.Sprint(err)); diff != "" {
+		t.Errorf("error mismatch (-want +got):\n%s", diff)
+	}
+	if err := (Scope{}).Check("default"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReadThrough(t *testing.T) {
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{ObjectMeta: metav1.ObjectMeta{Name: "cached", Namespace: "ci", Labels: map[string]string{"team": "ci"}}},
+		},
+	})
+	// Created in the API server only, as objects not matching the label
+	// selector of the informers are
+	if _, err := clients.Pipeline.TektonV1().PipelineRuns("ci").Create(ctx, &v1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "live", Namespace: "ci"},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx = WithScope(ctx, Scope{LabelSelector: "team=ci", Namespaces: []string{"ci"}})
+
+	if _, err := PipelineRun(ctx, "ci", "cached"); err != nil {
+		t.Errorf("failed to get cached PipelineRun: %v", err)
+	}
+	if _, err := PipelineRun(ctx, "ci", "live"); err != nil {
+		t.Errorf("failed to get PipelineRun from the API server: %v", err)
+	}
+	pr, err := PipelineRun(ctx, "ci", "missing")
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if pr != nil {
+		t.Errorf("expected no PipelineRun, got %v", pr)
+	}
+	if _, err := PipelineRun(ctx, "prod", "live"); err == nil {
+		t.Error("expected PipelineRun outside of the namespaces of the scope to be rejected")
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		selector  string
+		expected  []string
+		source    string
+		err       string
+	}{
+		{
+			name:      "cached",
+			namespace: "ci",
+			selector:  "team=ci",
+			expected:  []string{"cached"},
+			source:    `Listed from the informer cache of the server, which holds the Tekton objects of namespace ci matching the label selector "team=ci"`,
+		},
+		{
+			name:      "live",
+			namespace: "ci",
+			expected:  []string{"cached", "live"},
+			source:    `Listed from the API server, as the informer cache of the server only holds the Tekton objects of namespace ci matching the label selector "team=ci"`,
+		},
+		{
+			name:     "namespaces of the scope",
+			selector: "team=ci",
+			expected: []string{"cached"},
+			source:   `Listed from the informer cache of the server, which holds the Tekton objects of namespace ci matching the label selector "team=ci"`,
+		},
+		{
+			name:      "outside of the scope",
+			namespace: "prod",
+			err:       "namespace prod is outside of the namespaces the server is restricted to: ci",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector, err := labels.Parse(test.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prs, source, err := PipelineRuns(ctx, test.namespace, selector)
+			if test.err != "" {
+				if diff := cmp.Diff(test.err, fmt.Sprint(err)); diff != "" {
+					t.Errorf("error mismatch (-want +got):\n%s", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, pr := range prs {
+				names = append(names, pr.Name)
+			}
+			slices.Sort(names)
+			if diff := cmp.Diff(test.expected, names); diff != "" {
+				t.Errorf("PipelineRuns mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.source, source.String()); diff != "" {
+				t.Errorf("source mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
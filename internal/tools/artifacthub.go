@@ -29,6 +29,7 @@ type artifactHubInstallParams struct {
 	PackageID string `json:"packageId"`
 	Version   string `json:"version"`
 	Namespace string `json:"namespace"`
+	DryRun    bool   `json:"dryRun,omitempty"`
 }
 
 func listArtifactHubTasks() *mcp.ServerTool {
@@ -214,11 +215,15 @@ func handlerInstallArtifactHubTask(
 	task.ResourceVersion = ""
 
 	// Create the task in the cluster
-	createdTask, err := tektonClient.TektonV1().Tasks(request.Arguments.Namespace).Create(ctx, task, metav1.CreateOptions{})
+	createdTask, err := tektonClient.TektonV1().Tasks(request.Arguments.Namespace).Create(ctx, task, metav1.CreateOptions{DryRun: dryRunOption(request.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error creating task in cluster: %v", err)), nil
 	}
 
+	if request.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("Tekton task '%s' (v%s) would be installed to namespace '%s' as '%s'",
+			pkg.DisplayName, pkg.Version, request.Arguments.Namespace, createdTask.Name), createdTask)
+	}
 	return result(fmt.Sprintf("Successfully installed Tekton task '%s' (v%s) to namespace '%s' as '%s'",
 		pkg.DisplayName, pkg.Version, request.Arguments.Namespace, createdTask.Name)), nil
 }
@@ -282,11 +287,15 @@ func handlerInstallArtifactHubPipeline(
 	pipeline.ResourceVersion = ""
 
 	// Create the pipeline in the cluster
-	createdPipeline, err := tektonClient.TektonV1().Pipelines(request.Arguments.Namespace).Create(ctx, pipeline, metav1.CreateOptions{})
+	createdPipeline, err := tektonClient.TektonV1().Pipelines(request.Arguments.Namespace).Create(ctx, pipeline, metav1.CreateOptions{DryRun: dryRunOption(request.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error creating pipeline in cluster: %v", err)), nil
 	}
 
+	if request.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("Tekton pipeline '%s' (v%s) would be installed to namespace '%s' as '%s'",
+			pkg.DisplayName, pkg.Version, request.Arguments.Namespace, createdPipeline.Name), createdPipeline)
+	}
 	return result(fmt.Sprintf("Successfully installed Tekton pipeline '%s' (v%s) to namespace '%s' as '%s'",
 		pkg.DisplayName, pkg.Version, request.Arguments.Namespace, createdPipeline.Name)), nil
 }
@@ -313,6 +322,7 @@ type triggerParams struct {
 	Name      string                 `json:"name"`
 	Namespace string                 `json:"namespace"`
 	Params    map[string]interface{} `json:"params"`
+	DryRun    bool                   `json:"dryRun,omitempty"`
 }
 
 func handlerTriggerArtifactHubTask(
@@ -354,11 +364,15 @@ func handlerTriggerArtifactHubTask(
 		}
 	}
 
-	createdTaskRun, err := tektonClient.TektonV1().TaskRuns(request.Arguments.Namespace).Create(ctx, taskRun, metav1.CreateOptions{})
+	createdTaskRun, err := tektonClient.TektonV1().TaskRuns(request.Arguments.Namespace).Create(ctx, taskRun, metav1.CreateOptions{DryRun: dryRunOption(request.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error creating TaskRun: %v", err)), nil
 	}
 
+	if request.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("TaskRun '%s' would be triggered for task '%s' in namespace '%s'",
+			createdTaskRun.Name, request.Arguments.Name, request.Arguments.Namespace), createdTaskRun)
+	}
 	return result(fmt.Sprintf("Successfully triggered TaskRun '%s' for task '%s' in namespace '%s'",
 		createdTaskRun.Name, request.Arguments.Name, request.Arguments.Namespace)), nil
 }
@@ -402,11 +416,15 @@ func handlerTriggerArtifactHubPipeline(
 		}
 	}
 
-	createdPipelineRun, err := tektonClient.TektonV1().PipelineRuns(request.Arguments.Namespace).Create(ctx, pipelineRun, metav1.CreateOptions{})
+	createdPipelineRun, err := tektonClient.TektonV1().PipelineRuns(request.Arguments.Namespace).Create(ctx, pipelineRun, metav1.CreateOptions{DryRun: dryRunOption(request.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error creating PipelineRun: %v", err)), nil
 	}
 
+	if request.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("PipelineRun '%s' would be triggered for pipeline '%s' in namespace '%s'",
+			createdPipelineRun.Name, request.Arguments.Name, request.Arguments.Namespace), createdPipelineRun)
+	}
 	return result(fmt.Sprintf("Successfully triggered PipelineRun '%s' for pipeline '%s' in namespace '%s'",
 		createdPipelineRun.Name, request.Arguments.Name, request.Arguments.Namespace)), nil
 }
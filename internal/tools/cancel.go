@@ -0,0 +1,294 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/apis"
+)
+
+const cancelModeDescription = "How to stop the PipelineRun: Cancelled (stop all tasks, skip finally), " +
+	"CancelledRunFinally (stop all tasks, run finally) or StoppedRunFinally (let running tasks complete, run finally)"
+
+var pipelineRunCancelModes = []v1.PipelineRunSpecStatus{
+	v1.PipelineRunSpecStatusCancelled,
+	v1.PipelineRunSpecStatusCancelledRunFinally,
+	v1.PipelineRunSpecStatusStoppedRunFinally,
+}
+
+// specStatusPatch returns a merge patch setting spec.status of a run.
+func specStatusPatch(status string) []byte {
+	patch, _ := json.Marshal(map[string]any{"spec": map[string]any{"status": status}})
+	return patch
+}
+
+// pipelineRunCancelMode validates the requested cancellation mode, Cancelled
+// being the default.
+func pipelineRunCancelMode(mode string) (v1.PipelineRunSpecStatus, error) {
+	if mode == "" {
+		return v1.PipelineRunSpecStatusCancelled, nil
+	}
+	for _, m := range pipelineRunCancelModes {
+		if strings.EqualFold(mode, string(m)) {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("invalid mode %q, expected one of %v", mode, pipelineRunCancelModes)
+}
+
+// describeCondition returns a short description of the Succeeded condition
+// of a run.
+func describeCondition(c *apis.Condition) string {
+	if c == nil {
+		return "Succeeded condition not set yet"
+	}
+	desc := fmt.Sprintf("Succeeded=%s (%s)", c.Status, conditionReason(c))
+	if c.Message != "" {
+		desc += ": " + c.Message
+	}
+	return desc
+}
+
+type cancelPipelineRunParams struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Mode      string `json:"mode"`
+	DryRun    bool   `json:"dryRun"`
+}
+
+func cancelPipelineRun() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[cancelPipelineRunParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["name"].Description = "Name of the PipelineRun to cancel"
+	scheme.Properties["namespace"].Description = "Namespace of the PipelineRun"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["mode"].Description = cancelModeDescription
+	scheme.Properties["mode"].Default = json.RawMessage(`"Cancelled"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"cancel_pipelinerun",
+		"Cancel or gracefully stop a running PipelineRun",
+		handlerCancelPipelineRun,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerCancelPipelineRun(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[cancelPipelineRunParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	if name == "" {
+		return result("Error: PipelineRun name is required"), nil
+	}
+	mode, err := pipelineRunCancelMode(params.Arguments.Mode)
+	if err != nil {
+		return result(fmt.Sprintf("Error: %v", err)), nil
+	}
+
+	pipelineClient := pipelineclient.Get(ctx)
+	pr, err := pipelineClient.TektonV1().PipelineRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return result(fmt.Sprintf("Error getting PipelineRun: %v", err)), nil
+	}
+
+	patched, message := cancelPipelineRunObject(ctx, pr, mode, params.Arguments.DryRun)
+	if params.Arguments.DryRun && patched != nil {
+		return dryRunResult(message, patched)
+	}
+	return result(message), nil
+}
+
+// cancelPipelineRunObject sets spec.status of a PipelineRun and describes the
+// outcome, along with the patched PipelineRun. PipelineRuns that are already
+// done are left untouched.
+func cancelPipelineRunObject(ctx context.Context, pr *v1.PipelineRun, mode v1.PipelineRunSpecStatus, dryRun bool) (*v1.PipelineRun, string) {
+	if pr.IsDone() {
+		return nil, fmt.Sprintf("PipelineRun '%s' already completed: %s", pr.Name, describeCondition(pr.Status.GetCondition(apis.ConditionSucceeded)))
+	}
+
+	patched, err := pipelineclient.Get(ctx).TektonV1().PipelineRuns(pr.Namespace).Patch(
+		ctx,
+		pr.Name,
+		types.MergePatchType,
+		specStatusPatch(string(mode)),
+		metav1.PatchOptions{DryRun: dryRunOption(dryRun)},
+	)
+	if err != nil {
+		return nil, fmt.Sprintf("Error cancelling PipelineRun '%s': %v", pr.Name, err)
+	}
+
+	if dryRun {
+		return patched, fmt.Sprintf("PipelineRun '%s' in namespace '%s' would be set to %s", patched.Name, patched.Namespace, patched.Spec.Status)
+	}
+	return patched, fmt.Sprintf("PipelineRun '%s' in namespace '%s' set to %s, current condition: %s",
+		patched.Name, patched.Namespace, patched.Spec.Status, describeCondition(patched.Status.GetCondition(apis.ConditionSucceeded)))
+}
+
+type cancelTaskRunParams struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	DryRun    bool   `json:"dryRun"`
+}
+
+func cancelTaskRun() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[cancelTaskRunParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["name"].Description = "Name of the TaskRun to cancel"
+	scheme.Properties["namespace"].Description = "Namespace of the TaskRun"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"cancel_taskrun",
+		"Cancel a running TaskRun",
+		handlerCancelTaskRun,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerCancelTaskRun(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[cancelTaskRunParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	if name == "" {
+		return result("Error: TaskRun name is required"), nil
+	}
+
+	pipelineClient := pipelineclient.Get(ctx)
+	tr, err := pipelineClient.TektonV1().TaskRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return result(fmt.Sprintf("Error getting TaskRun: %v", err)), nil
+	}
+	if tr.IsDone() {
+		return result(fmt.Sprintf("TaskRun '%s' already completed: %s", name, describeCondition(tr.Status.GetCondition(apis.ConditionSucceeded)))), nil
+	}
+
+	patched, err := pipelineClient.TektonV1().TaskRuns(namespace).Patch(
+		ctx,
+		name,
+		types.MergePatchType,
+		specStatusPatch(string(v1.TaskRunSpecStatusCancelled)),
+		metav1.PatchOptions{DryRun: dryRunOption(params.Arguments.DryRun)},
+	)
+	if err != nil {
+		return result(fmt.Sprintf("Error cancelling TaskRun: %v", err)), nil
+	}
+
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("TaskRun '%s' in namespace '%s' would be set to %s", patched.Name, namespace, patched.Spec.Status), patched)
+	}
+
+	return result(fmt.Sprintf("TaskRun '%s' in namespace '%s' set to %s, current condition: %s",
+		patched.Name, namespace, patched.Spec.Status, describeCondition(patched.Status.GetCondition(apis.ConditionSucceeded)))), nil
+}
+
+type cancelAllPipelineRunsParams struct {
+	Namespace     string `json:"namespace"`
+	LabelSelector string `json:"labelSelector"`
+	FieldSelector string `json:"fieldSelector"`
+	Mode          string `json:"mode"`
+	DryRun        bool   `json:"dryRun"`
+}
+
+func cancelAllPipelineRuns() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[cancelAllPipelineRunsParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["namespace"].Description = "Namespace to cancel PipelineRuns in"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["labelSelector"].Description = "Label selector to filter PipelineRuns to cancel"
+	scheme.Properties["fieldSelector"].Description = "Field selector to filter PipelineRuns to cancel"
+	scheme.Properties["mode"].Description = cancelModeDescription
+	scheme.Properties["mode"].Default = json.RawMessage(`"Cancelled"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
+	scheme.Required = []string{"labelSelector"}
+
+	return mcp.NewServerTool(
+		"cancel_all_pipelineruns",
+		"Cancel or gracefully stop every running PipelineRun matching a label selector",
+		handlerCancelAllPipelineRuns,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerCancelAllPipelineRuns(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[cancelAllPipelineRunsParams],
+) (*mcp.CallToolResultFor[string], error) {
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	labelSelector := params.Arguments.LabelSelector
+	fieldSelector := params.Arguments.FieldSelector
+
+	// Cancelling every run of a namespace is most likely a mistake
+	if labelSelector == "" {
+		return result("Error: labelSelector is required"), nil
+	}
+	mode, err := pipelineRunCancelMode(params.Arguments.Mode)
+	if err != nil {
+		return result(fmt.Sprintf("Error: %v", err)), nil
+	}
+
+	pipelineClient := pipelineclient.Get(ctx)
+	prs, err := pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+	})
+	if err != nil {
+		return result(fmt.Sprintf("Error listing PipelineRuns: %v", err)), nil
+	}
+
+	running := slices.DeleteFunc(prs.Items, func(pr v1.PipelineRun) bool {
+		return pr.IsDone()
+	})
+	if len(running) == 0 {
+		return result(fmt.Sprintf("No running PipelineRuns found in namespace '%s' with selectors", namespace)), nil
+	}
+
+	lines := make([]string, 0, len(running))
+	for i := range running {
+		_, line := cancelPipelineRunObject(ctx, &running[i], mode, params.Arguments.DryRun)
+		lines = append(lines, line)
+	}
+	if params.Arguments.DryRun {
+		return result(fmt.Sprintf("Dry run, nothing was persisted. %d PipelineRuns would be cancelled in namespace '%s':\n%s", len(running), namespace, strings.Join(lines, "\n"))), nil
+	}
+	return result(fmt.Sprintf("Cancelling %d PipelineRuns in namespace '%s':\n%s", len(running), namespace, strings.Join(lines, "\n"))), nil
+}
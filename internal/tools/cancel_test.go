@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestCancelOperations(t *testing.T) {
+	running := duckv1.Status{Conditions: duckv1.Conditions{{
+		Type:   apis.ConditionSucceeded,
+		Status: corev1.ConditionUnknown,
+		Reason: "Running",
+	}}}
+	succeeded := duckv1.Status{Conditions: duckv1.Conditions{{
+		Type:   apis.ConditionSucceeded,
+		Status: corev1.ConditionTrue,
+		Reason: "Succeeded",
+	}}}
+	pipelineRun := func(name string, status duckv1.Status, labels map[string]string) *v1.PipelineRun {
+		return &v1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Status:     v1.PipelineRunStatus{Status: status},
+		}
+	}
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			pipelineRun("build", running, nil),
+			pipelineRun("deploy", running, nil),
+			pipelineRun("done", succeeded, nil),
+			pipelineRun("release-1", running, map[string]string{"tekton.dev/pipeline": "release"}),
+			pipelineRun("release-2", running, map[string]string{"tekton.dev/pipeline": "release"}),
+			pipelineRun("release-3", succeeded, map[string]string{"tekton.dev/pipeline": "release"}),
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "unit-test", Namespace: "default"},
+				Status:     v1.TaskRunStatus{Status: running},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     map[string]any
+		expected []string
+		// status expected in spec.status of the PipelineRuns after the call
+		pipelineRuns map[string]v1.PipelineRunSpecStatus
+	}{
+		{
+			name:         "cancel_pipelinerun",
+			tool:         "cancel_pipelinerun",
+			args:         map[string]any{"name": "build"},
+			expected:     []string{"PipelineRun 'build' in namespace 'default' set to Cancelled, current condition: Succeeded=Unknown (Running)"},
+			pipelineRuns: map[string]v1.PipelineRunSpecStatus{"build": v1.PipelineRunSpecStatusCancelled},
+		},
+		{
+			name:         "stop_pipelinerun_run_finally",
+			tool:         "cancel_pipelinerun",
+			args:         map[string]any{"name": "deploy", "mode": "stoppedRunFinally"},
+			expected:     []string{"PipelineRun 'deploy' in namespace 'default' set to StoppedRunFinally"},
+			pipelineRuns: map[string]v1.PipelineRunSpecStatus{"deploy": v1.PipelineRunSpecStatusStoppedRunFinally},
+		},
+		{
+			name:         "cancel_completed_pipelinerun",
+			tool:         "cancel_pipelinerun",
+			args:         map[string]any{"name": "done"},
+			expected:     []string{"PipelineRun 'done' already completed: Succeeded=True (Succeeded)"},
+			pipelineRuns: map[string]v1.PipelineRunSpecStatus{"done": ""},
+		},
+		{
+			name:     "cancel_pipelinerun_invalid_mode",
+			tool:     "cancel_pipelinerun",
+			args:     map[string]any{"name": "done", "mode": "Pending"},
+			expected: []string{`Error: invalid mode "Pending"`},
+		},
+		{
+			name:     "cancel_pipelinerun_not_found",
+			tool:     "cancel_pipelinerun",
+			args:     map[string]any{"name": "nonexistent"},
+			expected: []string{"Error getting PipelineRun"},
+		},
+		{
+			name:     "cancel_taskrun",
+			tool:     "cancel_taskrun",
+			args:     map[string]any{"name": "unit-test"},
+			expected: []string{"TaskRun 'unit-test' in namespace 'default' set to TaskRunCancelled"},
+		},
+		{
+			name: "cancel_all_pipelineruns",
+			tool: "cancel_all_pipelineruns",
+			args: map[string]any{"labelSelector": "tekton.dev/pipeline=release", "mode": "CancelledRunFinally"},
+			expected: []string{
+				"Cancelling 2 PipelineRuns in namespace 'default'",
+				"PipelineRun 'release-1' in namespace 'default' set to CancelledRunFinally",
+				"PipelineRun 'release-2' in namespace 'default' set to CancelledRunFinally",
+			},
+			pipelineRuns: map[string]v1.PipelineRunSpecStatus{
+				"release-1": v1.PipelineRunSpecStatusCancelledRunFinally,
+				"release-2": v1.PipelineRunSpecStatusCancelledRunFinally,
+				"release-3": "",
+			},
+		},
+		{
+			name:     "cancel_all_pipelineruns_missing_selector",
+			tool:     "cancel_all_pipelineruns",
+			args:     map[string]any{"namespace": "default"},
+			expected: []string{"Error: labelSelector is required"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      test.tool,
+				Arguments: test.args,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, ok := response.Content[0].(*mcp.TextContent)
+			if !ok {
+				t.Fatal("Expected text content")
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(content.Text, expected) {
+					t.Errorf("Expected response to contain '%s', got '%s'", expected, content.Text)
+				}
+			}
+
+			for name, status := range test.pipelineRuns {
+				pr, err := clients.Pipeline.TektonV1().PipelineRuns("default").Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pr.Spec.Status != status {
+					t.Errorf("Expected PipelineRun %s spec.status to be %q, got %q", name, status, pr.Spec.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelDryRun(t *testing.T) {
+	running := duckv1.Status{Conditions: duckv1.Conditions{{
+		Type:   apis.ConditionSucceeded,
+		Status: corev1.ConditionUnknown,
+		Reason: "Running",
+	}}}
+	release := map[string]string{"tekton.dev/pipeline": "release"}
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default"}, Status: v1.PipelineRunStatus{Status: running}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "release-1", Namespace: "default", Labels: release}, Status: v1.PipelineRunStatus{Status: running}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "release-2", Namespace: "default", Labels: release}, Status: v1.PipelineRunStatus{Status: running}},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{ObjectMeta: metav1.ObjectMeta{Name: "unit-test", Namespace: "default"}, Status: v1.TaskRunStatus{Status: running}},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+
+	// The fake clientset ignores the DryRun option: the patches are applied to
+	// a copy of the run, without being persisted
+	var dryRun [][]string
+	clients.Pipeline.PrependReactor("patch", "*", func(action ktesting.Action) (bool, runtime.Object, error) {
+		a := action.(ktesting.PatchActionImpl)
+		dryRun = append(dryRun, a.GetPatchOptions().DryRun)
+		var obj runtime.Object = &v1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: a.GetName(), Namespace: a.GetNamespace()}}
+		if a.GetResource().Resource == "taskruns" {
+			obj = &v1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: a.GetName(), Namespace: a.GetNamespace()}}
+		}
+		return true, obj, json.Unmarshal(a.GetPatch(), obj)
+	})
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		tool     string
+		args     map[string]any
+		response string
+		patches  int
+	}{
+		{
+			tool:     "cancel_pipelinerun",
+			args:     map[string]any{"name": "build"},
+			response: "Dry run, nothing was persisted. PipelineRun 'build' in namespace 'default' would be set to Cancelled\n---\n",
+			patches:  1,
+		},
+		{
+			tool:     "cancel_taskrun",
+			args:     map[string]any{"name": "unit-test"},
+			response: "Dry run, nothing was persisted. TaskRun 'unit-test' in namespace 'default' would be set to TaskRunCancelled\n---\n",
+			patches:  1,
+		},
+		{
+			tool: "cancel_all_pipelineruns",
+			args: map[string]any{"labelSelector": "tekton.dev/pipeline=release", "mode": "StoppedRunFinally"},
+			response: "Dry run, nothing was persisted. 2 PipelineRuns would be cancelled in namespace 'default':\n" +
+				"PipelineRun 'release-1' in namespace 'default' would be set to StoppedRunFinally\n" +
+				"PipelineRun 'release-2' in namespace 'default' would be set to StoppedRunFinally",
+			patches: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.tool, func(t *testing.T) {
+			dryRun = nil
+			test.args["dryRun"] = true
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: test.tool, Arguments: test.args})
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := response.Content[0].(*mcp.TextContent)
+			if !strings.HasPrefix(content.Text, test.response) {
+				t.Errorf("invalid response: %s", content.Text)
+			}
+			if len(dryRun) != test.patches {
+				t.Fatalf("expected %d patches, got %d", test.patches, len(dryRun))
+			}
+			for _, d := range dryRun {
+				if diff := cmp.Diff([]string{metav1.DryRunAll}, d); diff != "" {
+					t.Errorf("DryRun option mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+
+	for _, name := range []string{"build", "release-1", "release-2"} {
+		pr, err := clients.Pipeline.TektonV1().PipelineRuns("default").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pr.Spec.Status != "" {
+			t.Errorf("PipelineRun %s cancelled by a dry run: %q", name, pr.Spec.Status)
+		}
+	}
+}
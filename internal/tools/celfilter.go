@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/interpreter"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// celCostLimit bounds the cost of the evaluation of a filter on each object,
+// so that an expression iterating over large lists cannot hold the server.
+const celCostLimit = 1000000
+
+const celFilterDescription = "CEL expression the objects must match, evaluated on their metadata, spec and status, " +
+	"for example status.conditions[0].reason == 'Failed' && spec.params.exists(p, p.name == 'env' && p.value == 'prod'). " +
+	"Objects on which the expression fails to evaluate, for example because of a missing field, are filtered out, use has() to test optional fields. " +
+	"Expressions exceeding the cost limit on an object fail"
+
+// compileCELFilter compiles a boolean CEL expression on the metadata, spec and
+// status of the listed objects.
+func compileCELFilter(expression string) (cel.Program, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("metadata", cel.DynType),
+		cel.Variable("spec", cel.DynType),
+		cel.Variable("status", cel.DynType),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("invalid filter: %w", issues.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("invalid filter: the expression must return a bool, not %s", t)
+	}
+	return env.Program(ast, cel.CostLimit(celCostLimit), cel.InterruptCheckFrequency(100))
+}
+
+// filterCEL keeps the objects matching a CEL expression, evaluated on the
+// objects converted to unstructured maps. Objects on which the evaluation
+// fails are skipped, unless the cost limit is exceeded, as the objects that
+// would match could not be told apart.
+func filterCEL[T metav1.Object](ctx context.Context, in []T, expression string) ([]T, error) {
+	if expression == "" {
+		return in, nil
+	}
+	program, err := compileCELFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]T, 0, len(in))
+	for _, item := range in {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+		val, _, err := program.ContextEval(ctx, map[string]any{
+			"metadata": obj["metadata"],
+			"spec":     obj["spec"],
+			"status":   obj["status"],
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			var cancelled interpreter.EvalCancelledError
+			if errors.As(err, &cancelled) && cancelled.Cause == interpreter.CostLimitExceeded {
+				return nil, fmt.Errorf("filter exceeded the cost limit of %d on %s/%s, simplify the expression", celCostLimit, item.GetNamespace(), item.GetName())
+			}
+			continue
+		}
+		matched, ok := val.(types.Bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid filter: the expression must return a bool, not %s", val.Type().TypeName())
+		}
+		if matched {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
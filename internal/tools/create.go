@@ -3,19 +3,28 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/yaml"
 )
 
 type createPipelineParams struct {
 	Namespace string `json:"namespace"`
 	Yaml      string `json:"yaml"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func createPipeline() (*mcp.ServerTool, error) {
@@ -27,6 +36,7 @@ func createPipeline() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Description = "Namespace where the Pipeline will be created"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["yaml"].Description = "YAML definition of the Pipeline"
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"yaml"}
 
 	return mcp.NewServerTool(
@@ -58,17 +68,21 @@ func handlerCreatePipeline(
 	}
 
 	pipelineClient := pipelineclient.Get(ctx)
-	created, err := pipelineClient.TektonV1().Pipelines(namespace).Create(ctx, &pipeline, metav1.CreateOptions{})
+	created, err := pipelineClient.TektonV1().Pipelines(namespace).Create(ctx, &pipeline, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error creating Pipeline: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("Pipeline '%s' would be created in namespace '%s'", created.Name, namespace), created)
+	}
 	return result(fmt.Sprintf("Pipeline '%s' created successfully in namespace '%s'", created.Name, namespace)), nil
 }
 
 type createTaskParams struct {
 	Namespace string `json:"namespace"`
 	Yaml      string `json:"yaml"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func createTask() (*mcp.ServerTool, error) {
@@ -80,6 +94,7 @@ func createTask() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Description = "Namespace where the Task will be created"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["yaml"].Description = "YAML definition of the Task"
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"yaml"}
 
 	return mcp.NewServerTool(
@@ -111,18 +126,27 @@ func handlerCreateTask(
 	}
 
 	pipelineClient := pipelineclient.Get(ctx)
-	created, err := pipelineClient.TektonV1().Tasks(namespace).Create(ctx, &task, metav1.CreateOptions{})
+	created, err := pipelineClient.TektonV1().Tasks(namespace).Create(ctx, &task, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error creating Task: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("Task '%s' would be created in namespace '%s'", created.Name, namespace), created)
+	}
 	return result(fmt.Sprintf("Task '%s' created successfully in namespace '%s'", created.Name, namespace)), nil
 }
 
 type createPipelineRunParams struct {
-	Namespace    string `json:"namespace"`
-	Yaml         string `json:"yaml"`
-	GenerateName string `json:"generateName"`
+	Namespace      string           `json:"namespace"`
+	Yaml           string           `json:"yaml"`
+	GenerateName   string           `json:"generateName"`
+	PipelineRef    string           `json:"pipelineRef"`
+	Resolver       string           `json:"resolver"`
+	ResolverParams map[string]any   `json:"resolverParams"`
+	Params         map[string]any   `json:"params"`
+	Workspaces     []workspaceParam `json:"workspaces"`
+	DryRun         bool             `json:"dryRun"`
 }
 
 func createPipelineRun() (*mcp.ServerTool, error) {
@@ -134,11 +158,19 @@ func createPipelineRun() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Description = "Namespace where the PipelineRun will be created"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["yaml"].Description = "YAML definition of the PipelineRun"
-	scheme.Properties["generateName"].Description = "Generate name prefix for the PipelineRun (alternative to fixed name)"
+	scheme.Properties["generateName"].Description = "Generate name prefix for the PipelineRun (alternative to fixed name), the name of the referenced Pipeline by default"
+	scheme.Properties["pipelineRef"].Description = "Name of the Pipeline in the namespace to generate the PipelineRun from"
+	scheme.Properties["resolver"].Description = resolverDescription
+	scheme.Properties["resolverParams"].Description = resolverParamsDescription
+	scheme.Properties["params"].Description = referenceParamsDescription
+	workspacesDescriptions(scheme.Properties["workspaces"])
+	scheme.Properties["dryRun"].Description = dryRunDescription
+	// Either yaml, pipelineRef or resolver is required
+	scheme.Required = nil
 
 	return mcp.NewServerTool(
 		"create_pipelinerun",
-		"Create a new PipelineRun from YAML definition or generate from Pipeline",
+		"Create a new PipelineRun from YAML definition or generate it from a Pipeline, in the namespace or resolved remotely",
 		handlerCreatePipelineRun,
 		mcp.Input(mcp.Schema(scheme)),
 	), nil
@@ -155,9 +187,15 @@ func handlerCreatePipelineRun(
 	}
 	yamlStr := params.Arguments.Yaml
 	generateName := params.Arguments.GenerateName
+	pipelineRef := params.Arguments.PipelineRef
+	resolver := params.Arguments.Resolver
 
-	if yamlStr == "" && generateName == "" {
-		return result("Error: Either YAML definition or generateName is required"), nil
+	if yamlStr == "" && pipelineRef == "" && resolver == "" {
+		return result("Error: Either YAML definition, pipelineRef or resolver is required"), nil
+	}
+	if yamlStr != "" && (pipelineRef != "" || resolver != "" || len(params.Arguments.ResolverParams) > 0 ||
+		len(params.Arguments.Params) > 0 || len(params.Arguments.Workspaces) > 0) {
+		return result("Error: yaml cannot be used together with pipelineRef, resolver, resolverParams, params or workspaces"), nil
 	}
 
 	pipelineClient := pipelineclient.Get(ctx)
@@ -168,31 +206,81 @@ func handlerCreatePipelineRun(
 			return result(fmt.Sprintf("Error parsing YAML: %v", err)), nil
 		}
 
-		created, err := pipelineClient.TektonV1().PipelineRuns(namespace).Create(ctx, &pipelineRun, metav1.CreateOptions{})
+		created, err := pipelineClient.TektonV1().PipelineRuns(namespace).Create(ctx, &pipelineRun, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 		if err != nil {
 			return result(fmt.Sprintf("Error creating PipelineRun: %v", err)), nil
 		}
+		if params.Arguments.DryRun {
+			return dryRunResult(fmt.Sprintf("PipelineRun '%s' would be created in namespace '%s'", created.Name, namespace), created)
+		}
 		return result(fmt.Sprintf("PipelineRun '%s' created successfully in namespace '%s'", created.Name, namespace)), nil
 	}
 
+	resolverRef, defaultGenerateName, err := runReference("pipelineRef", pipelineRef, "pipeline", resolver, params.Arguments.ResolverParams)
+	if err != nil {
+		return result(fmt.Sprintf("Error: %v", err)), nil
+	}
+	if generateName == "" {
+		generateName = defaultGenerateName
+	}
+
 	pipelineRun := &pipelinev1.PipelineRun{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: generateName,
 		},
+		Spec: pipelinev1.PipelineRunSpec{
+			PipelineRef: &pipelinev1.PipelineRef{
+				Name:        pipelineRef,
+				ResolverRef: resolverRef,
+			},
+		},
 	}
 
-	created, err := pipelineClient.TektonV1().PipelineRuns(namespace).Create(ctx, pipelineRun, metav1.CreateOptions{})
+	// The params and workspaces are checked against the Pipeline when it can be
+	// read, remote references being left to the controller
+	var pipeline *pipelinev1.Pipeline
+	if pipelineRef != "" {
+		if pipeline, err = scope.Pipeline(ctx, namespace, pipelineRef); err != nil {
+			pipeline = nil
+		}
+	}
+	var notes []string
+	if pipeline != nil {
+		notes, err = setPipelineRunOptions(pipelineRun, &pipeline.Spec, startPipelineParams{
+			Params:     params.Arguments.Params,
+			Workspaces: params.Arguments.Workspaces,
+		})
+		if err != nil {
+			return result(fmt.Sprintf("Error: invalid params or workspaces for Pipeline '%s': %v", pipelineRef, err)), nil
+		}
+	} else {
+		pipelineRun.Spec.Params = referenceParams(params.Arguments.Params)
+		if pipelineRun.Spec.Workspaces, err = workspaceBindings(params.Arguments.Workspaces); err != nil {
+			return result(fmt.Sprintf("Error: %v", err)), nil
+		}
+	}
+
+	created, err := pipelineClient.TektonV1().PipelineRuns(namespace).Create(ctx, pipelineRun, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error creating PipelineRun: %v", err)), nil
 	}
 
-	return result(fmt.Sprintf("PipelineRun '%s' created successfully in namespace '%s'", created.Name, namespace)), nil
+	if params.Arguments.DryRun {
+		return dryRunResult(withNotes(fmt.Sprintf("PipelineRun '%s' would be created in namespace '%s'", created.Name, namespace), notes), created)
+	}
+	return result(withNotes(fmt.Sprintf("PipelineRun '%s' created successfully in namespace '%s'", created.Name, namespace), notes)), nil
 }
 
 type createTaskRunParams struct {
-	Namespace    string `json:"namespace"`
-	Yaml         string `json:"yaml"`
-	GenerateName string `json:"generateName"`
+	Namespace      string           `json:"namespace"`
+	Yaml           string           `json:"yaml"`
+	GenerateName   string           `json:"generateName"`
+	TaskRef        string           `json:"taskRef"`
+	Resolver       string           `json:"resolver"`
+	ResolverParams map[string]any   `json:"resolverParams"`
+	Params         map[string]any   `json:"params"`
+	Workspaces     []workspaceParam `json:"workspaces"`
+	DryRun         bool             `json:"dryRun"`
 }
 
 func createTaskRun() (*mcp.ServerTool, error) {
@@ -204,11 +292,19 @@ func createTaskRun() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Description = "Namespace where the TaskRun will be created"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["yaml"].Description = "YAML definition of the TaskRun"
-	scheme.Properties["generateName"].Description = "Generate name prefix for the TaskRun (alternative to fixed name)"
+	scheme.Properties["generateName"].Description = "Generate name prefix for the TaskRun (alternative to fixed name), the name of the referenced Task by default"
+	scheme.Properties["taskRef"].Description = "Name of the Task in the namespace to generate the TaskRun from"
+	scheme.Properties["resolver"].Description = resolverDescription
+	scheme.Properties["resolverParams"].Description = resolverParamsDescription
+	scheme.Properties["params"].Description = referenceParamsDescription
+	workspacesDescriptions(scheme.Properties["workspaces"])
+	scheme.Properties["dryRun"].Description = dryRunDescription
+	// Either yaml, taskRef or resolver is required
+	scheme.Required = nil
 
 	return mcp.NewServerTool(
 		"create_taskrun",
-		"Create a new TaskRun from YAML definition",
+		"Create a new TaskRun from YAML definition or generate it from a Task, in the namespace or resolved remotely",
 		handlerCreateTaskRun,
 		mcp.Input(mcp.Schema(scheme)),
 	), nil
@@ -225,9 +321,15 @@ func handlerCreateTaskRun(
 	}
 	yamlStr := params.Arguments.Yaml
 	generateName := params.Arguments.GenerateName
+	taskRef := params.Arguments.TaskRef
+	resolver := params.Arguments.Resolver
 
-	if yamlStr == "" && generateName == "" {
-		return result("Error: Either YAML definition or generateName is required"), nil
+	if yamlStr == "" && taskRef == "" && resolver == "" {
+		return result("Error: Either YAML definition, taskRef or resolver is required"), nil
+	}
+	if yamlStr != "" && (taskRef != "" || resolver != "" || len(params.Arguments.ResolverParams) > 0 ||
+		len(params.Arguments.Params) > 0 || len(params.Arguments.Workspaces) > 0) {
+		return result("Error: yaml cannot be used together with taskRef, resolver, resolverParams, params or workspaces"), nil
 	}
 
 	pipelineClient := pipelineclient.Get(ctx)
@@ -238,23 +340,149 @@ func handlerCreateTaskRun(
 			return result(fmt.Sprintf("Error parsing YAML: %v", err)), nil
 		}
 
-		created, err := pipelineClient.TektonV1().TaskRuns(namespace).Create(ctx, &taskRun, metav1.CreateOptions{})
+		created, err := pipelineClient.TektonV1().TaskRuns(namespace).Create(ctx, &taskRun, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 		if err != nil {
 			return result(fmt.Sprintf("Error creating TaskRun: %v", err)), nil
 		}
+		if params.Arguments.DryRun {
+			return dryRunResult(fmt.Sprintf("TaskRun '%s' would be created in namespace '%s'", created.Name, namespace), created)
+		}
 		return result(fmt.Sprintf("TaskRun '%s' created successfully in namespace '%s'", created.Name, namespace)), nil
 	}
 
+	resolverRef, defaultGenerateName, err := runReference("taskRef", taskRef, "task", resolver, params.Arguments.ResolverParams)
+	if err != nil {
+		return result(fmt.Sprintf("Error: %v", err)), nil
+	}
+	if generateName == "" {
+		generateName = defaultGenerateName
+	}
+
 	taskRun := &pipelinev1.TaskRun{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: generateName,
 		},
+		Spec: pipelinev1.TaskRunSpec{
+			TaskRef: &pipelinev1.TaskRef{
+				Name:        taskRef,
+				ResolverRef: resolverRef,
+			},
+		},
+	}
+
+	// The params and workspaces are checked against the Task when it can be
+	// read, remote references being left to the controller
+	var task *pipelinev1.Task
+	if taskRef != "" {
+		if task, err = scope.Task(ctx, namespace, taskRef); err != nil {
+			task = nil
+		}
+	}
+	var notes []string
+	if task != nil {
+		notes, err = setTaskRunOptions(taskRun, &task.Spec, startTaskParams{
+			Params:     params.Arguments.Params,
+			Workspaces: params.Arguments.Workspaces,
+		})
+		if err != nil {
+			return result(fmt.Sprintf("Error: invalid params or workspaces for Task '%s': %v", taskRef, err)), nil
+		}
+	} else {
+		taskRun.Spec.Params = referenceParams(params.Arguments.Params)
+		if taskRun.Spec.Workspaces, err = workspaceBindings(params.Arguments.Workspaces); err != nil {
+			return result(fmt.Sprintf("Error: %v", err)), nil
+		}
 	}
 
-	created, err := pipelineClient.TektonV1().TaskRuns(namespace).Create(ctx, taskRun, metav1.CreateOptions{})
+	created, err := pipelineClient.TektonV1().TaskRuns(namespace).Create(ctx, taskRun, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error creating TaskRun: %v", err)), nil
 	}
 
-	return result(fmt.Sprintf("TaskRun '%s' created successfully in namespace '%s'", created.Name, namespace)), nil
+	if params.Arguments.DryRun {
+		return dryRunResult(withNotes(fmt.Sprintf("TaskRun '%s' would be created in namespace '%s'", created.Name, namespace), notes), created)
+	}
+	return result(withNotes(fmt.Sprintf("TaskRun '%s' created successfully in namespace '%s'", created.Name, namespace), notes)), nil
+}
+
+const (
+	resolverDescription = "Resolver of a remote reference to generate the run from, instead of a name: " +
+		"bundles, git, hub, cluster or any other resolver installed in the cluster"
+	resolverParamsDescription = "Params of the resolver, by name (e.g. bundle and name for bundles, " +
+		"url, revision and pathInRepo for git, name and version for hub, name and namespace for cluster)"
+	referenceParamsDescription = "Values of the params of the run, by name. Arrays and objects are passed as JSON arrays and objects. " +
+		"They are checked against the definition when it is referenced by name"
+)
+
+// kindResolvers are the resolvers taking the kind of the resolved definition
+// as a param.
+var kindResolvers = []string{"bundles", "hub", "cluster"}
+
+// invalidNameChars matches the characters not allowed in DNS-1123 labels, once
+// lower-cased.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// maxGenerateNameLength is the length of a generateName leaving room for the
+// 5 random characters appended by the API server in a DNS-1123 label.
+const maxGenerateNameLength = validation.DNS1123LabelMaxLength - 5
+
+// runReference validates the reference a run is generated from, either the
+// name of a definition in the namespace or a resolver with its params. The
+// resolver params are sorted by name and the kind param of the resolvers that
+// need it is set when missing. The default generateName of the run, based on
+// the name of the definition, is returned along.
+func runReference(field, name, kind, resolver string, resolverParams map[string]any) (pipelinev1.ResolverRef, string, error) {
+	switch {
+	case name != "" && resolver != "":
+		return pipelinev1.ResolverRef{}, "", fmt.Errorf("%s and resolver cannot be used together", field)
+	case resolver == "" && len(resolverParams) > 0:
+		return pipelinev1.ResolverRef{}, "", errors.New("resolverParams can only be used with a resolver")
+	case name != "":
+		return pipelinev1.ResolverRef{}, name + "-", nil
+	}
+
+	ref := pipelinev1.ResolverRef{Resolver: pipelinev1.ResolverName(resolver)}
+	for _, key := range slices.Sorted(maps.Keys(resolverParams)) {
+		ref.Params = append(ref.Params, pipelinev1.Param{Name: key, Value: convertToParamValue(resolverParams[key])})
+	}
+	if _, ok := resolverParams["kind"]; !ok && slices.Contains(kindResolvers, resolver) {
+		ref.Params = append(ref.Params, pipelinev1.Param{Name: "kind", Value: *pipelinev1.NewStructuredValues(kind)})
+	}
+
+	generateName := ""
+	if n, ok := resolverParams["name"].(string); ok && n != "" {
+		generateName = sanitizeGenerateName(n)
+	} else if path, ok := resolverParams["pathInRepo"].(string); ok && path != "" {
+		generateName = sanitizeGenerateName(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	}
+	if generateName == "" {
+		generateName = resolver + "-"
+	}
+	return ref, generateName, nil
+}
+
+// referenceParams converts the supplied values to Tekton params, sorted by
+// name, when the declared params are not known.
+func referenceParams(values map[string]any) pipelinev1.Params {
+	if len(values) == 0 {
+		return nil
+	}
+	params := make(pipelinev1.Params, 0, len(values))
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		params = append(params, pipelinev1.Param{Name: name, Value: convertToParamValue(values[name])})
+	}
+	return params
+}
+
+// sanitizeGenerateName returns a generateName built from the name of a
+// resolved definition: it is lower-cased, the characters not allowed in
+// DNS-1123 labels are replaced with dashes and it is truncated. An empty
+// string is returned when no valid character is left.
+func sanitizeGenerateName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name[:min(len(name), maxGenerateNameLength-1)], "-")
+	if name == "" {
+		return ""
+	}
+	return name + "-"
 }
@@ -4,9 +4,15 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
 	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktesting "k8s.io/client-go/testing"
 )
 
 func TestCreateOperations(t *testing.T) {
@@ -109,6 +115,7 @@ spec:
 			args: map[string]interface{}{
 				"namespace":    "default",
 				"generateName": "generated-pr-",
+				"pipelineRef":  "test-pipeline",
 			},
 			expected: "PipelineRun '' created successfully", // Empty name since generateName doesn't work in test
 		},
@@ -174,7 +181,52 @@ func TestCreateOperationsErrors(t *testing.T) {
 			args: map[string]interface{}{
 				"namespace": "default",
 			},
-			expected: "Error: Either YAML definition or generateName is required",
+			expected: "Error: Either YAML definition, pipelineRef or resolver is required",
+		},
+		{
+			name: "create_pipelinerun_generateName_only",
+			tool: "create_pipelinerun",
+			args: map[string]interface{}{
+				"namespace":    "default",
+				"generateName": "generated-pr-",
+			},
+			expected: "Error: Either YAML definition, pipelineRef or resolver is required",
+		},
+		{
+			name: "create_taskrun_ref_and_resolver",
+			tool: "create_taskrun",
+			args: map[string]interface{}{
+				"taskRef":  "test-task",
+				"resolver": "hub",
+			},
+			expected: "Error: taskRef and resolver cannot be used together",
+		},
+		{
+			name: "create_taskrun_resolver_params_only",
+			tool: "create_taskrun",
+			args: map[string]interface{}{
+				"taskRef":        "test-task",
+				"resolverParams": map[string]interface{}{"name": "test-task"},
+			},
+			expected: "Error: resolverParams can only be used with a resolver",
+		},
+		{
+			name: "create_pipelinerun_yaml_and_ref",
+			tool: "create_pipelinerun",
+			args: map[string]interface{}{
+				"yaml":        "metadata:\n  name: release\n",
+				"pipelineRef": "release",
+			},
+			expected: "Error: yaml cannot be used together with pipelineRef, resolver, resolverParams, params or workspaces",
+		},
+		{
+			name: "create_taskrun_yaml_and_resolver",
+			tool: "create_taskrun",
+			args: map[string]interface{}{
+				"yaml":     "metadata:\n  name: build\n",
+				"resolver": "hub",
+			},
+			expected: "Error: yaml cannot be used together with taskRef, resolver, resolverParams, params or workspaces",
 		},
 	}
 
@@ -199,3 +251,320 @@ func TestCreateOperationsErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateRunSchemas(t *testing.T) {
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, test.Data{})
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tools, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, tool := range tools.Tools {
+		if tool.Name != "create_pipelinerun" && tool.Name != "create_taskrun" {
+			continue
+		}
+		found++
+		// The run is generated from either the yaml, a reference or a resolver
+		if len(tool.InputSchema.Required) != 0 {
+			t.Errorf("%s: expected no required property, got %v", tool.Name, tool.InputSchema.Required)
+		}
+	}
+	if found != 2 {
+		t.Errorf("expected create_pipelinerun and create_taskrun, found %d tools", found)
+	}
+}
+
+func TestCreateRunFromReference(t *testing.T) {
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, test.Data{})
+	// The fake clientset does not generate names
+	for _, resource := range []string{"pipelineruns", "taskruns"} {
+		clients.Pipeline.PrependReactor("create", resource, func(action ktesting.Action) (bool, runtime.Object, error) {
+			obj := action.(ktesting.CreateAction).GetObject().(metav1.Object)
+			obj.SetName(obj.GetGenerateName() + "abcde")
+			return false, nil, nil
+		})
+	}
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     map[string]interface{}
+		expected string
+		ref      any
+	}{
+		{
+			name:     "pipelineRef",
+			tool:     "create_pipelinerun",
+			args:     map[string]interface{}{"pipelineRef": "build"},
+			expected: "PipelineRun 'build-abcde' created successfully in namespace 'default'",
+			ref:      &v1.PipelineRef{Name: "build"},
+		},
+		{
+			name: "bundles resolver",
+			tool: "create_pipelinerun",
+			args: map[string]interface{}{
+				"generateName":   "release-",
+				"resolver":       "bundles",
+				"resolverParams": map[string]interface{}{"bundle": "registry/release:v1", "name": "release"},
+			},
+			expected: "PipelineRun 'release-abcde' created successfully in namespace 'default'",
+			ref: &v1.PipelineRef{ResolverRef: v1.ResolverRef{
+				Resolver: "bundles",
+				Params: v1.Params{
+					{Name: "bundle", Value: *v1.NewStructuredValues("registry/release:v1")},
+					{Name: "name", Value: *v1.NewStructuredValues("release")},
+					{Name: "kind", Value: *v1.NewStructuredValues("pipeline")},
+				},
+			}},
+		},
+		{
+			name: "git resolver",
+			tool: "create_taskrun",
+			args: map[string]interface{}{
+				"resolver": "git",
+				"resolverParams": map[string]interface{}{
+					"url":        "https://github.com/tektoncd/catalog",
+					"revision":   "main",
+					"pathInRepo": "task/git-clone/0.9/git-clone.yaml",
+				},
+			},
+			expected: "TaskRun 'git-clone-abcde' created successfully in namespace 'default'",
+			ref: &v1.TaskRef{ResolverRef: v1.ResolverRef{
+				Resolver: "git",
+				Params: v1.Params{
+					{Name: "pathInRepo", Value: *v1.NewStructuredValues("task/git-clone/0.9/git-clone.yaml")},
+					{Name: "revision", Value: *v1.NewStructuredValues("main")},
+					{Name: "url", Value: *v1.NewStructuredValues("https://github.com/tektoncd/catalog")},
+				},
+			}},
+		},
+		{
+			name: "git resolver with an invalid file name",
+			tool: "create_taskrun",
+			args: map[string]interface{}{
+				"resolver":       "git",
+				"resolverParams": map[string]interface{}{"url": "https://github.com/org/repo", "pathInRepo": "tasks/Build_Image.yaml"},
+			},
+			expected: "TaskRun 'build-image-abcde' created successfully in namespace 'default'",
+			ref: &v1.TaskRef{ResolverRef: v1.ResolverRef{
+				Resolver: "git",
+				Params: v1.Params{
+					{Name: "pathInRepo", Value: *v1.NewStructuredValues("tasks/Build_Image.yaml")},
+					{Name: "url", Value: *v1.NewStructuredValues("https://github.com/org/repo")},
+				},
+			}},
+		},
+		{
+			name: "git resolver without a valid file name",
+			tool: "create_taskrun",
+			args: map[string]interface{}{
+				"resolver":       "git",
+				"resolverParams": map[string]interface{}{"url": "https://github.com/org/repo", "pathInRepo": "tasks/__.yaml"},
+			},
+			expected: "TaskRun 'git-abcde' created successfully in namespace 'default'",
+			ref: &v1.TaskRef{ResolverRef: v1.ResolverRef{
+				Resolver: "git",
+				Params: v1.Params{
+					{Name: "pathInRepo", Value: *v1.NewStructuredValues("tasks/__.yaml")},
+					{Name: "url", Value: *v1.NewStructuredValues("https://github.com/org/repo")},
+				},
+			}},
+		},
+		{
+			name: "hub resolver",
+			tool: "create_taskrun",
+			args: map[string]interface{}{
+				"resolver":       "hub",
+				"resolverParams": map[string]interface{}{"name": "golang-build", "version": "0.3"},
+			},
+			expected: "TaskRun 'golang-build-abcde' created successfully in namespace 'default'",
+			ref: &v1.TaskRef{ResolverRef: v1.ResolverRef{
+				Resolver: "hub",
+				Params: v1.Params{
+					{Name: "name", Value: *v1.NewStructuredValues("golang-build")},
+					{Name: "version", Value: *v1.NewStructuredValues("0.3")},
+					{Name: "kind", Value: *v1.NewStructuredValues("task")},
+				},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      test.tool,
+				Arguments: test.args,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := response.Content[0].(*mcp.TextContent)
+			if content.Text != test.expected {
+				t.Fatalf("Expected response '%s', got '%s'", test.expected, content.Text)
+			}
+
+			name := strings.Split(test.expected, "'")[1]
+			var ref any
+			if test.tool == "create_pipelinerun" {
+				pr, err := clients.Pipeline.TektonV1().PipelineRuns("default").Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ref = pr.Spec.PipelineRef
+			} else {
+				tr, err := clients.Pipeline.TektonV1().TaskRuns("default").Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ref = tr.Spec.TaskRef
+			}
+			if diff := cmp.Diff(test.ref, ref); diff != "" {
+				t.Errorf("reference mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSanitizeGenerateName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "git-clone", expected: "git-clone-"},
+		{name: "build_image", expected: "build-image-"},
+		{name: "Build.Image v2", expected: "build-image-v2-"},
+		{name: "_build_", expected: "build-"},
+		{name: "__", expected: ""},
+		{name: strings.Repeat("a", 70), expected: strings.Repeat("a", 57) + "-"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sanitizeGenerateName(test.name); got != test.expected {
+				t.Errorf("sanitizeGenerateName(%q) = %q, want %q", test.name, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestCreateRunWorkspaces(t *testing.T) {
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, test.Data{
+		Pipelines: []*v1.Pipeline{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default"},
+				Spec: v1.PipelineSpec{
+					Params: v1.ParamSpecs{{Name: "revision", Type: v1.ParamTypeString}},
+					Workspaces: []v1.PipelineWorkspaceDeclaration{
+						{Name: "source"},
+						{Name: "cache", Optional: true},
+					},
+				},
+			},
+		},
+	})
+	// The fake clientset does not generate names
+	for _, resource := range []string{"pipelineruns", "taskruns"} {
+		clients.Pipeline.PrependReactor("create", resource, func(action ktesting.Action) (bool, runtime.Object, error) {
+			obj := action.(ktesting.CreateAction).GetObject().(metav1.Object)
+			obj.SetName(obj.GetGenerateName() + "abcde")
+			return false, nil, nil
+		})
+	}
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	call := func(tool string, args map[string]any) string {
+		t.Helper()
+		response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := response.Content[0].(*mcp.TextContent)
+		return content.Text
+	}
+
+	t.Run("missing workspace", func(t *testing.T) {
+		got := call("create_pipelinerun", map[string]any{"pipelineRef": "build"})
+		for _, expected := range []string{
+			"Error: invalid params or workspaces for Pipeline 'build'",
+			`missing value for required param "revision"`,
+			`missing binding for workspace "source"`,
+		} {
+			if !strings.Contains(got, expected) {
+				t.Errorf("expected response to contain %q, got %q", expected, got)
+			}
+		}
+	})
+
+	t.Run("pipeline in the namespace", func(t *testing.T) {
+		got := call("create_pipelinerun", map[string]any{
+			"pipelineRef": "build",
+			"params":      map[string]any{"revision": "main"},
+			"workspaces":  []any{map[string]any{"name": "source", "persistentVolumeClaim": "sources"}},
+		})
+		expected := "PipelineRun 'build-abcde' created successfully in namespace 'default'\nNote: optional workspace \"cache\" is not bound"
+		if diff := cmp.Diff(expected, got); diff != "" {
+			t.Errorf("create_pipelinerun mismatch (-want +got):\n%s", diff)
+		}
+		pr, err := clients.Pipeline.TektonV1().PipelineRuns("default").Get(ctx, "build-abcde", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(v1.Params{{Name: "revision", Value: *v1.NewStructuredValues("main")}}, pr.Spec.Params); diff != "" {
+			t.Errorf("params mismatch (-want +got):\n%s", diff)
+		}
+		expectedWorkspaces := []v1.WorkspaceBinding{
+			{Name: "source", PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "sources"}},
+		}
+		if diff := cmp.Diff(expectedWorkspaces, pr.Spec.Workspaces); diff != "" {
+			t.Errorf("workspaces mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("remote task", func(t *testing.T) {
+		got := call("create_taskrun", map[string]any{
+			"resolver":       "hub",
+			"resolverParams": map[string]any{"name": "golang-build"},
+			"params":         map[string]any{"packages": []any{"./...", "./cmd/..."}},
+			"workspaces":     []any{map[string]any{"name": "source", "emptyDir": true}},
+		})
+		if got != "TaskRun 'golang-build-abcde' created successfully in namespace 'default'" {
+			t.Fatalf("unexpected response %q", got)
+		}
+		tr, err := clients.Pipeline.TektonV1().TaskRuns("default").Get(ctx, "golang-build-abcde", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(v1.Params{{Name: "packages", Value: *v1.NewStructuredValues("./...", "./cmd/...")}}, tr.Spec.Params); diff != "" {
+			t.Errorf("params mismatch (-want +got):\n%s", diff)
+		}
+		expectedWorkspaces := []v1.WorkspaceBinding{{Name: "source", EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+		if diff := cmp.Diff(expectedWorkspaces, tr.Spec.Workspaces); diff != "" {
+			t.Errorf("workspaces mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("invalid workspace of a remote task", func(t *testing.T) {
+		got := call("create_taskrun", map[string]any{
+			"resolver":       "hub",
+			"resolverParams": map[string]any{"name": "golang-build"},
+			"workspaces":     []any{map[string]any{"name": "source"}},
+		})
+		if got != `Error: workspace "source" has no volume source` {
+			t.Errorf("unexpected response %q", got)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/tektoncd/mcp-server/internal/scope"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+const taskRunKind = "TaskRun"
+
+// pipelineSpecFor returns the spec a PipelineRun was (or will be) executed with.
+// The resolved spec recorded in the status is preferred, then the embedded
+// spec, and finally the referenced Pipeline from the informer cache.
+func pipelineSpecFor(ctx context.Context, pr *v1.PipelineRun) (*v1.PipelineSpec, error) {
+	if pr.Status.PipelineSpec != nil {
+		return pr.Status.PipelineSpec, nil
+	}
+	if pr.Spec.PipelineSpec != nil {
+		return pr.Spec.PipelineSpec, nil
+	}
+	if pr.Spec.PipelineRef == nil || pr.Spec.PipelineRef.Name == "" {
+		return nil, fmt.Errorf("PipelineRun %s/%s has no resolved pipeline spec", pr.Namespace, pr.Name)
+	}
+
+	pipeline, err := scope.Pipeline(ctx, pr.Namespace, pr.Spec.PipelineRef.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Pipeline %s/%s: %w", pr.Namespace, pr.Spec.PipelineRef.Name, err)
+	}
+	return &pipeline.Spec, nil
+}
+
+// dagOrder returns the names of the pipeline tasks in a topological order of
+// the DAG built from runAfter and result references. Ties are broken by the
+// declaration order, and finally tasks are appended at the end.
+func dagOrder(spec *v1.PipelineSpec) []string {
+	deps := v1.PipelineTaskList(spec.Tasks).Deps()
+
+	done := make(map[string]bool, len(spec.Tasks))
+	order := make([]string, 0, len(spec.Tasks)+len(spec.Finally))
+	for len(order) < len(spec.Tasks) {
+		progressed := false
+		for _, pt := range spec.Tasks {
+			if done[pt.Name] {
+				continue
+			}
+			ready := true
+			for _, dep := range deps[pt.Name] {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[pt.Name] = true
+				order = append(order, pt.Name)
+				progressed = true
+			}
+		}
+		if !progressed {
+			// Invalid graph (cycle or unknown dependency), keep the declaration order
+			for _, pt := range spec.Tasks {
+				if !done[pt.Name] {
+					done[pt.Name] = true
+					order = append(order, pt.Name)
+				}
+			}
+		}
+	}
+
+	for _, pt := range spec.Finally {
+		order = append(order, pt.Name)
+	}
+	return order
+}
+
+// orderedChildReferences returns the child references of a PipelineRun sorted
+// following the given pipeline task order. Children of unknown pipeline tasks
+// keep their relative order and are placed last.
+func orderedChildReferences(pr *v1.PipelineRun, order []string) []v1.ChildStatusReference {
+	children := slices.Clone(pr.Status.ChildReferences)
+	index := func(child v1.ChildStatusReference) int {
+		if i := slices.Index(order, child.PipelineTaskName); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(children, func(a, b v1.ChildStatusReference) int {
+		return index(a) - index(b)
+	})
+	return children
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -14,6 +15,7 @@ import (
 type deletePipelineParams struct {
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func deletePipeline() (*mcp.ServerTool, error) {
@@ -25,6 +27,7 @@ func deletePipeline() (*mcp.ServerTool, error) {
 	scheme.Properties["name"].Description = "Name of the Pipeline to delete"
 	scheme.Properties["namespace"].Description = "Namespace of the Pipeline"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
@@ -51,17 +54,21 @@ func handlerDeletePipeline(
 	}
 
 	pipelineClient := pipelineclient.Get(ctx)
-	err := pipelineClient.TektonV1().Pipelines(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := pipelineClient.TektonV1().Pipelines(namespace).Delete(ctx, name, metav1.DeleteOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error deleting Pipeline: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return result(fmt.Sprintf("Dry run, nothing was persisted. Pipeline '%s' would be deleted from namespace '%s'", name, namespace)), nil
+	}
 	return result(fmt.Sprintf("Pipeline '%s' deleted successfully from namespace '%s'", name, namespace)), nil
 }
 
 type deleteTaskParams struct {
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func deleteTask() (*mcp.ServerTool, error) {
@@ -73,6 +80,7 @@ func deleteTask() (*mcp.ServerTool, error) {
 	scheme.Properties["name"].Description = "Name of the Task to delete"
 	scheme.Properties["namespace"].Description = "Namespace of the Task"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
@@ -99,17 +107,21 @@ func handlerDeleteTask(
 	}
 
 	pipelineClient := pipelineclient.Get(ctx)
-	err := pipelineClient.TektonV1().Tasks(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := pipelineClient.TektonV1().Tasks(namespace).Delete(ctx, name, metav1.DeleteOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error deleting Task: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return result(fmt.Sprintf("Dry run, nothing was persisted. Task '%s' would be deleted from namespace '%s'", name, namespace)), nil
+	}
 	return result(fmt.Sprintf("Task '%s' deleted successfully from namespace '%s'", name, namespace)), nil
 }
 
 type deletePipelineRunParams struct {
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func deletePipelineRun() (*mcp.ServerTool, error) {
@@ -121,6 +133,7 @@ func deletePipelineRun() (*mcp.ServerTool, error) {
 	scheme.Properties["name"].Description = "Name of the PipelineRun to delete"
 	scheme.Properties["namespace"].Description = "Namespace of the PipelineRun"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
@@ -147,17 +160,21 @@ func handlerDeletePipelineRun(
 	}
 
 	pipelineClient := pipelineclient.Get(ctx)
-	err := pipelineClient.TektonV1().PipelineRuns(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := pipelineClient.TektonV1().PipelineRuns(namespace).Delete(ctx, name, metav1.DeleteOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error deleting PipelineRun: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return result(fmt.Sprintf("Dry run, nothing was persisted. PipelineRun '%s' would be deleted from namespace '%s'", name, namespace)), nil
+	}
 	return result(fmt.Sprintf("PipelineRun '%s' deleted successfully from namespace '%s'", name, namespace)), nil
 }
 
 type deleteTaskRunParams struct {
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func deleteTaskRun() (*mcp.ServerTool, error) {
@@ -169,6 +186,7 @@ func deleteTaskRun() (*mcp.ServerTool, error) {
 	scheme.Properties["name"].Description = "Name of the TaskRun to delete"
 	scheme.Properties["namespace"].Description = "Namespace of the TaskRun"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
@@ -195,11 +213,14 @@ func handlerDeleteTaskRun(
 	}
 
 	pipelineClient := pipelineclient.Get(ctx)
-	err := pipelineClient.TektonV1().TaskRuns(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := pipelineClient.TektonV1().TaskRuns(namespace).Delete(ctx, name, metav1.DeleteOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error deleting TaskRun: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return result(fmt.Sprintf("Dry run, nothing was persisted. TaskRun '%s' would be deleted from namespace '%s'", name, namespace)), nil
+	}
 	return result(fmt.Sprintf("TaskRun '%s' deleted successfully from namespace '%s'", name, namespace)), nil
 }
 
@@ -207,6 +228,7 @@ type deleteAllPipelineRunsParams struct {
 	Namespace     string `json:"namespace"`
 	LabelSelector string `json:"labelSelector"`
 	FieldSelector string `json:"fieldSelector"`
+	DryRun        bool   `json:"dryRun"`
 }
 
 func deleteAllPipelineRuns() (*mcp.ServerTool, error) {
@@ -217,6 +239,7 @@ func deleteAllPipelineRuns() (*mcp.ServerTool, error) {
 
 	scheme.Properties["namespace"].Description = "Namespace to delete PipelineRuns from"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Properties["labelSelector"].Description = "Label selector to filter PipelineRuns to delete"
 	scheme.Properties["fieldSelector"].Description = "Field selector to filter PipelineRuns to delete"
 
@@ -242,7 +265,7 @@ func handlerDeleteAllPipelineRuns(
 
 	pipelineClient := pipelineclient.Get(ctx)
 
-	deleteOptions := metav1.DeleteOptions{}
+	deleteOptions := metav1.DeleteOptions{DryRun: dryRunOption(params.Arguments.DryRun)}
 	listOptions := metav1.ListOptions{
 		LabelSelector: labelSelector,
 		FieldSelector: fieldSelector,
@@ -253,5 +276,19 @@ func handlerDeleteAllPipelineRuns(
 		return result(fmt.Sprintf("Error deleting PipelineRuns: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		// DeleteCollection does not return the deleted objects
+		prs, err := pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, listOptions)
+		if err != nil {
+			return result(fmt.Sprintf("Error listing PipelineRuns: %v", err)), nil
+		}
+		names := make([]string, 0, len(prs.Items))
+		for _, pr := range prs.Items {
+			names = append(names, pr.Name)
+		}
+		return result(fmt.Sprintf("Dry run, nothing was persisted. %d PipelineRuns would be deleted from namespace '%s': %s",
+			len(names), namespace, strings.Join(names, ", "))), nil
+	}
+
 	return result(fmt.Sprintf("PipelineRuns deleted successfully from namespace '%s' with selectors", namespace)), nil
 }
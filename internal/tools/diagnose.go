@@ -0,0 +1,323 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/apis"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+const (
+	kindPipelineRun = "pipelinerun"
+	kindTaskRun     = "taskrun"
+
+	defaultDiagnoseTailLines = 20
+)
+
+type diagnoseRunParams struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	TailLines int64  `json:"tailLines"`
+	Output    string `json:"output"`
+}
+
+// runDiagnosis is the root-cause report of a PipelineRun or TaskRun.
+type runDiagnosis struct {
+	Kind      string           `json:"kind"`
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	Summary   string           `json:"summary"`
+	Condition *conditionReport `json:"condition,omitempty"`
+	// FailedTasks holds the failed TaskRuns of a PipelineRun, or the TaskRun itself
+	FailedTasks  []taskRunDiagnosis `json:"failedTasks,omitempty"`
+	SkippedTasks []string           `json:"skippedTasks,omitempty"`
+}
+
+type conditionReport struct {
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type taskRunDiagnosis struct {
+	PipelineTask string           `json:"pipelineTask,omitempty"`
+	TaskRun      string           `json:"taskRun"`
+	Condition    *conditionReport `json:"condition,omitempty"`
+	Retries      int              `json:"retries,omitempty"`
+	PodName      string           `json:"podName,omitempty"`
+	FailedSteps  []stepDiagnosis  `json:"failedSteps,omitempty"`
+	Events       []eventReport    `json:"events,omitempty"`
+}
+
+type stepDiagnosis struct {
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	ExitCode  int32  `json:"exitCode"`
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Logs      string `json:"logs,omitempty"`
+}
+
+type eventReport struct {
+	Object  string `json:"object"`
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Count   int32  `json:"count,omitempty"`
+}
+
+func diagnoseRun() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[diagnoseRunParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["kind"].Description = "Kind of the run to diagnose (pipelinerun or taskrun)"
+	scheme.Properties["kind"].Default = json.RawMessage(`"pipelinerun"`)
+	scheme.Properties["name"].Description = "Name of the run to diagnose"
+	scheme.Properties["namespace"].Description = "Namespace of the run"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["tailLines"].Description = "Number of log lines to include for each failed step"
+	scheme.Properties["tailLines"].Default = json.RawMessage(`20`)
+	scheme.Properties["output"].Description = outputFormatDescription
+	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"diagnose_run",
+		"Diagnose a failed PipelineRun or TaskRun: failing tasks and steps, exit codes, conditions, pod events and the last log lines of the failing containers",
+		handlerDiagnoseRun,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerDiagnoseRun(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[diagnoseRunParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	kind := strings.ToLower(params.Arguments.Kind)
+	if kind == "" {
+		kind = kindPipelineRun
+	}
+	tailLines := params.Arguments.TailLines
+	if tailLines <= 0 {
+		tailLines = defaultDiagnoseTailLines
+	}
+
+	var (
+		diagnosis *runDiagnosis
+		err       error
+	)
+	switch kind {
+	case kindPipelineRun:
+		diagnosis, err = diagnosePipelineRun(ctx, namespace, name, tailLines)
+	case kindTaskRun:
+		diagnosis, err = diagnoseTaskRun(ctx, namespace, name, tailLines)
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, expected %s or %s", params.Arguments.Kind, kindPipelineRun, kindTaskRun)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := marshalOutput(diagnosis, params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
+	return result(out), nil
+}
+
+func diagnosePipelineRun(ctx context.Context, namespace, name string, tailLines int64) (*runDiagnosis, error) {
+	pr, err := scope.PipelineRun(ctx, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
+	}
+
+	succeeded := pr.Status.GetCondition(apis.ConditionSucceeded)
+	diagnosis := &runDiagnosis{
+		Kind:      "PipelineRun",
+		Name:      pr.Name,
+		Namespace: pr.Namespace,
+		Condition: newConditionReport(succeeded),
+	}
+	for _, skipped := range pr.Status.SkippedTasks {
+		diagnosis.SkippedTasks = append(diagnosis.SkippedTasks, fmt.Sprintf("%s (%s)", skipped.Name, skipped.Reason))
+	}
+
+	var order []string
+	if spec, err := pipelineSpecFor(ctx, pr); err == nil {
+		order = dagOrder(spec)
+	}
+	for _, child := range orderedChildReferences(pr, order) {
+		if child.Kind != taskRunKind {
+			continue
+		}
+		tr, err := scope.TaskRun(ctx, namespace, child.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, child.Name, err)
+		}
+		if !tr.Status.GetCondition(apis.ConditionSucceeded).IsFalse() {
+			continue
+		}
+		trDiagnosis := diagnoseTaskRunStatus(ctx, tr, tailLines)
+		trDiagnosis.PipelineTask = child.PipelineTaskName
+		diagnosis.FailedTasks = append(diagnosis.FailedTasks, trDiagnosis)
+	}
+
+	diagnosis.Summary = summarize("PipelineRun", succeeded, diagnosis.FailedTasks)
+	return diagnosis, nil
+}
+
+func diagnoseTaskRun(ctx context.Context, namespace, name string, tailLines int64) (*runDiagnosis, error) {
+	tr, err := scope.TaskRun(ctx, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, name, err)
+	}
+
+	succeeded := tr.Status.GetCondition(apis.ConditionSucceeded)
+	diagnosis := &runDiagnosis{
+		Kind:      "TaskRun",
+		Name:      tr.Name,
+		Namespace: tr.Namespace,
+		Condition: newConditionReport(succeeded),
+	}
+	if succeeded.IsFalse() {
+		diagnosis.FailedTasks = []taskRunDiagnosis{diagnoseTaskRunStatus(ctx, tr, tailLines)}
+	}
+
+	diagnosis.Summary = summarize("TaskRun", succeeded, diagnosis.FailedTasks)
+	return diagnosis, nil
+}
+
+// diagnoseTaskRunStatus collects the failed steps, their last log lines and
+// the events of a failed TaskRun and of its pod. Errors while fetching logs
+// and events are reported inline, the status being the primary source.
+func diagnoseTaskRunStatus(ctx context.Context, tr *pipelinev1.TaskRun, tailLines int64) taskRunDiagnosis {
+	diagnosis := taskRunDiagnosis{
+		TaskRun:   tr.Name,
+		Condition: newConditionReport(tr.Status.GetCondition(apis.ConditionSucceeded)),
+		Retries:   len(tr.Status.RetriesStatus),
+		PodName:   tr.Status.PodName,
+	}
+
+	kubeclientset := kubeclient.Get(ctx)
+	pods := kubeclientset.CoreV1().Pods(tr.Namespace)
+	for _, step := range tr.Status.Steps {
+		if step.Terminated == nil || step.Terminated.ExitCode == 0 {
+			continue
+		}
+		stepDiagnosis := stepDiagnosis{
+			Name:      step.Name,
+			Container: step.Container,
+			ExitCode:  step.Terminated.ExitCode,
+			Reason:    step.Terminated.Reason,
+			Message:   step.Terminated.Message,
+		}
+		if tr.Status.PodName != "" {
+			stepDiagnosis.Logs = containerLogs(ctx, pods, tr.Status.PodName, step.Container, logOptions{tailLines: tailLines})
+		}
+		diagnosis.FailedSteps = append(diagnosis.FailedSteps, stepDiagnosis)
+	}
+
+	objects := []string{tr.Name}
+	if tr.Status.PodName != "" {
+		objects = append(objects, tr.Status.PodName)
+	}
+	diagnosis.Events = objectEvents(ctx, kubeclientset, tr.Namespace, objects)
+	return diagnosis
+}
+
+// objectEvents returns the Kubernetes Events of the given objects, oldest
+// first.
+func objectEvents(ctx context.Context, client kubernetes.Interface, namespace string, objects []string) []eventReport {
+	var reports []eventReport
+	for _, object := range objects {
+		events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("involvedObject.name", object).String(),
+		})
+		if err != nil {
+			reports = append(reports, eventReport{
+				Object:  object,
+				Type:    "Error",
+				Reason:  "ListFailed",
+				Message: fmt.Sprintf("failed to list events: %v", err),
+			})
+			continue
+		}
+		items := slices.Clone(events.Items)
+		slices.SortStableFunc(items, func(a, b corev1.Event) int {
+			return a.LastTimestamp.Compare(b.LastTimestamp.Time)
+		})
+		for _, event := range items {
+			// The field selector is not honoured by every client
+			if event.InvolvedObject.Name != object {
+				continue
+			}
+			reports = append(reports, eventReport{
+				Object:  fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, object),
+				Type:    event.Type,
+				Reason:  event.Reason,
+				Message: event.Message,
+				Count:   event.Count,
+			})
+		}
+	}
+	return reports
+}
+
+func newConditionReport(c *apis.Condition) *conditionReport {
+	if c == nil {
+		return nil
+	}
+	return &conditionReport{
+		Status:  string(c.Status),
+		Reason:  c.Reason,
+		Message: c.Message,
+	}
+}
+
+// summarize returns a one line description of the outcome of a run, naming
+// the first failing task and step.
+func summarize(kind string, succeeded *apis.Condition, failed []taskRunDiagnosis) string {
+	switch {
+	case succeeded.IsTrue():
+		return fmt.Sprintf("%s succeeded", kind)
+	case succeeded.IsUnknown():
+		return fmt.Sprintf("%s has not completed yet (%s)", kind, conditionReason(succeeded))
+	}
+
+	summary := fmt.Sprintf("%s failed (%s)", kind, succeeded.Reason)
+	if len(failed) == 0 {
+		return summary
+	}
+	first := failed[0]
+	if first.PipelineTask != "" {
+		summary += fmt.Sprintf(", pipeline task %s (TaskRun %s) failed", first.PipelineTask, first.TaskRun)
+	}
+	if len(first.FailedSteps) > 0 {
+		step := first.FailedSteps[0]
+		summary += fmt.Sprintf(" in step %s with exit code %d", step.Name, step.ExitCode)
+	} else if first.PipelineTask != "" && first.Condition != nil && first.Condition.Reason != "" {
+		summary += fmt.Sprintf(" with reason %s", first.Condition.Reason)
+	}
+	return summary
+}
@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+func TestDiagnoseRun(t *testing.T) {
+	failed := func(reason, message string) duckv1.Status {
+		return duckv1.Status{Conditions: duckv1.Conditions{{
+			Type:    apis.ConditionSucceeded,
+			Status:  corev1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		}}}
+	}
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+				Status: v1.PipelineRunStatus{
+					Status: failed("Failed", "Tasks Completed: 2 (Failed: 1, Cancelled 0), Skipped: 1"),
+					PipelineRunStatusFields: v1.PipelineRunStatusFields{
+						PipelineSpec: &v1.PipelineSpec{Tasks: []v1.PipelineTask{
+							{Name: "build"},
+							{Name: "test", RunAfter: []string{"build"}},
+							{Name: "publish", RunAfter: []string{"test"}},
+						}},
+						ChildReferences: []v1.ChildStatusReference{
+							{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-test", PipelineTaskName: "test"},
+							{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-build", PipelineTaskName: "build"},
+						},
+						SkippedTasks: []v1.SkippedTask{{Name: "publish", Reason: v1.StoppingSkip}},
+					},
+				},
+			},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-build", Namespace: "default"},
+				Status: v1.TaskRunStatus{
+					Status: duckv1.Status{Conditions: duckv1.Conditions{{
+						Type:   apis.ConditionSucceeded,
+						Status: corev1.ConditionTrue,
+						Reason: "Succeeded",
+					}}},
+					TaskRunStatusFields: v1.TaskRunStatusFields{PodName: "release-build-pod"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-test", Namespace: "default"},
+				Status: v1.TaskRunStatus{
+					Status: failed("Failed", `"step-unit-test" exited with code 1`),
+					TaskRunStatusFields: v1.TaskRunStatusFields{
+						PodName: "release-test-pod",
+						Steps: []v1.StepState{
+							{
+								Name:           "lint",
+								Container:      "step-lint",
+								ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"}},
+							},
+							{
+								Name:           "unit-test",
+								Container:      "step-unit-test",
+								ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		Pods: []*corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "release-test-pod", Namespace: "default"}},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	for _, event := range []*corev1.Event{
+		{
+			ObjectMeta:     metav1.ObjectMeta{Name: "release-test-pod.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "release-test-pod"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off pulling image",
+			Count:          2,
+		},
+		{
+			ObjectMeta:     metav1.ObjectMeta{Name: "release-build-pod.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "release-build-pod"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Pulled",
+		},
+	} {
+		if _, err := clients.Kube.CoreV1().Events("default").Create(ctx, event, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kubeclientset := &fakeClient{
+		Clientset: clients.Kube,
+		logs: map[string]map[string]string{
+			"release-test-pod": {
+				"step-lint":      "ok",
+				"step-unit-test": "=== RUN TestFoo\n--- FAIL: TestFoo\nFAIL",
+			},
+		},
+	}
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeclientset)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	failedTest := taskRunDiagnosis{
+		TaskRun:   "release-test",
+		Condition: &conditionReport{Status: "False", Reason: "Failed", Message: `"step-unit-test" exited with code 1`},
+		PodName:   "release-test-pod",
+		FailedSteps: []stepDiagnosis{{
+			Name:      "unit-test",
+			Container: "step-unit-test",
+			ExitCode:  1,
+			Reason:    "Error",
+			Logs:      "--- FAIL: TestFoo\nFAIL",
+		}},
+		Events: []eventReport{{
+			Object:  "Pod/release-test-pod",
+			Type:    "Warning",
+			Reason:  "BackOff",
+			Message: "Back-off pulling image",
+			Count:   2,
+		}},
+	}
+	failedPipelineTest := failedTest
+	failedPipelineTest.PipelineTask = "test"
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		expected  runDiagnosis
+	}{
+		{
+			name:      "pipelinerun",
+			arguments: map[string]any{"name": "release", "tailLines": 2, "output": "json"},
+			expected: runDiagnosis{
+				Kind:         "PipelineRun",
+				Name:         "release",
+				Namespace:    "default",
+				Summary:      "PipelineRun failed (Failed), pipeline task test (TaskRun release-test) failed in step unit-test with exit code 1",
+				Condition:    &conditionReport{Status: "False", Reason: "Failed", Message: "Tasks Completed: 2 (Failed: 1, Cancelled 0), Skipped: 1"},
+				FailedTasks:  []taskRunDiagnosis{failedPipelineTest},
+				SkippedTasks: []string{"publish (PipelineRun was stopping)"},
+			},
+		},
+		{
+			name:      "taskrun",
+			arguments: map[string]any{"kind": "taskrun", "name": "release-test", "tailLines": 2, "output": "json"},
+			expected: runDiagnosis{
+				Kind:        "TaskRun",
+				Name:        "release-test",
+				Namespace:   "default",
+				Summary:     "TaskRun failed (Failed) in step unit-test with exit code 1",
+				Condition:   failedTest.Condition,
+				FailedTasks: []taskRunDiagnosis{failedTest},
+			},
+		},
+		{
+			name:      "succeeded taskrun",
+			arguments: map[string]any{"kind": "TaskRun", "name": "release-build", "output": "json"},
+			expected: runDiagnosis{
+				Kind:      "TaskRun",
+				Name:      "release-build",
+				Namespace: "default",
+				Summary:   "TaskRun succeeded",
+				Condition: &conditionReport{Status: "True", Reason: "Succeeded"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      "diagnose_run",
+				Arguments: test.arguments,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			text, _ := response.Content[0].(*mcp.TextContent)
+			var received runDiagnosis
+			if err := json.Unmarshal([]byte(text.Text), &received); err != nil {
+				t.Fatalf("failed to unmarshal diagnosis: %v\n%s", err, text.Text)
+			}
+			if diff := cmp.Diff(test.expected, received); diff != "" {
+				t.Errorf("diagnose_run mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("unsupported kind", func(t *testing.T) {
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "diagnose_run",
+			Arguments: map[string]any{"kind": "pipeline", "name": "release"},
+		})
+		if err == nil || !strings.Contains(err.Error(), `unsupported kind "pipeline"`) {
+			t.Fatalf("expected unsupported kind error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"slices"
+	"strings"
+)
+
+const diffContextLines = 3
+
+// diffLine is a line of a diff, op being ' ' for an unchanged line, '-' for a
+// removed line and '+' for an added line.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// lineDiff returns a unified-like diff of two texts, with the removed lines
+// prefixed by "-", the added lines by "+" and up to diffContextLines
+// unchanged lines around each change. Hunks are separated by "...". An empty
+// string is returned when the texts are identical.
+func lineDiff(a, b string) string {
+	before := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	after := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	d := &differ{before: before, after: after}
+	d.compare(0, len(before), 0, len(after))
+	lines := d.lines
+	if !slices.ContainsFunc(lines, func(l diffLine) bool { return l.op != ' ' }) {
+		return ""
+	}
+	groupChanges(lines)
+
+	// Keep the changed lines and their context
+	keep := make([]bool, len(lines))
+	for k, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for c := max(0, k-diffContextLines); c <= min(len(lines)-1, k+diffContextLines); c++ {
+			keep[c] = true
+		}
+	}
+
+	var sb strings.Builder
+	skipped := false
+	for k, l := range lines {
+		if !keep[k] {
+			skipped = true
+			continue
+		}
+		if skipped && sb.Len() > 0 {
+			sb.WriteString("...\n")
+		}
+		skipped = false
+		sb.WriteByte(l.op)
+		sb.WriteByte(' ')
+		sb.WriteString(l.text)
+		sb.WriteByte('\n')
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// differ computes the shortest edit script of two lists of lines with the
+// linear space variant of the Myers algorithm: the paths of the edit script
+// are searched from both ends at once until they overlap, and the lists on
+// each side of the overlap are compared recursively. Time is O((N+M)D) and
+// space O(N+M), D being the number of changed lines.
+type differ struct {
+	before, after []string
+	lines         []diffLine
+}
+
+// compare appends the diff of before[aLo:aHi] and after[bLo:bHi] to the lines.
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.before[aLo] == d.after[bLo] {
+		d.lines = append(d.lines, diffLine{' ', d.before[aLo]})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aHi-suffix > aLo && bHi-suffix > bLo && d.before[aHi-suffix-1] == d.after[bHi-suffix-1] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	x, y, ok := d.split(aLo, aHi, bLo, bHi)
+	if ok {
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	} else {
+		for _, text := range d.before[aLo:aHi] {
+			d.lines = append(d.lines, diffLine{'-', text})
+		}
+		for _, text := range d.after[bLo:bHi] {
+			d.lines = append(d.lines, diffLine{'+', text})
+		}
+	}
+
+	for _, text := range d.before[aHi : aHi+suffix] {
+		d.lines = append(d.lines, diffLine{' ', text})
+	}
+}
+
+// split returns a point of the shortest edit script of before[aLo:aHi] and
+// after[bLo:bHi], whose first and last lines differ, where the paths searched
+// from the start and from the end overlap. No point is returned when one of
+// the lists is empty.
+func (d *differ) split(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+
+	// forward[offset+k] is the furthest x reached on diagonal k = x - y from
+	// the start, backward[offset+k] the furthest x reached from the end, on
+	// the reversed lists. Paths leaving the lists shrink the diagonals to
+	// search.
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	var fStart, fEnd, bStart, bEnd int
+	for e := 0; e < maxD; e++ {
+		for k := -e + fStart; k <= e-fEnd; k += 2 {
+			var x int
+			if k == -e || (k != e && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.before[aLo+x] == d.after[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if i := offset + delta - k; i >= 0 && i < len(backward) && backward[i] != -1 && x >= n-backward[i] {
+					return aLo + x, bLo + y, true
+				}
+			}
+		}
+		for k := -e + bStart; k <= e-bEnd; k += 2 {
+			var x int
+			if k == -e || (k != e && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.before[aHi-x-1] == d.after[bHi-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if i := offset + delta - k; i >= 0 && i < len(forward) && forward[i] != -1 {
+					fx := forward[i]
+					if fx >= n-x {
+						return aLo + fx, bLo + fx - (i - offset), true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// groupChanges reorders the lines of each change so that the removed lines
+// come before the added ones.
+func groupChanges(lines []diffLine) {
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lines) && lines[end].op != ' ' {
+			end++
+		}
+		slices.SortStableFunc(lines[start:end], func(a, b diffLine) int {
+			// '-' sorts after '+' in ASCII
+			return int(b.op) - int(a.op)
+		})
+		start = end
+	}
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{
+			name:   "identical",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   "",
+		},
+		{
+			name:   "changed line",
+			before: "a\nb\nc\n",
+			after:  "a\nB\nc\n",
+			want:   "  a\n- b\n+ B\n  c",
+		},
+		{
+			name:   "hunks",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			after:  "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			want:   "+ 0\n  1\n  2\n  3\n...\n  7\n  8\n  9\n- 10",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, lineDiff(test.before, test.after)); diff != "" {
+				t.Errorf("lineDiff mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDifferShortestEditScript(t *testing.T) {
+	// The edit scripts of random lists over a small alphabet are checked to
+	// rebuild both lists with as few changes as the longest common subsequence
+	// allows
+	r := rand.New(rand.NewPCG(1, 2))
+	random := func() []string {
+		lines := make([]string, r.IntN(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.IntN(4)))
+		}
+		return lines
+	}
+	for range 2000 {
+		before, after := random(), random()
+		d := &differ{before: before, after: after}
+		d.compare(0, len(before), 0, len(after))
+
+		var gotBefore, gotAfter []string
+		changes := 0
+		for _, l := range d.lines {
+			if l.op != '+' {
+				gotBefore = append(gotBefore, l.text)
+			}
+			if l.op != '-' {
+				gotAfter = append(gotAfter, l.text)
+			}
+			if l.op != ' ' {
+				changes++
+			}
+		}
+		if !slices.Equal(before, gotBefore) || !slices.Equal(after, gotAfter) {
+			t.Fatalf("edit script of %q and %q does not rebuild them: %v", before, after, d.lines)
+		}
+		if want := len(before) + len(after) - 2*lcsLength(before, after); changes != want {
+			t.Fatalf("edit script of %q and %q has %d changes, want %d", before, after, changes, want)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
@@ -0,0 +1,561 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/yaml"
+)
+
+// The changes of a value between the base and the target run.
+const (
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeChanged = "changed"
+)
+
+type diffRunsParams struct {
+	Kind      string `json:"kind"`
+	Base      string `json:"base"`
+	Target    string `json:"target"`
+	Namespace string `json:"namespace"`
+	Output    string `json:"output"`
+}
+
+// runDiff is the difference between two PipelineRuns or TaskRuns, only
+// holding what changed from the base run to the target run.
+type runDiff struct {
+	Kind           string        `json:"kind"`
+	Namespace      string        `json:"namespace"`
+	Base           string        `json:"base"`
+	Target         string        `json:"target"`
+	Summary        string        `json:"summary"`
+	Status         *valueChange  `json:"status,omitempty"`
+	Duration       *valueChange  `json:"duration,omitempty"`
+	ServiceAccount *valueChange  `json:"serviceAccount,omitempty"`
+	Params         []valueChange `json:"params,omitempty"`
+	Workspaces     []valueChange `json:"workspaces,omitempty"`
+	// Spec is a line diff of the resolved pipeline or task spec, as YAML
+	Spec    string        `json:"spec,omitempty"`
+	Results []valueChange `json:"results,omitempty"`
+	// Tasks holds the pipeline tasks of PipelineRuns that changed
+	Tasks []taskDiff `json:"tasks,omitempty"`
+	// Steps holds the steps of TaskRuns that changed
+	Steps []stepDiff `json:"steps,omitempty"`
+}
+
+type valueChange struct {
+	Name   string `json:"name,omitempty"`
+	Change string `json:"change"`
+	Base   string `json:"base,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+type taskDiff struct {
+	PipelineTask  string        `json:"pipelineTask"`
+	Change        string        `json:"change"`
+	BaseTaskRun   string        `json:"baseTaskRun,omitempty"`
+	TargetTaskRun string        `json:"targetTaskRun,omitempty"`
+	Status        *valueChange  `json:"status,omitempty"`
+	Duration      *valueChange  `json:"duration,omitempty"`
+	Results       []valueChange `json:"results,omitempty"`
+	Steps         []stepDiff    `json:"steps,omitempty"`
+}
+
+type stepDiff struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+	// Image is the resolved image digest of the step
+	Image    *valueChange `json:"image,omitempty"`
+	ExitCode *valueChange `json:"exitCode,omitempty"`
+	Duration *valueChange `json:"duration,omitempty"`
+}
+
+// taskOutcome is a pipeline task of a PipelineRun, its TaskRun being nil when
+// it was skipped or pruned.
+type taskOutcome struct {
+	status  string
+	taskRun *v1.TaskRun
+}
+
+func diffRuns() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[diffRunsParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["kind"].Description = "Kind of the runs to compare (pipelinerun or taskrun)"
+	scheme.Properties["kind"].Enum = []any{kindPipelineRun, kindTaskRun}
+	scheme.Properties["kind"].Default = json.RawMessage(`"pipelinerun"`)
+	scheme.Properties["base"].Description = "Name of the run to compare from, such as the last run that succeeded"
+	scheme.Properties["target"].Description = "Name of the run to compare to, such as a run that failed"
+	scheme.Properties["namespace"].Description = "Namespace of the runs"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["output"].Description = outputFormatDescription
+	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Required = []string{"base", "target"}
+
+	return mcp.NewServerTool(
+		"diff_runs",
+		"Compare two PipelineRuns or TaskRuns and return what changed: status, duration, params, service account, "+
+			"workspaces, resolved spec, results, and for each task its outcome, results, step image digests and durations",
+		handlerDiffRuns,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerDiffRuns(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[diffRunsParams],
+) (*mcp.CallToolResultFor[string], error) {
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	kind := strings.ToLower(params.Arguments.Kind)
+	if kind == "" {
+		kind = kindPipelineRun
+	}
+
+	var (
+		diff *runDiff
+		err  error
+	)
+	switch kind {
+	case kindPipelineRun:
+		diff, err = diffPipelineRuns(ctx, namespace, params.Arguments.Base, params.Arguments.Target)
+	case kindTaskRun:
+		diff, err = diffTaskRuns(ctx, namespace, params.Arguments.Base, params.Arguments.Target)
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, expected %s or %s", params.Arguments.Kind, kindPipelineRun, kindTaskRun)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := marshalOutput(diff, params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
+	return result(out), nil
+}
+
+func diffPipelineRuns(ctx context.Context, namespace, base, target string) (*runDiff, error) {
+	a, err := scope.PipelineRun(ctx, namespace, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, base, err)
+	}
+	b, err := scope.PipelineRun(ctx, namespace, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, target, err)
+	}
+
+	diff := &runDiff{
+		Kind:      kindPipelineRun,
+		Namespace: namespace,
+		Base:      base,
+		Target:    target,
+		Status: compareValue("",
+			runStatus(a.Status.GetCondition(apis.ConditionSucceeded)),
+			runStatus(b.Status.GetCondition(apis.ConditionSucceeded))),
+		Duration: compareValue("",
+			runDuration(a.Status.StartTime, a.Status.CompletionTime),
+			runDuration(b.Status.StartTime, b.Status.CompletionTime)),
+		ServiceAccount: compareValue("", a.Spec.TaskRunTemplate.ServiceAccountName, b.Spec.TaskRunTemplate.ServiceAccountName),
+		Params:         compareValues(paramValues(a.Spec.Params), paramValues(b.Spec.Params)),
+		Workspaces:     compareValues(workspaceValues(a.Spec.Workspaces), workspaceValues(b.Spec.Workspaces)),
+		Results:        compareValues(pipelineRunResultValues(a.Status.Results), pipelineRunResultValues(b.Status.Results)),
+	}
+
+	// The spec of a run that could not be resolved is compared as empty
+	specA, _ := pipelineSpecFor(ctx, a)
+	specB, _ := pipelineSpecFor(ctx, b)
+	if diff.Spec, err = specDiff(specA, specB); err != nil {
+		return nil, err
+	}
+
+	tasksA, orderA := pipelineTaskOutcomes(a, func(name string) *v1.TaskRun {
+		tr, _ := scope.TaskRun(ctx, namespace, name)
+		return tr
+	})
+	tasksB, orderB := pipelineTaskOutcomes(b, func(name string) *v1.TaskRun {
+		tr, _ := scope.TaskRun(ctx, namespace, name)
+		return tr
+	})
+	for _, name := range orderB {
+		if !slices.Contains(orderA, name) {
+			diff.Tasks = append(diff.Tasks, taskDiff{PipelineTask: name, Change: changeAdded, TargetTaskRun: taskRunName(tasksB[name].taskRun)})
+			continue
+		}
+		if td := compareTasks(name, tasksA[name], tasksB[name]); td != nil {
+			diff.Tasks = append(diff.Tasks, *td)
+		}
+	}
+	for _, name := range orderA {
+		if !slices.Contains(orderB, name) {
+			diff.Tasks = append(diff.Tasks, taskDiff{PipelineTask: name, Change: changeRemoved, BaseTaskRun: taskRunName(tasksA[name].taskRun)})
+		}
+	}
+
+	diff.Summary = summarizeRunDiff("PipelineRun",
+		a.Status.GetCondition(apis.ConditionSucceeded), b.Status.GetCondition(apis.ConditionSucceeded),
+		runDuration(a.Status.StartTime, a.Status.CompletionTime), runDuration(b.Status.StartTime, b.Status.CompletionTime), diff)
+	return diff, nil
+}
+
+func diffTaskRuns(ctx context.Context, namespace, base, target string) (*runDiff, error) {
+	a, err := scope.TaskRun(ctx, namespace, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, base, err)
+	}
+	b, err := scope.TaskRun(ctx, namespace, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, target, err)
+	}
+
+	diff := &runDiff{
+		Kind:      kindTaskRun,
+		Namespace: namespace,
+		Base:      base,
+		Target:    target,
+		Status: compareValue("",
+			runStatus(a.Status.GetCondition(apis.ConditionSucceeded)),
+			runStatus(b.Status.GetCondition(apis.ConditionSucceeded))),
+		Duration: compareValue("",
+			runDuration(a.Status.StartTime, a.Status.CompletionTime),
+			runDuration(b.Status.StartTime, b.Status.CompletionTime)),
+		ServiceAccount: compareValue("", a.Spec.ServiceAccountName, b.Spec.ServiceAccountName),
+		Params:         compareValues(paramValues(a.Spec.Params), paramValues(b.Spec.Params)),
+		Workspaces:     compareValues(workspaceValues(a.Spec.Workspaces), workspaceValues(b.Spec.Workspaces)),
+		Results:        compareValues(taskRunResultValues(a.Status.Results), taskRunResultValues(b.Status.Results)),
+		Steps:          compareSteps(a.Status.Steps, b.Status.Steps),
+	}
+	// The spec of a run that could not be resolved is compared as empty
+	specA, _ := taskSpecFor(ctx, a)
+	specB, _ := taskSpecFor(ctx, b)
+	if diff.Spec, err = specDiff(specA, specB); err != nil {
+		return nil, err
+	}
+
+	diff.Summary = summarizeRunDiff("TaskRun",
+		a.Status.GetCondition(apis.ConditionSucceeded), b.Status.GetCondition(apis.ConditionSucceeded),
+		runDuration(a.Status.StartTime, a.Status.CompletionTime), runDuration(b.Status.StartTime, b.Status.CompletionTime), diff)
+	return diff, nil
+}
+
+// pipelineTaskOutcomes returns the pipeline tasks of a PipelineRun, in the
+// order of its child references followed by the skipped tasks. The TaskRuns
+// of a matrixed pipeline task are keyed by their index.
+func pipelineTaskOutcomes(pr *v1.PipelineRun, getTaskRun func(string) *v1.TaskRun) (map[string]taskOutcome, []string) {
+	outcomes := map[string]taskOutcome{}
+	var order []string
+	counts := map[string]int{}
+	for _, child := range pr.Status.ChildReferences {
+		if child.Kind == taskRunKind {
+			counts[child.PipelineTaskName]++
+		}
+	}
+	indexes := map[string]int{}
+	for _, child := range pr.Status.ChildReferences {
+		if child.Kind != taskRunKind {
+			continue
+		}
+		name := child.PipelineTaskName
+		if counts[name] > 1 {
+			name = fmt.Sprintf("%s[%d]", name, indexes[child.PipelineTaskName])
+			indexes[child.PipelineTaskName]++
+		}
+		// TaskRuns may have been pruned, their outcome is then unknown
+		outcome := taskOutcome{taskRun: getTaskRun(child.Name)}
+		if outcome.taskRun != nil {
+			outcome.status = runStatus(outcome.taskRun.Status.GetCondition(apis.ConditionSucceeded))
+		}
+		outcomes[name] = outcome
+		order = append(order, name)
+	}
+	for _, skipped := range pr.Status.SkippedTasks {
+		if _, ok := outcomes[skipped.Name]; !ok {
+			outcomes[skipped.Name] = taskOutcome{status: graphStatusSkipped}
+			order = append(order, skipped.Name)
+		}
+	}
+	return outcomes, order
+}
+
+// compareTasks compares a pipeline task in two PipelineRuns, returning nil
+// if nothing changed. Only the status is compared when a TaskRun is missing.
+func compareTasks(name string, a, b taskOutcome) *taskDiff {
+	td := &taskDiff{
+		PipelineTask:  name,
+		Change:        changeChanged,
+		BaseTaskRun:   taskRunName(a.taskRun),
+		TargetTaskRun: taskRunName(b.taskRun),
+		Status:        compareValue("", a.status, b.status),
+	}
+	if a.taskRun != nil && b.taskRun != nil {
+		td.Duration = compareValue("",
+			runDuration(a.taskRun.Status.StartTime, a.taskRun.Status.CompletionTime),
+			runDuration(b.taskRun.Status.StartTime, b.taskRun.Status.CompletionTime))
+		td.Results = compareValues(taskRunResultValues(a.taskRun.Status.Results), taskRunResultValues(b.taskRun.Status.Results))
+		td.Steps = compareSteps(a.taskRun.Status.Steps, b.taskRun.Status.Steps)
+	}
+	if td.Status == nil && td.Duration == nil && len(td.Results) == 0 && len(td.Steps) == 0 {
+		return nil
+	}
+	return td
+}
+
+// compareSteps compares the steps of two TaskRuns by name, in the order of
+// the target TaskRun followed by the removed steps.
+func compareSteps(a, b []v1.StepState) []stepDiff {
+	steps := map[string]v1.StepState{}
+	for _, step := range a {
+		steps[step.Name] = step
+	}
+	var diffs []stepDiff
+	for _, step := range b {
+		base, ok := steps[step.Name]
+		if !ok {
+			diffs = append(diffs, stepDiff{Name: step.Name, Change: changeAdded})
+			continue
+		}
+		sd := stepDiff{
+			Name:     step.Name,
+			Change:   changeChanged,
+			Image:    compareValue("", base.ImageID, step.ImageID),
+			ExitCode: compareValue("", stepExitCode(base), stepExitCode(step)),
+			Duration: compareValue("", stepDuration(base), stepDuration(step)),
+		}
+		if sd.Image != nil || sd.ExitCode != nil || sd.Duration != nil {
+			diffs = append(diffs, sd)
+		}
+	}
+	for _, step := range a {
+		if !slices.ContainsFunc(b, func(s v1.StepState) bool { return s.Name == step.Name }) {
+			diffs = append(diffs, stepDiff{Name: step.Name, Change: changeRemoved})
+		}
+	}
+	return diffs
+}
+
+// compareValue returns the change of a value, or nil if it did not change.
+// An empty value is absent.
+func compareValue(name, base, target string) *valueChange {
+	if base == target {
+		return nil
+	}
+	change := changeChanged
+	switch {
+	case base == "":
+		change = changeAdded
+	case target == "":
+		change = changeRemoved
+	}
+	return &valueChange{Name: name, Change: change, Base: base, Target: target}
+}
+
+// compareValues returns the changes of named values, sorted by name.
+func compareValues(base, target map[string]string) []valueChange {
+	names := slices.Sorted(maps.Keys(base))
+	for name := range target {
+		if _, ok := base[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var changes []valueChange
+	for _, name := range names {
+		a, inBase := base[name]
+		b, inTarget := target[name]
+		switch {
+		case !inBase:
+			changes = append(changes, valueChange{Name: name, Change: changeAdded, Target: b})
+		case !inTarget:
+			changes = append(changes, valueChange{Name: name, Change: changeRemoved, Base: a})
+		case a != b:
+			changes = append(changes, valueChange{Name: name, Change: changeChanged, Base: a, Target: b})
+		}
+	}
+	return changes
+}
+
+func paramValues(params v1.Params) map[string]string {
+	values := make(map[string]string, len(params))
+	for _, p := range params {
+		values[p.Name] = formatParamValue(p.Value)
+	}
+	return values
+}
+
+func pipelineRunResultValues(results []v1.PipelineRunResult) map[string]string {
+	values := make(map[string]string, len(results))
+	for _, r := range results {
+		values[r.Name] = formatParamValue(r.Value)
+	}
+	return values
+}
+
+func taskRunResultValues(results []v1.TaskRunResult) map[string]string {
+	values := make(map[string]string, len(results))
+	for _, r := range results {
+		values[r.Name] = formatParamValue(r.Value)
+	}
+	return values
+}
+
+// formatParamValue returns a string value as is, and arrays and objects as
+// JSON.
+func formatParamValue(v v1.ParamValue) string {
+	if v.Type == v1.ParamTypeString || v.Type == "" {
+		return v.StringVal
+	}
+	data, err := json.Marshal(&v)
+	if err != nil {
+		return v.StringVal
+	}
+	return string(data)
+}
+
+func workspaceValues(workspaces []v1.WorkspaceBinding) map[string]string {
+	values := make(map[string]string, len(workspaces))
+	for _, w := range workspaces {
+		values[w.Name] = describeWorkspaceBinding(w)
+	}
+	return values
+}
+
+// describeWorkspaceBinding describes the volume bound to a workspace.
+func describeWorkspaceBinding(w v1.WorkspaceBinding) string {
+	var volume string
+	switch {
+	case w.PersistentVolumeClaim != nil:
+		volume = "persistentVolumeClaim " + w.PersistentVolumeClaim.ClaimName
+	case w.VolumeClaimTemplate != nil:
+		volume = "volumeClaimTemplate"
+	case w.EmptyDir != nil:
+		volume = "emptyDir"
+	case w.ConfigMap != nil:
+		volume = "configMap " + w.ConfigMap.Name
+	case w.Secret != nil:
+		volume = "secret " + w.Secret.SecretName
+	case w.Projected != nil:
+		volume = "projected"
+	case w.CSI != nil:
+		volume = "csi " + w.CSI.Driver
+	default:
+		volume = "unknown"
+	}
+	if w.SubPath != "" {
+		volume += " subPath " + w.SubPath
+	}
+	return volume
+}
+
+// specDiff returns a line diff of two specs marshalled as YAML.
+func specDiff(a, b any) (string, error) {
+	before, err := yaml.Marshal(a)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal spec to YAML: %w", err)
+	}
+	after, err := yaml.Marshal(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal spec to YAML: %w", err)
+	}
+	return lineDiff(string(before), string(after)), nil
+}
+
+func stepExitCode(step v1.StepState) string {
+	if step.Terminated == nil {
+		return ""
+	}
+	return strconv.Itoa(int(step.Terminated.ExitCode))
+}
+
+func stepDuration(step v1.StepState) string {
+	if step.Terminated == nil || step.Terminated.StartedAt.IsZero() {
+		return ""
+	}
+	return formatDuration(step.Terminated.FinishedAt.Sub(step.Terminated.StartedAt.Time))
+}
+
+func taskRunName(tr *v1.TaskRun) string {
+	if tr == nil {
+		return ""
+	}
+	return tr.Name
+}
+
+// summarizeRunDiff describes the outcome of both runs followed by the kinds
+// of differences found.
+func summarizeRunDiff(kind string, a, b *apis.Condition, durationA, durationB string, diff *runDiff) string {
+	summary := fmt.Sprintf("%s %s %s %s, %s %s %s",
+		kind, diff.Base, describeRunStatus(runStatus(a)), durationA,
+		diff.Target, describeRunStatus(runStatus(b)), durationB)
+
+	var parts []string
+	names := func(changes []valueChange) string {
+		out := make([]string, 0, len(changes))
+		for _, c := range changes {
+			out = append(out, c.Name)
+		}
+		return strings.Join(out, ", ")
+	}
+	if len(diff.Params) > 0 {
+		parts = append(parts, fmt.Sprintf("params (%s)", names(diff.Params)))
+	}
+	if diff.ServiceAccount != nil {
+		parts = append(parts, "service account")
+	}
+	if len(diff.Workspaces) > 0 {
+		parts = append(parts, fmt.Sprintf("workspaces (%s)", names(diff.Workspaces)))
+	}
+	if diff.Spec != "" {
+		parts = append(parts, "spec")
+	}
+	if len(diff.Results) > 0 {
+		parts = append(parts, fmt.Sprintf("results (%s)", names(diff.Results)))
+	}
+
+	var outcomes, images []string
+	for _, td := range diff.Tasks {
+		switch {
+		case td.Change != changeChanged:
+			outcomes = append(outcomes, fmt.Sprintf("%s %s", td.PipelineTask, td.Change))
+		case td.Status != nil:
+			outcomes = append(outcomes, fmt.Sprintf("%s %s -> %s", td.PipelineTask, td.Status.Base, td.Status.Target))
+		}
+		for _, sd := range td.Steps {
+			if sd.Image != nil {
+				images = append(images, td.PipelineTask+"/"+sd.Name)
+			}
+		}
+	}
+	for _, sd := range diff.Steps {
+		if sd.Image != nil {
+			images = append(images, sd.Name)
+		}
+	}
+	if len(outcomes) > 0 {
+		parts = append(parts, fmt.Sprintf("task outcomes (%s)", strings.Join(outcomes, ", ")))
+	}
+	if len(images) > 0 {
+		parts = append(parts, fmt.Sprintf("step images (%s)", strings.Join(images, ", ")))
+	}
+
+	if len(parts) == 0 {
+		return summary + ". No differences in the inputs, outcomes or images of the runs"
+	}
+	return summary + ". Differences: " + strings.Join(parts, "; ")
+}
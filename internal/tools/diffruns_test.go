@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDiffRuns(t *testing.T) {
+	base := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	at := func(day, minutes int) *metav1.Time {
+		t := metav1.NewTime(base.Add(time.Duration(day)*24*time.Hour + time.Duration(minutes)*time.Minute))
+		return &t
+	}
+	step := func(name, image string, day, start, end int, exitCode int32) v1.StepState {
+		return v1.StepState{Name: name, ImageID: image, ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			StartedAt: *at(day, start), FinishedAt: *at(day, end), ExitCode: exitCode,
+		}}}
+	}
+	taskRun := func(name string, day, minutes int, status corev1.ConditionStatus, results []v1.TaskRunResult, steps ...v1.StepState) *v1.TaskRun {
+		reason := "Succeeded"
+		if status == corev1.ConditionFalse {
+			reason = "Failed"
+		}
+		return &v1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: v1.TaskRunStatus{
+				Status: succeededCondition(status, reason),
+				TaskRunStatusFields: v1.TaskRunStatusFields{
+					StartTime:      at(day, 0),
+					CompletionTime: at(day, minutes),
+					Steps:          steps,
+					Results:        results,
+				},
+			},
+		}
+	}
+	pipelineRun := func(name string, day, minutes int, status corev1.ConditionStatus, revision, serviceAccount, subPath string, testParams v1.Params, results []v1.PipelineRunResult, children ...string) *v1.PipelineRun {
+		reason := "Succeeded"
+		if status == corev1.ConditionFalse {
+			reason = "Failed"
+		}
+		pr := &v1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1.PipelineRunSpec{
+				Params: v1.Params{
+					{Name: "revision", Value: *v1.NewStructuredValues(revision)},
+					{Name: "flags", Value: *v1.NewStructuredValues("-v", "-race")},
+				},
+				TaskRunTemplate: v1.PipelineTaskRunTemplate{ServiceAccountName: serviceAccount},
+				Workspaces: []v1.WorkspaceBinding{{
+					Name:                  "source",
+					SubPath:               subPath,
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "src"},
+				}},
+			},
+			Status: v1.PipelineRunStatus{
+				Status: succeededCondition(status, reason),
+				PipelineRunStatusFields: v1.PipelineRunStatusFields{
+					StartTime:      at(day, 0),
+					CompletionTime: at(day, minutes),
+					PipelineSpec: &v1.PipelineSpec{Tasks: []v1.PipelineTask{
+						{Name: "build", TaskRef: &v1.TaskRef{Name: "build"}},
+						{Name: "test", TaskRef: &v1.TaskRef{Name: "test"}, Params: testParams},
+					}},
+					Results: results,
+				},
+			},
+		}
+		for _, child := range children {
+			pr.Status.ChildReferences = append(pr.Status.ChildReferences, v1.ChildStatusReference{
+				TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: name + "-" + child, PipelineTaskName: child,
+			})
+		}
+		return pr
+	}
+
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			pipelineRun("nightly-1", 0, 10, corev1.ConditionTrue, "abc", "builder", "", nil,
+				[]v1.PipelineRunResult{{Name: "digest", Value: *v1.NewStructuredValues("sha256:1")}}, "build", "test"),
+			pipelineRun("nightly-2", 1, 5, corev1.ConditionFalse, "def", "deployer", "repo",
+				v1.Params{{Name: "race", Value: *v1.NewStructuredValues("true")}}, nil, "build", "test", "lint"),
+		},
+		TaskRuns: []*v1.TaskRun{
+			taskRun("nightly-1-build", 0, 4, corev1.ConditionTrue, nil, step("compile", "golang@sha256:aaa", 0, 1, 3, 0)),
+			taskRun("nightly-2-build", 1, 4, corev1.ConditionTrue, nil, step("compile", "golang@sha256:bbb", 1, 1, 3, 0)),
+			taskRun("nightly-1-test", 0, 5, corev1.ConditionTrue,
+				[]v1.TaskRunResult{{Name: "coverage", Type: v1.ResultsTypeString, Value: *v1.NewStructuredValues("80")}},
+				step("unit", "golang@sha256:aaa", 0, 1, 3, 0)),
+			taskRun("nightly-2-test", 1, 1, corev1.ConditionFalse, nil, step("unit", "golang@sha256:aaa", 1, 0, 1, 1)),
+			taskRun("nightly-2-lint", 1, 1, corev1.ConditionTrue, nil),
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		expected  runDiff
+		spec      []string
+	}{
+		{
+			name:      "pipelineruns",
+			arguments: map[string]any{"base": "nightly-1", "target": "nightly-2"},
+			expected: runDiff{
+				Kind:      "pipelinerun",
+				Namespace: "default",
+				Base:      "nightly-1",
+				Target:    "nightly-2",
+				Summary: "PipelineRun nightly-1 succeeded in 10m0s, nightly-2 failed in 5m0s. Differences: params (revision); service account; " +
+					"workspaces (source); spec; results (digest); task outcomes (test succeeded -> failed, lint added); step images (build/compile)",
+				Status:         &valueChange{Change: "changed", Base: "succeeded", Target: "failed"},
+				Duration:       &valueChange{Change: "changed", Base: "10m0s", Target: "5m0s"},
+				ServiceAccount: &valueChange{Change: "changed", Base: "builder", Target: "deployer"},
+				Params:         []valueChange{{Name: "revision", Change: "changed", Base: "abc", Target: "def"}},
+				Workspaces: []valueChange{{
+					Name: "source", Change: "changed", Base: "persistentVolumeClaim src", Target: "persistentVolumeClaim src subPath repo",
+				}},
+				Results: []valueChange{{Name: "digest", Change: "removed", Base: "sha256:1"}},
+				Tasks: []taskDiff{
+					{
+						PipelineTask: "build", Change: "changed", BaseTaskRun: "nightly-1-build", TargetTaskRun: "nightly-2-build",
+						Steps: []stepDiff{{
+							Name: "compile", Change: "changed",
+							Image: &valueChange{Change: "changed", Base: "golang@sha256:aaa", Target: "golang@sha256:bbb"},
+						}},
+					},
+					{
+						PipelineTask: "test", Change: "changed", BaseTaskRun: "nightly-1-test", TargetTaskRun: "nightly-2-test",
+						Status:   &valueChange{Change: "changed", Base: "succeeded", Target: "failed"},
+						Duration: &valueChange{Change: "changed", Base: "5m0s", Target: "1m0s"},
+						Results:  []valueChange{{Name: "coverage", Change: "removed", Base: "80"}},
+						Steps: []stepDiff{{
+							Name: "unit", Change: "changed",
+							ExitCode: &valueChange{Change: "changed", Base: "0", Target: "1"},
+							Duration: &valueChange{Change: "changed", Base: "2m0s", Target: "1m0s"},
+						}},
+					},
+					{PipelineTask: "lint", Change: "added", TargetTaskRun: "nightly-2-lint"},
+				},
+			},
+			spec: []string{"+   params:\n+   - name: race\n+     value: \"true\""},
+		},
+		{
+			name:      "taskruns",
+			arguments: map[string]any{"kind": "taskrun", "base": "nightly-1-test", "target": "nightly-2-test"},
+			expected: runDiff{
+				Kind:      "taskrun",
+				Namespace: "default",
+				Base:      "nightly-1-test",
+				Target:    "nightly-2-test",
+				Summary:   "TaskRun nightly-1-test succeeded in 5m0s, nightly-2-test failed in 1m0s. Differences: results (coverage)",
+				Status:    &valueChange{Change: "changed", Base: "succeeded", Target: "failed"},
+				Duration:  &valueChange{Change: "changed", Base: "5m0s", Target: "1m0s"},
+				Results:   []valueChange{{Name: "coverage", Change: "removed", Base: "80"}},
+				Steps: []stepDiff{{
+					Name: "unit", Change: "changed",
+					ExitCode: &valueChange{Change: "changed", Base: "0", Target: "1"},
+					Duration: &valueChange{Change: "changed", Base: "2m0s", Target: "1m0s"},
+				}},
+			},
+		},
+		{
+			name:      "identical",
+			arguments: map[string]any{"base": "nightly-1", "target": "nightly-1"},
+			expected: runDiff{
+				Kind:      "pipelinerun",
+				Namespace: "default",
+				Base:      "nightly-1",
+				Target:    "nightly-1",
+				Summary:   "PipelineRun nightly-1 succeeded in 10m0s, nightly-1 succeeded in 10m0s. No differences in the inputs, outcomes or images of the runs",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.arguments["output"] = "json"
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "diff_runs", Arguments: tc.arguments})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got runDiff
+			if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range tc.spec {
+				if !strings.Contains(got.Spec, line) {
+					t.Errorf("expected the spec diff to contain %q, got:\n%s", line, got.Spec)
+				}
+			}
+			if len(tc.spec) == 0 && got.Spec != "" {
+				t.Errorf("unexpected spec diff:\n%s", got.Spec)
+			}
+			got.Spec = ""
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("diff mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "diff_runs",
+		Arguments: map[string]any{"base": "nightly-1", "target": "missing"},
+	}); err == nil {
+		t.Error("expected an error for a missing run")
+	}
+}
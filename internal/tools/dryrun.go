@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"fmt"
+
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const dryRunDescription = "Only validate the request server side, including the admission webhooks, " +
+	"without persisting anything, and return the resulting object"
+
+// dryRunOption returns the DryRun option of the requests made by a mutating
+// tool.
+func dryRunOption(dryRun bool) []string {
+	if dryRun {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
+
+// dryRunResult is the result of a dry-run request: the message is followed by
+// the object returned by the API server, as YAML.
+func dryRunResult(message string, obj metav1.Object) (*mcp.CallToolResultFor[string], error) {
+	obj.SetManagedFields(nil)
+	out, err := marshalOutput(obj, outputFormatYAML)
+	if err != nil {
+		return nil, err
+	}
+	return result(fmt.Sprintf("Dry run, nothing was persisted. %s\n---\n%s", message, out)), nil
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestDryRun(t *testing.T) {
+	data := test.Data{
+		Pipelines: []*v1.Pipeline{
+			{ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default"}},
+		},
+		Tasks: []*v1.Task{
+			{ObjectMeta: metav1.ObjectMeta{Name: "lint", Namespace: "default"}},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "lint-run", Namespace: "default"},
+				Spec:       v1.TaskRunSpec{TaskRef: &v1.TaskRef{Name: "lint"}},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+
+	// The fake clientset ignores the DryRun option: the requests are recorded
+	// and answered without being persisted, the way the API server does
+	var dryRun []string
+	clients.Pipeline.PrependReactor("*", "*", func(action ktesting.Action) (bool, runtime.Object, error) {
+		switch a := action.(type) {
+		case ktesting.CreateActionImpl:
+			dryRun = a.GetCreateOptions().DryRun
+			obj := a.GetObject().DeepCopyObject()
+			m := obj.(metav1.Object)
+			if m.GetName() == "invalid" {
+				return true, nil, errors.New(`admission webhook "validation.webhook.pipeline.tekton.dev" denied the request: missing field(s): spec.tasks`)
+			}
+			if m.GetName() == "" {
+				m.SetName(m.GetGenerateName() + "abcde")
+			}
+			return true, obj, nil
+		case ktesting.UpdateActionImpl:
+			dryRun = a.GetUpdateOptions().DryRun
+			return true, a.GetObject(), nil
+		case ktesting.PatchActionImpl:
+			dryRun = a.GetPatchOptions().DryRun
+			return true, &v1.Pipeline{ObjectMeta: metav1.ObjectMeta{Name: a.GetName(), Namespace: a.GetNamespace()}}, nil
+		case ktesting.DeleteActionImpl:
+			dryRun = a.GetDeleteOptions().DryRun
+			return true, nil, nil
+		}
+		return false, nil, nil
+	})
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		tool      string
+		arguments map[string]any
+		response  string
+	}{
+		{
+			tool:      "create_pipeline",
+			arguments: map[string]any{"yaml": "metadata:\n  name: release\nspec:\n  tasks:\n  - name: build\n    taskRef:\n      name: build\n"},
+			response:  "Dry run, nothing was persisted. Pipeline 'release' would be created in namespace 'default'\n---\n",
+		},
+		{
+			tool:      "create_pipeline",
+			arguments: map[string]any{"yaml": "metadata:\n  name: invalid\n"},
+			response:  `Error creating Pipeline: admission webhook "validation.webhook.pipeline.tekton.dev" denied the request: missing field(s): spec.tasks`,
+		},
+		{
+			tool:      "update_pipeline",
+			arguments: map[string]any{"name": "build", "yaml": "spec:\n  description: build\n"},
+			response:  "Dry run, nothing was persisted. Pipeline 'build' would be updated in namespace 'default'\n---\n",
+		},
+		{
+			tool:      "patch_pipeline",
+			arguments: map[string]any{"name": "build", "patch": `[{"op":"add","path":"/spec/description","value":"build"}]`},
+			response:  "Dry run, nothing was persisted. Pipeline 'build' would be patched in namespace 'default'\n---\n",
+		},
+		{
+			tool:      "delete_task",
+			arguments: map[string]any{"name": "lint"},
+			response:  "Dry run, nothing was persisted. Task 'lint' would be deleted from namespace 'default'",
+		},
+		{
+			tool:      "start_task",
+			arguments: map[string]any{"name": "lint"},
+			response:  "Dry run, nothing was persisted. Task lint would be started in namespace default with TaskRun lint-abcde\n---\n",
+		},
+		{
+			tool:      "restart_taskrun",
+			arguments: map[string]any{"name": "lint-run"},
+			response:  "Dry run, nothing was persisted. TaskRun lint-run would be restarted as lint-run-abcde in namespace default\n---\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.tool, func(t *testing.T) {
+			dryRun = nil
+			test.arguments["dryRun"] = true
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: test.tool, Arguments: test.arguments})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]string{metav1.DryRunAll}, dryRun); diff != "" {
+				t.Errorf("DryRun option mismatch (-want +got):\n%s", diff)
+			}
+			content, _ := response.Content[0].(*mcp.TextContent)
+			if !strings.HasPrefix(content.Text, test.response) {
+				t.Errorf("invalid response: %s", content.Text)
+			}
+		})
+	}
+
+	if _, err := clients.Pipeline.TektonV1().Tasks("default").Get(ctx, "lint", metav1.GetOptions{}); err != nil {
+		t.Errorf("task deleted by a dry run: %v", err)
+	}
+}
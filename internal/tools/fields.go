@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const fieldsDescription = "JSONPath expressions, in kubectl's syntax, of the fields to return instead of the whole object, " +
+	"for example .status.results, .spec.params or .status.childReferences[*].pipelineTaskName"
+
+// fieldPath is a parsed JSONPath expression of the fields param.
+type fieldPath struct {
+	expression string
+	path       *jsonpath.JSONPath
+	// multiple is true when the expression can select several values, which
+	// are then returned as a list even if a single one matches.
+	multiple bool
+}
+
+// parseFields parses JSONPath expressions, with or without the enclosing
+// braces like kubectl's relaxed syntax.
+func parseFields(fields []string) ([]fieldPath, error) {
+	paths := make([]fieldPath, 0, len(fields))
+	for _, field := range fields {
+		expression := strings.TrimSpace(field)
+		if !strings.HasPrefix(expression, "{") {
+			expression = "{" + expression + "}"
+		}
+		parser, err := jsonpath.Parse(field, expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %w", field, err)
+		}
+		if len(parser.Root.Nodes) != 1 || parser.Root.Nodes[0].Type() != jsonpath.NodeList {
+			return nil, fmt.Errorf("invalid field %q: expected a single JSONPath expression", field)
+		}
+
+		path := jsonpath.New(field).AllowMissingKeys(true)
+		if err := path.Parse(expression); err != nil {
+			return nil, fmt.Errorf("invalid field %q: %w", field, err)
+		}
+		paths = append(paths, fieldPath{
+			expression: field,
+			path:       path,
+			multiple:   selectsMultiple(parser.Root.Nodes[0].(*jsonpath.ListNode)),
+		})
+	}
+	return paths, nil
+}
+
+func selectsMultiple(list *jsonpath.ListNode) bool {
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *jsonpath.WildcardNode, *jsonpath.RecursiveNode, *jsonpath.FilterNode, *jsonpath.UnionNode:
+			return true
+		case *jsonpath.ArrayNode:
+			if !n.Params[1].Derived {
+				return true
+			}
+		case *jsonpath.ListNode:
+			if selectsMultiple(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// projectFields returns the values of the fields of an object, by expression.
+// Missing fields are null, or an empty list for the expressions selecting
+// several values.
+func projectFields(obj any, paths []fieldPath) (map[string]any, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	projection := make(map[string]any, len(paths))
+	for _, p := range paths {
+		results, err := p.path.FindResults(data)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating field %q: %w", p.expression, err)
+		}
+		values := []any{}
+		for _, r := range results {
+			for _, v := range r {
+				values = append(values, v.Interface())
+			}
+		}
+		switch {
+		case p.multiple:
+			projection[p.expression] = values
+		case len(values) == 0:
+			projection[p.expression] = nil
+		default:
+			projection[p.expression] = values[0]
+		}
+	}
+	return projection, nil
+}
+
+// fieldsResult is the result of a get tool called with fields: the values of
+// the fields in the requested output format.
+func fieldsResult(obj any, fields []string, output string) *mcp.CallToolResultFor[string] {
+	paths, err := parseFields(fields)
+	if err != nil {
+		return result(fmt.Sprintf("Error: %v", err))
+	}
+	projection, err := projectFields(obj, paths)
+	if err != nil {
+		return result(fmt.Sprintf("Error: %v", err))
+	}
+	out, err := marshalOutput(projection, output)
+	if err != nil {
+		return result(fmt.Sprintf("Error: %v", err))
+	}
+	return result(out)
+}
@@ -19,9 +19,10 @@ const (
 )
 
 type getPipelineParams struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
-	Output    string `json:"output"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Output    string   `json:"output"`
+	Fields    []string `json:"fields"`
 }
 
 func getPipeline() (*mcp.ServerTool, error) {
@@ -35,6 +36,7 @@ func getPipeline() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["output"].Description = outputFormatDescription
 	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Properties["fields"].Description = fieldsDescription
 	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
@@ -70,6 +72,10 @@ func handlerGetPipeline(
 		return result(fmt.Sprintf("Error getting Pipeline: %v", err)), nil
 	}
 
+	if len(params.Arguments.Fields) > 0 {
+		return fieldsResult(pipeline, params.Arguments.Fields, output), nil
+	}
+
 	var outputStr string
 	if output == outputFormatJSON {
 		jsonData, err := json.MarshalIndent(pipeline, "", "  ")
@@ -89,9 +95,10 @@ func handlerGetPipeline(
 }
 
 type getTaskParams struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
-	Output    string `json:"output"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Output    string   `json:"output"`
+	Fields    []string `json:"fields"`
 }
 
 func getTask() (*mcp.ServerTool, error) {
@@ -105,6 +112,7 @@ func getTask() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["output"].Description = outputFormatDescription
 	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Properties["fields"].Description = fieldsDescription
 	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
@@ -140,6 +148,10 @@ func handlerGetTask(
 		return result(fmt.Sprintf("Error getting Task: %v", err)), nil
 	}
 
+	if len(params.Arguments.Fields) > 0 {
+		return fieldsResult(task, params.Arguments.Fields, output), nil
+	}
+
 	var outputStr string
 	if output == outputFormatJSON {
 		jsonData, err := json.MarshalIndent(task, "", "  ")
@@ -159,9 +171,10 @@ func handlerGetTask(
 }
 
 type getPipelineRunParams struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
-	Output    string `json:"output"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Output    string   `json:"output"`
+	Fields    []string `json:"fields"`
 }
 
 func getPipelineRun() (*mcp.ServerTool, error) {
@@ -175,6 +188,7 @@ func getPipelineRun() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["output"].Description = outputFormatDescription
 	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Properties["fields"].Description = fieldsDescription
 	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
@@ -210,6 +224,10 @@ func handlerGetPipelineRun(
 		return result(fmt.Sprintf("Error getting PipelineRun: %v", err)), nil
 	}
 
+	if len(params.Arguments.Fields) > 0 {
+		return fieldsResult(pipelineRun, params.Arguments.Fields, output), nil
+	}
+
 	var outputStr string
 	if output == outputFormatJSON {
 		jsonData, err := json.MarshalIndent(pipelineRun, "", "  ")
@@ -229,9 +247,10 @@ func handlerGetPipelineRun(
 }
 
 type getTaskRunParams struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
-	Output    string `json:"output"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Output    string   `json:"output"`
+	Fields    []string `json:"fields"`
 }
 
 func getTaskRun() (*mcp.ServerTool, error) {
@@ -245,6 +264,7 @@ func getTaskRun() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["output"].Description = outputFormatDescription
 	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Properties["fields"].Description = fieldsDescription
 	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
@@ -280,6 +300,10 @@ func handlerGetTaskRun(
 		return result(fmt.Sprintf("Error getting TaskRun: %v", err)), nil
 	}
 
+	if len(params.Arguments.Fields) > 0 {
+		return fieldsResult(taskRun, params.Arguments.Fields, output), nil
+	}
+
 	var outputStr string
 	if output == outputFormatJSON {
 		jsonData, err := json.MarshalIndent(taskRun, "", "  ")
@@ -297,3 +321,20 @@ func handlerGetTaskRun(
 
 	return result(outputStr), nil
 }
+
+// marshalOutput marshals an object in the requested output format, YAML
+// being the default.
+func marshalOutput(v any, output string) (string, error) {
+	if output == outputFormatJSON {
+		jsonData, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error marshaling to JSON: %w", err)
+		}
+		return string(jsonData), nil
+	}
+	yamlData, err := yaml.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling to YAML: %w", err)
+	}
+	return string(yamlData), nil
+}
@@ -1,24 +1,173 @@
 package tools
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
-	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/taskrun"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"knative.dev/pkg/apis"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 )
 
 type getLogsParams struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
+	Name           string `json:"name"`
+	Namespace      string `json:"namespace"`
+	Step           string `json:"step"`
+	TailLines      int64  `json:"tailLines"`
+	SinceSeconds   int64  `json:"sinceSeconds"`
+	LimitBytes     int64  `json:"limitBytes"`
+	Previous       bool   `json:"previous"`
+	InitContainers bool   `json:"initContainers"`
+	Sidecars       bool   `json:"sidecars"`
+	Follow         bool   `json:"follow"`
+}
+
+// followPollInterval is the interval used to check whether the pods and
+// containers being followed have started.
+var followPollInterval = time.Second
+
+// logOptions holds the options used to select and bound the logs of the
+// containers of a TaskRun pod.
+type logOptions struct {
+	step           string
+	tailLines      int64
+	sinceSeconds   int64
+	limitBytes     int64
+	previous       bool
+	initContainers bool
+	sidecars       bool
+	follow         bool
+	// notify is called with every header and log line while following logs
+	notify func(string)
+}
+
+func (o logOptions) podLogOptions(container string) *v1.PodLogOptions {
+	opts := &v1.PodLogOptions{
+		Follow:    o.follow,
+		Container: container,
+		Previous:  o.previous,
+	}
+	if o.tailLines > 0 {
+		opts.TailLines = &o.tailLines
+	}
+	if o.sinceSeconds > 0 {
+		opts.SinceSeconds = &o.sinceSeconds
+	}
+	if o.limitBytes > 0 {
+		opts.LimitBytes = &o.limitBytes
+	}
+	return opts
+}
+
+// write appends a header to the logs and, when following, sends it to the
+// client.
+func (o logOptions) write(sb *strings.Builder, s string) {
+	sb.WriteString(s)
+	if o.follow && o.notify != nil {
+		o.notify(strings.Trim(s, "\n"))
+	}
+}
+
+// logNotifier returns a function sending log lines to the client. Progress
+// notifications are used when the client asked for them, logging
+// notifications otherwise.
+func logNotifier(ctx context.Context, cc *mcp.ServerSession, progressToken any) func(string) {
+	var progress float64
+	return func(line string) {
+		if progressToken != nil {
+			progress++
+			_ = cc.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+				ProgressToken: progressToken,
+				Progress:      progress,
+				Message:       line,
+			})
+			return
+		}
+		_ = cc.Log(ctx, &mcp.LoggingMessageParams{
+			Level:  "info",
+			Logger: "tekton",
+			Data:   line,
+		})
+	}
+}
+
+// followMiddleware rejects the calls of the log tools following logs when
+// the lines could not reach the client: without a progress token, they are
+// sent as info logging notifications, which the session drops until the client
+// sets a logging level of info or below.
+func followMiddleware(logTools ...*mcp.ServerTool) mcp.Middleware[*mcp.ServerSession] {
+	var mu sync.Mutex
+	levels := map[*mcp.ServerSession]mcp.LoggingLevel{}
+
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, ss *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			switch p := params.(type) {
+			case *mcp.SetLevelParams:
+				res, err := next(ctx, ss, method, params)
+				if err != nil {
+					return res, err
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if _, ok := levels[ss]; !ok {
+					go func() {
+						_ = ss.Wait()
+						mu.Lock()
+						defer mu.Unlock()
+						delete(levels, ss)
+					}()
+				}
+				levels[ss] = p.Level
+				return res, nil
+
+			case *mcp.CallToolParamsFor[json.RawMessage]:
+				if !slices.ContainsFunc(logTools, func(t *mcp.ServerTool) bool { return t.Tool.Name == p.Name }) || p.GetProgressToken() != nil {
+					return next(ctx, ss, method, params)
+				}
+				var args struct {
+					Follow bool `json:"follow"`
+				}
+				// Invalid arguments are reported by the tool
+				_ = json.Unmarshal(p.Arguments, &args)
+				if !args.Follow {
+					return next(ctx, ss, method, params)
+				}
+				mu.Lock()
+				level := levels[ss]
+				mu.Unlock()
+				if level != "debug" && level != "info" {
+					return nil, errors.New("follow requires a progress token in the request, or a logging level of info or debug set with logging/setLevel")
+				}
+			}
+			return next(ctx, ss, method, params)
+		}
+	}
+}
+
+// logOptionsDescriptions documents the log options shared by the log tools.
+func logOptionsDescriptions(scheme *jsonschema.Schema) {
+	scheme.Properties["tailLines"].Description = "Number of lines from the end of the logs to show for each container (all if 0)"
+	scheme.Properties["sinceSeconds"].Description = "Only show the logs newer than this number of seconds (all if 0)"
+	scheme.Properties["limitBytes"].Description = "Maximum number of bytes of logs to return for each container (unlimited if 0)"
+	scheme.Properties["previous"].Description = "Return the logs of the previous terminated instance of the containers"
+	scheme.Properties["initContainers"].Description = "Include the logs of the init containers of the pod"
+	scheme.Properties["sidecars"].Description = "Include the logs of the sidecars of the pod"
+	scheme.Properties["follow"].Description = "Stream the logs until the pods terminate, as progress notifications when the request carries a progress token, as info logging notifications otherwise, which requires the client to set a logging level of info or debug first"
 }
 
 func getTaskRunLogsSchema() (mcp.ToolOption, error) {
@@ -30,6 +179,9 @@ func getTaskRunLogsSchema() (mcp.ToolOption, error) {
 	scheme.Properties["name"].Description = "Name or referece of the object"
 	scheme.Properties["namespace"].Description = "Namespace of the object"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["step"].Description = "Name of the step to get the logs of (all steps if empty)"
+	logOptionsDescriptions(scheme)
+	scheme.Required = []string{"name"}
 
 	return mcp.Input(mcp.Schema(scheme)), nil
 }
@@ -54,21 +206,39 @@ func handlerGetTaskRunLogs(
 ) (*mcp.CallToolResultFor[string], error) {
 	name := params.Arguments.Name
 	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	opts := logOptions{
+		step:           params.Arguments.Step,
+		tailLines:      params.Arguments.TailLines,
+		sinceSeconds:   params.Arguments.SinceSeconds,
+		limitBytes:     params.Arguments.LimitBytes,
+		previous:       params.Arguments.Previous,
+		initContainers: params.Arguments.InitContainers,
+		sidecars:       params.Arguments.Sidecars,
+		follow:         params.Arguments.Follow,
+		notify:         logNotifier(ctx, cc, params.GetProgressToken()),
+	}
 
-	taskrunInformer := taskruninformer.Get(ctx)
 	kubeclientset := kubeclient.Get(ctx)
 
-	task, err := taskrunInformer.Lister().TaskRuns(namespace).Get(name)
+	task, err := scope.TaskRun(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, name, err)
 	}
+	if opts.follow {
+		if task, err = waitForTaskRunPod(ctx, namespace, name); err != nil {
+			return nil, err
+		}
+	}
 
 	podName := task.Status.PodName
 	if podName == "" {
 		return nil, fmt.Errorf("podName not set for TaskRun %s/%s", namespace, name)
 	}
 
-	logs, err := getLogs(ctx, kubeclientset.CoreV1().Pods(namespace), podName)
+	logs, err := getLogs(ctx, kubeclientset.CoreV1().Pods(namespace), podName, &task.Status, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get logs for TaskRun %s/%s: %w", namespace, name, err)
 	}
@@ -76,25 +246,390 @@ func handlerGetTaskRunLogs(
 	return result(logs), nil
 }
 
-func getLogs(ctx context.Context, client corev1.PodInterface, name string) (string, error) {
+type getPipelineRunLogsParams struct {
+	Name           string   `json:"name"`
+	Namespace      string   `json:"namespace"`
+	FailedOnly     bool     `json:"failedOnly"`
+	Tasks          []string `json:"tasks"`
+	TailLines      int64    `json:"tailLines"`
+	SinceSeconds   int64    `json:"sinceSeconds"`
+	LimitBytes     int64    `json:"limitBytes"`
+	Previous       bool     `json:"previous"`
+	InitContainers bool     `json:"initContainers"`
+	Sidecars       bool     `json:"sidecars"`
+	Follow         bool     `json:"follow"`
+}
+
+func getPipelineRunLogs() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[getPipelineRunLogsParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["name"].Description = "Name of the PipelineRun"
+	scheme.Properties["namespace"].Description = "Namespace of the PipelineRun"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["failedOnly"].Description = "Only include the logs of failed TaskRuns"
+	scheme.Properties["tasks"].Description = "Names of the pipeline tasks to include (all if empty)"
+	logOptionsDescriptions(scheme)
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"get_pipelinerun_logs",
+		"Get the logs of all the TaskRuns of a given PipelineRun, in DAG order. With follow, TaskRuns are streamed as they start until the PipelineRun completes",
+		handlerGetPipelineRunLogs,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerGetPipelineRunLogs(
+	ctx context.Context,
+	cc *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[getPipelineRunLogsParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	tasks := params.Arguments.Tasks
+	opts := logOptions{
+		tailLines:      params.Arguments.TailLines,
+		sinceSeconds:   params.Arguments.SinceSeconds,
+		limitBytes:     params.Arguments.LimitBytes,
+		previous:       params.Arguments.Previous,
+		initContainers: params.Arguments.InitContainers,
+		sidecars:       params.Arguments.Sidecars,
+		follow:         params.Arguments.Follow,
+		notify:         logNotifier(ctx, cc, params.GetProgressToken()),
+	}
+	if opts.follow && params.Arguments.FailedOnly {
+		return nil, errors.New("failedOnly cannot be used with follow")
+	}
+
+	kubeclientset := kubeclient.Get(ctx)
+
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for {
+		pr, err := scope.PipelineRun(ctx, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
+		}
+		// Checked before the children so that the TaskRuns created right
+		// before the PipelineRun completed are still followed
+		done := pr.IsDone()
+
+		// Without a pipeline spec, fallback to the order of the child references
+		var order []string
+		if spec, err := pipelineSpecFor(ctx, pr); err == nil {
+			order = dagOrder(spec)
+		}
+
+		for _, child := range orderedChildReferences(pr, order) {
+			if child.Kind != taskRunKind || seen[child.Name] {
+				continue
+			}
+			if len(tasks) > 0 && !slices.Contains(tasks, child.PipelineTaskName) {
+				continue
+			}
+			seen[child.Name] = true
+
+			tr, err := scope.TaskRun(ctx, namespace, child.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, child.Name, err)
+			}
+			if opts.follow {
+				if tr, err = waitForTaskRunPod(ctx, namespace, child.Name); err != nil {
+					return nil, err
+				}
+			}
+			succeeded := tr.Status.GetCondition(apis.ConditionSucceeded)
+			if params.Arguments.FailedOnly && !succeeded.IsFalse() {
+				continue
+			}
+
+			opts.write(&sb, fmt.Sprintf("\n=== PipelineTask %s TaskRun %s (%s)", child.PipelineTaskName, tr.Name, conditionReason(succeeded)))
+			sb.WriteString(taskRunAttemptsLogs(ctx, kubeclientset.CoreV1().Pods(namespace), tr, opts))
+		}
+
+		if !opts.follow || done {
+			break
+		}
+		if err := sleep(ctx, followPollInterval); err != nil {
+			return nil, fmt.Errorf("stopped following PipelineRun %s/%s: %w", namespace, name, err)
+		}
+	}
+
+	if sb.Len() == 0 {
+		return result(fmt.Sprintf("No TaskRun logs found for PipelineRun %s/%s", namespace, name)), nil
+	}
+	return result(sb.String()), nil
+}
+
+// taskRunAttemptsLogs returns the logs of every attempt of a TaskRun, retries
+// first. Errors are reported inline, as the pods of older attempts may have
+// been deleted already.
+func taskRunAttemptsLogs(ctx context.Context, client corev1.PodInterface, tr *pipelinev1.TaskRun, opts logOptions) string {
+	attempts := make([]*pipelinev1.TaskRunStatus, 0, len(tr.Status.RetriesStatus)+1)
+	for i := range tr.Status.RetriesStatus {
+		attempts = append(attempts, &tr.Status.RetriesStatus[i])
+	}
+	attempts = append(attempts, &tr.Status)
+
+	var sb strings.Builder
+	for i, attempt := range attempts {
+		if len(attempts) > 1 {
+			opts.write(&sb, fmt.Sprintf("\n--- Attempt %d/%d", i+1, len(attempts)))
+		}
+		if attempt.PodName == "" {
+			opts.write(&sb, "\npodName not set")
+			continue
+		}
+		logs, err := getLogs(ctx, client, attempt.PodName, attempt, opts)
+		if err != nil {
+			opts.write(&sb, fmt.Sprintf("\n%v", err))
+			continue
+		}
+		sb.WriteString(logs)
+	}
+	return sb.String()
+}
+
+// conditionReason returns a short description of a Succeeded condition.
+func conditionReason(c *apis.Condition) string {
+	if c == nil {
+		return "Pending"
+	}
+	if c.Reason != "" {
+		return c.Reason
+	}
+	return string(c.Status)
+}
+
+// getLogs returns the logs of the containers of a TaskRun pod. Containers are
+// mapped to Tekton steps and sidecars using the TaskRun status, and only the
+// ones selected by the options are included.
+func getLogs(ctx context.Context, client corev1.PodInterface, name string, status *pipelinev1.TaskRunStatus, opts logOptions) (string, error) {
 	pod, err := client.Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to get Pod %s: %w", name, err)
 	}
+
+	steps := make(map[string]string, len(status.Steps))
+	for _, step := range status.Steps {
+		steps[step.Container] = step.Name
+	}
+	sidecars := make(map[string]string, len(status.Sidecars))
+	for _, sidecar := range status.Sidecars {
+		sidecars[sidecar.Container] = sidecar.Name
+	}
+
 	var sb strings.Builder
+	if opts.initContainers {
+		for _, container := range pod.Spec.InitContainers {
+			opts.write(&sb, fmt.Sprintf("\n>>> Pod %s Init Container %s\n", pod.Name, container.Name))
+			sb.WriteString(containerLogs(ctx, client, pod.Name, container.Name, opts))
+		}
+	}
+
+	foundStep := false
 	for _, container := range pod.Spec.Containers {
-		sb.WriteString(fmt.Sprintf("\n>>> Pod %s Container %s\n", pod.Name, container.Name))
-		req := client.GetLogs(pod.Name, &v1.PodLogOptions{Follow: false, Container: container.Name})
-		res, err := req.Stream(ctx)
+		stepName, isStep := steps[container.Name]
+		sidecarName, isSidecar := sidecars[container.Name]
+		if !isStep && !isSidecar {
+			// The status may not be populated yet, fallback to the container naming convention
+			if sidecarName, isSidecar = strings.CutPrefix(container.Name, "sidecar-"); !isSidecar {
+				stepName, isStep = strings.CutPrefix(container.Name, "step-")
+			}
+		}
+
+		var header string
+		switch {
+		case isSidecar:
+			if !opts.sidecars {
+				continue
+			}
+			header = fmt.Sprintf("\n>>> Pod %s Container %s (sidecar %s)\n", pod.Name, container.Name, sidecarName)
+		case isStep:
+			if opts.step != "" && opts.step != stepName && opts.step != container.Name {
+				continue
+			}
+			foundStep = true
+			header = fmt.Sprintf("\n>>> Pod %s Container %s (step %s)\n", pod.Name, container.Name, stepName)
+		default:
+			if opts.step != "" {
+				continue
+			}
+			header = fmt.Sprintf("\n>>> Pod %s Container %s\n", pod.Name, container.Name)
+		}
+		opts.write(&sb, header)
+		sb.WriteString(containerLogs(ctx, client, pod.Name, container.Name, opts))
+	}
+
+	if opts.step != "" && !foundStep {
+		return "", fmt.Errorf("step %q not found in Pod %s", opts.step, name)
+	}
+	return sb.String(), nil
+}
+
+// containerLogs returns the logs of a single container, bounded by the
+// options. Errors are reported inline so that the logs of the other
+// containers are still returned. When following, the container is waited for
+// and every line is sent to the client as soon as it is read.
+func containerLogs(ctx context.Context, client corev1.PodInterface, podName string, container string, opts logOptions) string {
+	if opts.follow {
+		if err := waitForContainer(ctx, client, podName, container); err != nil {
+			return fmt.Sprintf("failed to wait for container %q of Pod %s: %v", container, podName, err)
+		}
+	}
+
+	res, err := client.GetLogs(podName, opts.podLogOptions(container)).Stream(ctx)
+	if err != nil {
+		return fmt.Sprintf("failed to get container %q logs for Pod %s: %v", container, podName, err)
+	}
+	defer res.Close()
+
+	var reader io.Reader = res
+	if opts.limitBytes > 0 {
+		reader = io.LimitReader(res, opts.limitBytes)
+	}
+	if !opts.follow {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Sprintf("failed to read response for container %q logs for Pod %s: %v", container, podName, err)
+		}
+		return string(data)
+	}
+
+	var sb strings.Builder
+	br := bufio.NewReader(reader)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			sb.WriteString(line)
+			if opts.notify != nil {
+				opts.notify(strings.TrimSuffix(line, "\n"))
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
 		if err != nil {
-			return "", fmt.Errorf("failed to get container %q logs for Pod %s: %w", container.Name, name, err)
+			sb.WriteString(fmt.Sprintf("failed to read response for container %q logs for Pod %s: %v", container, podName, err))
+			break
 		}
-		defer res.Close()
-		data, err := io.ReadAll(res)
+	}
+	return sb.String()
+}
+
+// containerErrorReasons are the reasons a container, or its pod, waits for
+// that are not resolved without a change to the pod or to the cluster.
+var containerErrorReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"ErrImageNeverPull":          true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+	v1.PodReasonUnschedulable:    true,
+}
+
+// waitForContainer blocks until a container of a pod has started, so that its
+// logs can be followed. Pods that already completed are returned immediately.
+// Waiting stops with an error when the container waits for an error to be
+// resolved, or when the TaskRun of the pod is done.
+func waitForContainer(ctx context.Context, client corev1.PodInterface, podName string, container string) error {
+	for {
+		pod, err := client.Get(ctx, podName, metav1.GetOptions{})
 		if err != nil {
-			return "", fmt.Errorf("failed to read response for container %q logs for Pod %s: %w", container.Name, name, err)
+			return fmt.Errorf("failed to get Pod %s: %w", podName, err)
+		}
+		waiting := containerWaiting(pod, container)
+		if waiting == nil {
+			return nil
+		}
+		if containerErrorReasons[waiting.Reason] {
+			return fmt.Errorf("container is waiting: %s", describeWaiting(waiting))
+		}
+		if trName := pod.Labels[pipeline.TaskRunLabelKey]; trName != "" {
+			tr, err := scope.TaskRun(ctx, pod.Namespace, trName)
+			if err != nil {
+				return fmt.Errorf("failed to get TaskRun %s/%s: %w", pod.Namespace, trName, err)
+			}
+			if tr.IsDone() {
+				return fmt.Errorf("TaskRun %s is done but the container did not start: %s", trName, describeWaiting(waiting))
+			}
+		}
+		if err := sleep(ctx, followPollInterval); err != nil {
+			return err
 		}
-		sb.Write(data)
 	}
-	return sb.String(), nil
+}
+
+// containerWaiting returns the waiting state of a container of a pod that has
+// not started yet, and nil otherwise. The state of a container of a pod that
+// was not scheduled is the PodScheduled condition.
+func containerWaiting(pod *v1.Pod, container string) *v1.ContainerStateWaiting {
+	switch pod.Status.Phase {
+	case v1.PodSucceeded, v1.PodFailed:
+		return nil
+	}
+	statuses := append(slices.Clone(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.Name == container {
+			return status.State.Waiting
+		}
+	}
+	if pod.Status.Phase != v1.PodPending {
+		return nil
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse {
+			return &v1.ContainerStateWaiting{Reason: c.Reason, Message: c.Message}
+		}
+	}
+	return &v1.ContainerStateWaiting{}
+}
+
+// describeWaiting returns a short description of the waiting state of a
+// container.
+func describeWaiting(w *v1.ContainerStateWaiting) string {
+	if w.Reason == "" {
+		return "no reason reported"
+	}
+	if w.Message == "" {
+		return w.Reason
+	}
+	return w.Reason + ": " + w.Message
+}
+
+// waitForTaskRunPod blocks until the pod of a TaskRun has been created, or the
+// TaskRun is done.
+func waitForTaskRunPod(ctx context.Context, namespace, name string) (*pipelinev1.TaskRun, error) {
+	for {
+		tr, err := scope.TaskRun(ctx, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, name, err)
+		}
+		if tr.Status.PodName != "" || tr.IsDone() {
+			return tr, nil
+		}
+		if err := sleep(ctx, followPollInterval); err != nil {
+			return nil, fmt.Errorf("stopped waiting for the pod of TaskRun %s/%s: %w", namespace, name, err)
+		}
+	}
+}
+
+// sleep waits for the given duration, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -4,8 +4,11 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -14,10 +17,13 @@ import (
 	"github.com/tektoncd/pipeline/test"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	typedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	restclient "k8s.io/client-go/rest"
 	fakerest "k8s.io/client-go/rest/fake"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 )
 
@@ -60,6 +66,10 @@ func (f *fakePodV1Client) GetLogs(name string, opts *corev1.PodLogOptions) *rest
 	if !ok {
 		statusCode = http.StatusNotFound
 	}
+	if opts.TailLines != nil {
+		lines := strings.Split(containerLogs, "\n")
+		containerLogs = strings.Join(lines[max(0, len(lines)-int(*opts.TailLines)):], "\n")
+	}
 	fakeClient := &fakerest.RESTClient{
 		Client: fakerest.CreateHTTPClient(func(request *http.Request) (*http.Response, error) {
 			resp := &http.Response{
@@ -125,3 +135,533 @@ Goodbye!`}
 		t.Errorf("getLogs mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestGetPipelineRunLogs(t *testing.T) {
+	succeeded := duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue, Reason: "Succeeded"}}}
+	failed := duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: corev1.ConditionFalse, Reason: "Failed"}}}
+	childRef := func(name, pipelineTask string) v1.ChildStatusReference {
+		return v1.ChildStatusReference{
+			TypeMeta:         runtime.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "TaskRun"},
+			Name:             name,
+			PipelineTaskName: pipelineTask,
+		}
+	}
+	pod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "step-run"}}},
+		}
+	}
+
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+				Status: v1.PipelineRunStatus{
+					PipelineRunStatusFields: v1.PipelineRunStatusFields{
+						PipelineSpec: &v1.PipelineSpec{
+							Tasks: []v1.PipelineTask{
+								{Name: "test", RunAfter: []string{"build"}},
+								{Name: "build"},
+							},
+							Finally: []v1.PipelineTask{{Name: "cleanup"}},
+						},
+						ChildReferences: []v1.ChildStatusReference{
+							childRef("release-cleanup", "cleanup"),
+							childRef("release-test", "test"),
+							childRef("release-build", "build"),
+						},
+					},
+				},
+			},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-build", Namespace: "default"},
+				Status: v1.TaskRunStatus{
+					Status:              succeeded,
+					TaskRunStatusFields: v1.TaskRunStatusFields{PodName: "release-build-pod"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-test", Namespace: "default"},
+				Status: v1.TaskRunStatus{
+					Status: failed,
+					TaskRunStatusFields: v1.TaskRunStatusFields{
+						PodName:       "release-test-pod-retry1",
+						RetriesStatus: v1.RetriesStatus{{TaskRunStatusFields: v1.TaskRunStatusFields{PodName: "release-test-pod"}}},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-cleanup", Namespace: "default"},
+				Status: v1.TaskRunStatus{
+					Status:              succeeded,
+					TaskRunStatusFields: v1.TaskRunStatusFields{PodName: "release-cleanup-pod"},
+				},
+			},
+		},
+		Pods: []*corev1.Pod{
+			pod("release-build-pod"),
+			pod("release-test-pod"),
+			pod("release-test-pod-retry1"),
+			pod("release-cleanup-pod"),
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	kubeclientset := &fakeClient{
+		Clientset: clients.Kube,
+		logs: map[string]map[string]string{
+			"release-build-pod":       {"step-run": "built"},
+			"release-test-pod":        {"step-run": "flaky"},
+			"release-test-pod-retry1": {"step-run": "broken"},
+			"release-cleanup-pod":     {"step-run": "cleaned"},
+		},
+	}
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeclientset)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		expected  string
+	}{
+		{
+			name:      "all tasks in DAG order",
+			arguments: map[string]any{"name": "release", "namespace": "default"},
+			expected: `
+=== PipelineTask build TaskRun release-build (Succeeded)
+>>> Pod release-build-pod Container step-run (step run)
+built
+=== PipelineTask test TaskRun release-test (Failed)
+--- Attempt 1/2
+>>> Pod release-test-pod Container step-run (step run)
+flaky
+--- Attempt 2/2
+>>> Pod release-test-pod-retry1 Container step-run (step run)
+broken
+=== PipelineTask cleanup TaskRun release-cleanup (Succeeded)
+>>> Pod release-cleanup-pod Container step-run (step run)
+cleaned`,
+		},
+		{
+			name:      "failed only",
+			arguments: map[string]any{"name": "release", "namespace": "default", "failedOnly": true},
+			expected: `
+=== PipelineTask test TaskRun release-test (Failed)
+--- Attempt 1/2
+>>> Pod release-test-pod Container step-run (step run)
+flaky
+--- Attempt 2/2
+>>> Pod release-test-pod-retry1 Container step-run (step run)
+broken`,
+		},
+		{
+			name:      "selected tasks",
+			arguments: map[string]any{"name": "release", "namespace": "default", "tasks": []string{"cleanup"}},
+			expected: `
+=== PipelineTask cleanup TaskRun release-cleanup (Succeeded)
+>>> Pod release-cleanup-pod Container step-run (step run)
+cleaned`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      "get_pipelinerun_logs",
+				Arguments: test.arguments,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			received, _ := response.Content[0].(*mcp.TextContent)
+			if diff := cmp.Diff(test.expected, received.Text); diff != "" {
+				t.Errorf("get_pipelinerun_logs mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetTaskRunLogsOptions(t *testing.T) {
+	data := test.Data{
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default"},
+				Status: v1.TaskRunStatus{TaskRunStatusFields: v1.TaskRunStatusFields{
+					PodName: "build-pod",
+					Steps: []v1.StepState{
+						{Name: "compile", Container: "step-compile"},
+						{Name: "unit-test", Container: "step-unit-test"},
+					},
+					Sidecars: []v1.SidecarState{{Name: "docker", Container: "sidecar-docker"}},
+				}},
+			},
+		},
+		Pods: []*corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "build-pod", Namespace: "default"},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "prepare"}},
+					Containers: []corev1.Container{
+						{Name: "step-compile"},
+						{Name: "step-unit-test"},
+						{Name: "sidecar-docker"},
+					},
+				},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	kubeclientset := &fakeClient{
+		Clientset: clients.Kube,
+		logs: map[string]map[string]string{
+			"build-pod": {
+				"prepare":        "prepared",
+				"step-compile":   "compiling\ncompiled",
+				"step-unit-test": "testing\nFAIL: TestFoo",
+				"sidecar-docker": "dockerd started",
+			},
+		},
+	}
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeclientset)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		expected  string
+	}{
+		{
+			name:      "steps only by default",
+			arguments: map[string]any{"name": "build"},
+			expected: `
+>>> Pod build-pod Container step-compile (step compile)
+compiling
+compiled
+>>> Pod build-pod Container step-unit-test (step unit-test)
+testing
+FAIL: TestFoo`,
+		},
+		{
+			name:      "single step with tail",
+			arguments: map[string]any{"name": "build", "step": "unit-test", "tailLines": 1},
+			expected: `
+>>> Pod build-pod Container step-unit-test (step unit-test)
+FAIL: TestFoo`,
+		},
+		{
+			name:      "limit bytes",
+			arguments: map[string]any{"name": "build", "step": "compile", "limitBytes": 9},
+			expected: `
+>>> Pod build-pod Container step-compile (step compile)
+compiling`,
+		},
+		{
+			name:      "init containers and sidecars",
+			arguments: map[string]any{"name": "build", "step": "compile", "initContainers": true, "sidecars": true},
+			expected: `
+>>> Pod build-pod Init Container prepare
+prepared
+>>> Pod build-pod Container step-compile (step compile)
+compiling
+compiled
+>>> Pod build-pod Container sidecar-docker (sidecar docker)
+dockerd started`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      "get_taskrun_logs",
+				Arguments: test.arguments,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			received, _ := response.Content[0].(*mcp.TextContent)
+			if diff := cmp.Diff(test.expected, received.Text); diff != "" {
+				t.Errorf("get_taskrun_logs mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("unknown step", func(t *testing.T) {
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "get_taskrun_logs",
+			Arguments: map[string]any{"name": "build", "step": "deploy"},
+		})
+		if err == nil || !strings.Contains(err.Error(), `step "deploy" not found`) {
+			t.Fatalf("expected unknown step error, got %v", err)
+		}
+	})
+}
+
+func TestGetTaskRunLogsFollow(t *testing.T) {
+	data := test.Data{
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default"},
+				Status: v1.TaskRunStatus{TaskRunStatusFields: v1.TaskRunStatusFields{
+					PodName: "build-pod",
+					Steps:   []v1.StepState{{Name: "compile", Container: "step-compile"}},
+				}},
+			},
+		},
+		Pods: []*corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "build-pod", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "step-compile"}}},
+				Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	kubeclientset := &fakeClient{
+		Clientset: clients.Kube,
+		logs: map[string]map[string]string{
+			"build-pod": {"step-compile": "compiling\ncompiled\n"},
+		},
+	}
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeclientset)
+
+	var mu sync.Mutex
+	var progress, logging []string
+	ss, cs := newSessionWithClientOptions(t, ctx, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, _ *mcp.ClientSession, params *mcp.ProgressNotificationParams) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, params.Message)
+		},
+		LoggingMessageHandler: func(_ context.Context, _ *mcp.ClientSession, params *mcp.LoggingMessageParams) {
+			mu.Lock()
+			defer mu.Unlock()
+			logging = append(logging, params.Data.(string))
+		},
+	})
+	defer ss.Close()
+	defer cs.Close()
+
+	expected := []string{
+		">>> Pod build-pod Container step-compile (step compile)",
+		"compiling",
+		"compiled",
+	}
+	received := func(messages *[]string) []string {
+		// Notifications are handled asynchronously by the client
+		for range 50 {
+			mu.Lock()
+			n := len(*messages)
+			mu.Unlock()
+			if n >= len(expected) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(*messages)
+	}
+
+	t.Run("progress notifications", func(t *testing.T) {
+		params := &mcp.CallToolParams{
+			// SetProgressToken does not allocate the meta map
+			Meta:      mcp.Meta{},
+			Name:      "get_taskrun_logs",
+			Arguments: map[string]any{"name": "build", "follow": true},
+		}
+		params.SetProgressToken(1)
+		response, err := cs.CallTool(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		text, _ := response.Content[0].(*mcp.TextContent)
+		if diff := cmp.Diff("\n"+strings.Join(expected, "\n")+"\n", text.Text); diff != "" {
+			t.Errorf("get_taskrun_logs mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(expected, received(&progress)); diff != "" {
+			t.Errorf("progress notifications mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("without progress token nor logging level", func(t *testing.T) {
+		// The info logging notifications would be dropped by the session
+		for _, level := range []mcp.LoggingLevel{"", "warning"} {
+			if level != "" {
+				if err := cs.SetLevel(ctx, &mcp.SetLevelParams{Level: level}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      "get_taskrun_logs",
+				Arguments: map[string]any{"name": "build", "follow": true},
+			})
+			if err == nil || !strings.Contains(err.Error(), "follow requires a progress token") {
+				t.Fatalf("expected follow to be rejected with logging level %q, got %v", level, err)
+			}
+		}
+	})
+
+	t.Run("logging notifications", func(t *testing.T) {
+		if err := cs.SetLevel(ctx, &mcp.SetLevelParams{Level: "info"}); err != nil {
+			t.Fatal(err)
+		}
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "get_taskrun_logs",
+			Arguments: map[string]any{"name": "build", "follow": true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(expected, received(&logging)); diff != "" {
+			t.Errorf("logging notifications mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("failedOnly with follow", func(t *testing.T) {
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "get_pipelinerun_logs",
+			Arguments: map[string]any{"name": "release", "follow": true, "failedOnly": true},
+		})
+		if err == nil || !strings.Contains(err.Error(), "failedOnly cannot be used with follow") {
+			t.Fatalf("expected failedOnly error, got %v", err)
+		}
+	})
+}
+
+func TestContainerWaiting(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   corev1.PodStatus
+		expected *corev1.ContainerStateWaiting
+	}{
+		{
+			name:     "pending pod without statuses",
+			status:   corev1.PodStatus{Phase: corev1.PodPending},
+			expected: &corev1.ContainerStateWaiting{},
+		},
+		{
+			name: "unschedulable pod",
+			status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "0/3 nodes are available"},
+			}},
+			expected: &corev1.ContainerStateWaiting{Reason: "Unschedulable", Message: "0/3 nodes are available"},
+		},
+		{
+			name: "waiting container",
+			status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "step-build", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+			}},
+			expected: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"},
+		},
+		{
+			name: "running container",
+			status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "step-build", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			}},
+		},
+		{
+			name: "failed pod",
+			status: corev1.PodStatus{Phase: corev1.PodFailed, ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "step-build", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: test.status}
+			if diff := cmp.Diff(test.expected, containerWaiting(pod, "step-build")); diff != "" {
+				t.Errorf("containerWaiting() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetTaskRunLogsFollowWaiting(t *testing.T) {
+	waitingPod := func(name, taskRun string, waiting *corev1.ContainerStateWaiting) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"tekton.dev/taskRun": taskRun}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "step-compile"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodPending, ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "step-compile", State: corev1.ContainerState{Waiting: waiting}},
+			}},
+		}
+	}
+	taskRun := func(name string, status corev1.ConditionStatus) *v1.TaskRun {
+		return &v1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: v1.TaskRunStatus{
+				Status:              succeededCondition(status, ""),
+				TaskRunStatusFields: v1.TaskRunStatusFields{PodName: name + "-pod"},
+			},
+		}
+	}
+	data := test.Data{
+		TaskRuns: []*v1.TaskRun{
+			taskRun("pull", corev1.ConditionUnknown),
+			taskRun("timeout", corev1.ConditionFalse),
+		},
+		Pods: []*corev1.Pod{
+			waitingPod("pull-pod", "pull", &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: `Back-off pulling image "golang:missing"`}),
+			waitingPod("timeout-pod", "timeout", &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}),
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	ctx = context.WithValue(ctx, kubeclient.Key{}, &fakeClient{Clientset: clients.Kube})
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{
+			name:     "pull",
+			expected: `failed to wait for container "step-compile" of Pod pull-pod: container is waiting: ImagePullBackOff: Back-off pulling image "golang:missing"`,
+		},
+		{
+			name:     "timeout",
+			expected: `failed to wait for container "step-compile" of Pod timeout-pod: TaskRun timeout is done but the container did not start: ContainerCreating`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := &mcp.CallToolParams{
+				Meta:      mcp.Meta{},
+				Name:      "get_taskrun_logs",
+				Arguments: map[string]any{"name": test.name, "follow": true},
+			}
+			params.SetProgressToken(test.name)
+			response, err := cs.CallTool(ctx, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, _ := response.Content[0].(*mcp.TextContent)
+			if !strings.Contains(text.Text, test.expected) {
+				t.Errorf("expected %q in the logs, got %q", test.expected, text.Text)
+			}
+		})
+	}
+}
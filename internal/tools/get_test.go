@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
@@ -216,3 +217,83 @@ func TestGetOperationsErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestGetFields(t *testing.T) {
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{{
+			ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+			Spec: v1.PipelineRunSpec{
+				PipelineRef: &v1.PipelineRef{Name: "release"},
+				Params:      v1.Params{{Name: "version", Value: *v1.NewStructuredValues("v1.2.0")}},
+			},
+			Status: v1.PipelineRunStatus{PipelineRunStatusFields: v1.PipelineRunStatusFields{
+				ChildReferences: []v1.ChildStatusReference{
+					{Name: "release-build", PipelineTaskName: "build"},
+					{Name: "release-push", PipelineTaskName: "push"},
+				},
+				Results: []v1.PipelineRunResult{{Name: "digest", Value: *v1.NewStructuredValues("sha256:abc")}},
+			}},
+		}},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		expected string
+	}{{
+		name: "yaml",
+		args: map[string]any{
+			"name":   "release",
+			"fields": []string{".status.results", "{.status.childReferences[*].pipelineTaskName}", ".spec.params[0].value", ".status.startTime"},
+		},
+		expected: `.spec.params[0].value: v1.2.0
+.status.results:
+- name: digest
+  value: sha256:abc
+.status.startTime: null
+'{.status.childReferences[*].pipelineTaskName}':
+- build
+- push
+`,
+	}, {
+		name: "json",
+		args: map[string]any{
+			"name":   "release",
+			"output": "json",
+			"fields": []string{".status.childReferences[?(@.pipelineTaskName=='push')].name", ".spec.pipelineRef.name"},
+		},
+		expected: `{
+  ".spec.pipelineRef.name": "release",
+  ".status.childReferences[?(@.pipelineTaskName=='push')].name": [
+    "release-push"
+  ]
+}`,
+	}, {
+		name:     "invalid expression",
+		args:     map[string]any{"name": "release", "fields": []string{".status.results[0"}},
+		expected: `Error: invalid field ".status.results[0": unterminated array`,
+	}, {
+		name:     "template",
+		args:     map[string]any{"name": "release", "fields": []string{"{.metadata.name}{.metadata.namespace}"}},
+		expected: `Error: invalid field "{.metadata.name}{.metadata.namespace}": expected a single JSONPath expression`,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "get_pipelinerun", Arguments: tc.args})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, response.Content[0].(*mcp.TextContent).Text); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
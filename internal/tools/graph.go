@@ -0,0 +1,423 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	kindPipeline = "pipeline"
+
+	graphFormatMermaid = "mermaid"
+	graphFormatDOT     = "dot"
+
+	// graphStatusSkipped is the status of the skipped pipeline tasks of a
+	// PipelineRun, next to the run statuses of their TaskRuns.
+	graphStatusSkipped = "skipped"
+)
+
+// graphStatusColors are the fill and stroke colors of the nodes, by status.
+var graphStatusColors = map[string][2]string{
+	runStatusSucceeded: {"#dafbe1", "#1a7f37"},
+	runStatusFailed:    {"#ffebe9", "#cf222e"},
+	runStatusTimedOut:  {"#ffebe9", "#cf222e"},
+	runStatusCancelled: {"#fff8c5", "#9a6700"},
+	runStatusRunning:   {"#ddf4ff", "#0969da"},
+	runStatusPending:   {"#f6f8fa", "#57606a"},
+	graphStatusSkipped: {"#f6f8fa", "#8c959f"},
+}
+
+type renderPipelineGraphParams struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Format    string `json:"format"`
+}
+
+// pipelineGraph is the DAG of the tasks of a pipeline.
+type pipelineGraph struct {
+	name  string
+	nodes []graphNode
+	edges []graphEdge
+}
+
+type graphNode struct {
+	name    string
+	finally bool
+	// when describes the when expressions guarding the task
+	when []string
+	// status is the status of the task in a PipelineRun, empty if it did not
+	// run yet or for a Pipeline
+	status string
+}
+
+// graphEdge is a dependency between two tasks: an ordering with runAfter,
+// results consumed by the params or the when expressions of the task, or
+// the implicit dependency of the finally tasks on the last tasks.
+type graphEdge struct {
+	from, to string
+	results  []string
+	// when is true when the results are only consumed by when expressions
+	when    bool
+	finally bool
+}
+
+func renderPipelineGraph() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[renderPipelineGraphParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["kind"].Description = "Kind of the object to render (pipeline or pipelinerun)"
+	scheme.Properties["kind"].Default = json.RawMessage(`"pipeline"`)
+	scheme.Properties["name"].Description = "Name of the Pipeline or PipelineRun"
+	scheme.Properties["namespace"].Description = "Namespace of the Pipeline or PipelineRun"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["format"].Description = "Format of the graph (mermaid or dot)"
+	scheme.Properties["format"].Enum = []any{graphFormatMermaid, graphFormatDOT}
+	scheme.Properties["format"].Default = json.RawMessage(`"mermaid"`)
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"render_pipeline_graph",
+		"Render the task graph of a Pipeline, or of the resolved pipeline of a PipelineRun with the tasks colored by status, "+
+			"built from runAfter, result references, when expressions and finally, as Mermaid or Graphviz DOT",
+		handlerRenderPipelineGraph,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerRenderPipelineGraph(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[renderPipelineGraphParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	kind := strings.ToLower(params.Arguments.Kind)
+	if kind == "" {
+		kind = kindPipeline
+	}
+	format := strings.ToLower(params.Arguments.Format)
+	if format == "" {
+		format = graphFormatMermaid
+	}
+	if format != graphFormatMermaid && format != graphFormatDOT {
+		return nil, fmt.Errorf("unsupported format %q, expected %s or %s", params.Arguments.Format, graphFormatMermaid, graphFormatDOT)
+	}
+
+	var graph *pipelineGraph
+	switch kind {
+	case kindPipeline:
+		pipeline, err := scope.Pipeline(ctx, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Pipeline %s/%s: %w", namespace, name, err)
+		}
+		graph = newPipelineGraph(pipeline.Name, &pipeline.Spec)
+	case kindPipelineRun:
+		pr, err := scope.PipelineRun(ctx, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
+		}
+		spec, err := pipelineSpecFor(ctx, pr)
+		if err != nil {
+			return nil, err
+		}
+		graph = newPipelineGraph(pr.Name, spec)
+		graph.setStatuses(pipelineTaskStatuses(ctx, pr))
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, expected %s or %s", params.Arguments.Kind, kindPipeline, kindPipelineRun)
+	}
+
+	if format == graphFormatDOT {
+		return result(graph.dot()), nil
+	}
+	return result(graph.mermaid()), nil
+}
+
+// newPipelineGraph builds the DAG of a pipeline spec. The nodes follow the
+// order of dagOrder, and the edges the order of their target then of their
+// source.
+func newPipelineGraph(name string, spec *v1.PipelineSpec) *pipelineGraph {
+	graph := &pipelineGraph{name: name}
+	order := dagOrder(spec)
+	tasks := make(map[string]v1.PipelineTask, len(spec.Tasks)+len(spec.Finally))
+	finally := make(map[string]bool, len(spec.Finally))
+	for _, pt := range spec.Tasks {
+		tasks[pt.Name] = pt
+	}
+	for _, pt := range spec.Finally {
+		tasks[pt.Name] = pt
+		finally[pt.Name] = true
+	}
+
+	// The last tasks are the ones no other task depends on, which the
+	// finally tasks implicitly run after
+	dependedOn := map[string]bool{}
+	for _, name := range order {
+		pt := tasks[name]
+		graph.nodes = append(graph.nodes, graphNode{name: name, finally: finally[name], when: describeWhen(pt.When)})
+		edges := taskEdges(pt)
+		if !finally[name] {
+			for _, e := range edges {
+				dependedOn[e.from] = true
+			}
+		} else {
+			for _, last := range spec.Tasks {
+				if !slices.ContainsFunc(edges, func(e graphEdge) bool { return e.from == last.Name }) {
+					edges = append(edges, graphEdge{from: last.Name, to: name, finally: true})
+				}
+			}
+		}
+		graph.edges = append(graph.edges, edges...)
+	}
+
+	// Drop the finally edges of the tasks that are not the last ones, and the
+	// edges from unknown tasks of invalid pipelines
+	graph.edges = slices.DeleteFunc(graph.edges, func(e graphEdge) bool {
+		_, known := tasks[e.from]
+		return !known || (e.finally && dependedOn[e.from])
+	})
+	index := func(name string) int { return slices.Index(order, name) }
+	slices.SortStableFunc(graph.edges, func(a, b graphEdge) int {
+		if c := index(a.to) - index(b.to); c != 0 {
+			return c
+		}
+		return index(a.from) - index(b.from)
+	})
+	return graph
+}
+
+// taskEdges returns the dependencies of a pipeline task on other tasks, one
+// edge by task.
+func taskEdges(pt v1.PipelineTask) []graphEdge {
+	var edges []graphEdge
+	edge := func(from string) *graphEdge {
+		for i := range edges {
+			if edges[i].from == from {
+				return &edges[i]
+			}
+		}
+		edges = append(edges, graphEdge{from: from, to: pt.Name, when: true})
+		return &edges[len(edges)-1]
+	}
+	addResult := func(e *graphEdge, result string) {
+		if !slices.Contains(e.results, result) {
+			e.results = append(e.results, result)
+		}
+	}
+
+	whenRefs := map[string]bool{}
+	for _, we := range pt.When {
+		expressions, _ := we.GetVarSubstitutionExpressions()
+		for _, ref := range v1.NewResultRefs(expressions) {
+			addResult(edge(ref.PipelineTask), ref.Result)
+			whenRefs[ref.PipelineTask+"."+ref.Result] = true
+		}
+	}
+	for _, ref := range v1.PipelineTaskResultRefs(&pt) {
+		e := edge(ref.PipelineTask)
+		addResult(e, ref.Result)
+		if !whenRefs[ref.PipelineTask+"."+ref.Result] {
+			e.when = false
+		}
+	}
+	for _, runAfter := range pt.RunAfter {
+		e := edge(runAfter)
+		if len(e.results) == 0 {
+			e.when = false
+		}
+	}
+	for i := range edges {
+		slices.Sort(edges[i].results)
+	}
+	return edges
+}
+
+// describeWhen returns a short description of each when expression.
+func describeWhen(wes v1.WhenExpressions) []string {
+	var descriptions []string
+	for _, we := range wes {
+		if we.CEL != "" {
+			descriptions = append(descriptions, we.CEL)
+			continue
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s %s [%s]", we.Input, we.Operator, strings.Join(we.Values, ", ")))
+	}
+	return descriptions
+}
+
+// pipelineTaskStatuses returns the status of the pipeline tasks of a
+// PipelineRun, from their TaskRuns and the skipped tasks. The status of a
+// matrixed task is the least successful one of its TaskRuns.
+func pipelineTaskStatuses(ctx context.Context, pr *v1.PipelineRun) map[string]string {
+	rank := []string{runStatusFailed, runStatusTimedOut, runStatusCancelled, runStatusRunning, runStatusPending, runStatusSucceeded}
+	statuses := map[string]string{}
+	for _, child := range pr.Status.ChildReferences {
+		if child.Kind != taskRunKind {
+			continue
+		}
+		status := runStatusPending
+		if tr, err := scope.TaskRun(ctx, pr.Namespace, child.Name); err == nil {
+			status = runStatus(tr.Status.GetCondition(apis.ConditionSucceeded))
+		}
+		if current, ok := statuses[child.PipelineTaskName]; !ok || slices.Index(rank, status) < slices.Index(rank, current) {
+			statuses[child.PipelineTaskName] = status
+		}
+	}
+	for _, skipped := range pr.Status.SkippedTasks {
+		statuses[skipped.Name] = graphStatusSkipped
+	}
+	return statuses
+}
+
+func (g *pipelineGraph) setStatuses(statuses map[string]string) {
+	for i := range g.nodes {
+		g.nodes[i].status = statuses[g.nodes[i].name]
+	}
+}
+
+func (e graphEdge) label() string {
+	label := strings.Join(e.results, ", ")
+	if e.when {
+		label = "when " + label
+	}
+	return label
+}
+
+// mermaid renders the graph as a Mermaid flowchart. The node ids are
+// generated, as task names such as "end" are reserved words in Mermaid.
+func (g *pipelineGraph) mermaid() string {
+	ids := make(map[string]string, len(g.nodes))
+	for i, n := range g.nodes {
+		ids[n.name] = fmt.Sprintf("t%d", i)
+	}
+	escape := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "|", "#124;").Replace
+	node := func(n graphNode) string {
+		lines := []string{escape(n.name)}
+		for _, when := range n.when {
+			lines = append(lines, escape("when "+when))
+		}
+		if n.status != "" {
+			lines = append(lines, n.status)
+		}
+		return fmt.Sprintf("%s[\"%s\"]", ids[n.name], strings.Join(lines, "<br/>"))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ntitle: %s\n---\nflowchart TD\n", g.name)
+	var finally []graphNode
+	for _, n := range g.nodes {
+		if n.finally {
+			finally = append(finally, n)
+			continue
+		}
+		fmt.Fprintf(&b, "    %s\n", node(n))
+	}
+	if len(finally) > 0 {
+		b.WriteString("    subgraph finally\n")
+		for _, n := range finally {
+			fmt.Fprintf(&b, "        %s\n", node(n))
+		}
+		b.WriteString("    end\n")
+	}
+	for _, e := range g.edges {
+		arrow := "-->"
+		if e.when || e.finally {
+			arrow = "-.->"
+		}
+		if label := e.label(); label != "" {
+			arrow += "|" + escape(label) + "|"
+		}
+		fmt.Fprintf(&b, "    %s %s %s\n", ids[e.from], arrow, ids[e.to])
+	}
+
+	var statuses []string
+	nodes := map[string][]string{}
+	for _, n := range g.nodes {
+		if n.status == "" {
+			continue
+		}
+		if _, ok := nodes[n.status]; !ok {
+			statuses = append(statuses, n.status)
+		}
+		nodes[n.status] = append(nodes[n.status], ids[n.name])
+	}
+	for _, status := range statuses {
+		colors := graphStatusColors[status]
+		fmt.Fprintf(&b, "    classDef %s fill:%s,stroke:%s\n", status, colors[0], colors[1])
+		fmt.Fprintf(&b, "    class %s %s\n", strings.Join(nodes[status], ","), status)
+	}
+	return b.String()
+}
+
+// dot renders the graph in the Graphviz DOT language.
+func (g *pipelineGraph) dot() string {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+	}
+	node := func(n graphNode) string {
+		label := n.name
+		for _, when := range n.when {
+			label += "\nwhen " + when
+		}
+		if n.status != "" {
+			label += "\n" + n.status
+		}
+		attrs := []string{"label=" + quote(label)}
+		if colors, ok := graphStatusColors[n.status]; ok {
+			style := "rounded,filled"
+			if n.status == graphStatusSkipped {
+				style += ",dashed"
+			}
+			attrs = append(attrs, "style="+quote(style), "fillcolor="+quote(colors[0]), "color="+quote(colors[1]))
+		}
+		return fmt.Sprintf("%s [%s];", quote(n.name), strings.Join(attrs, ", "))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n    rankdir=TB;\n    node [shape=box, style=rounded];\n", quote(g.name))
+	var finally []graphNode
+	for _, n := range g.nodes {
+		if n.finally {
+			finally = append(finally, n)
+			continue
+		}
+		fmt.Fprintf(&b, "    %s\n", node(n))
+	}
+	if len(finally) > 0 {
+		b.WriteString("    subgraph cluster_finally {\n        label=\"finally\";\n        style=dashed;\n")
+		for _, n := range finally {
+			fmt.Fprintf(&b, "        %s\n", node(n))
+		}
+		b.WriteString("    }\n")
+	}
+	for _, e := range g.edges {
+		var attrs []string
+		if label := e.label(); label != "" {
+			attrs = append(attrs, "label="+quote(label))
+		}
+		if e.when || e.finally {
+			attrs = append(attrs, "style=dashed")
+		}
+		edge := fmt.Sprintf("    %s -> %s", quote(e.from), quote(e.to))
+		if len(attrs) > 0 {
+			edge += " [" + strings.Join(attrs, ", ") + "]"
+		}
+		b.WriteString(edge + ";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+func TestRenderPipelineGraph(t *testing.T) {
+	spec := v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{
+			{Name: "fetch"},
+			{Name: "build", RunAfter: []string{"fetch"}},
+			{Name: "test", RunAfter: []string{"fetch"}},
+			{
+				Name:   "deploy",
+				Params: v1.Params{{Name: "image", Value: *v1.NewStructuredValues("$(tasks.build.results.image)")}},
+				When: v1.WhenExpressions{{
+					Input:    "$(tasks.test.results.coverage)",
+					Operator: selection.NotIn,
+					Values:   []string{"low"},
+				}},
+			},
+		},
+		Finally: []v1.PipelineTask{
+			{Name: "notify", Params: v1.Params{{Name: "digest", Value: *v1.NewStructuredValues("$(tasks.build.results.digest)")}}},
+		},
+	}
+	data := test.Data{
+		Pipelines: []*v1.Pipeline{{
+			ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+			Spec:       spec,
+		}},
+		PipelineRuns: []*v1.PipelineRun{{
+			ObjectMeta: metav1.ObjectMeta{Name: "release-run", Namespace: "default"},
+			Status: v1.PipelineRunStatus{PipelineRunStatusFields: v1.PipelineRunStatusFields{
+				PipelineSpec: &spec,
+				ChildReferences: []v1.ChildStatusReference{
+					{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-run-fetch", PipelineTaskName: "fetch"},
+					{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-run-build", PipelineTaskName: "build"},
+					{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-run-test", PipelineTaskName: "test"},
+					{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-run-notify", PipelineTaskName: "notify"},
+				},
+				SkippedTasks: []v1.SkippedTask{{Name: "deploy", Reason: v1.WhenExpressionsSkip}},
+			}},
+		}},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-run-fetch", Namespace: "default"},
+				Status:     v1.TaskRunStatus{Status: succeededCondition(corev1.ConditionTrue, "Succeeded")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-run-build", Namespace: "default"},
+				Status:     v1.TaskRunStatus{Status: succeededCondition(corev1.ConditionTrue, "Succeeded")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-run-test", Namespace: "default"},
+				Status:     v1.TaskRunStatus{Status: succeededCondition(corev1.ConditionFalse, "Failed")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-run-notify", Namespace: "default"},
+				Status:     v1.TaskRunStatus{Status: succeededCondition(corev1.ConditionUnknown, "Running")},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		expected  string
+	}{{
+		name:      "pipeline as mermaid",
+		arguments: map[string]any{"name": "release"},
+		expected: `---
+title: release
+---
+flowchart TD
+    t0["fetch"]
+    t1["build"]
+    t2["test"]
+    t3["deploy<br/>when $(tasks.test.results.coverage) notin [low]"]
+    subgraph finally
+        t4["notify"]
+    end
+    t0 --> t1
+    t0 --> t2
+    t1 -->|image| t3
+    t2 -.->|when coverage| t3
+    t1 -->|digest| t4
+    t3 -.-> t4
+`,
+	}, {
+		name:      "pipelinerun as dot",
+		arguments: map[string]any{"kind": "pipelinerun", "name": "release-run", "format": "dot"},
+		expected: `digraph "release-run" {
+    rankdir=TB;
+    node [shape=box, style=rounded];
+    "fetch" [label="fetch\nsucceeded", style="rounded,filled", fillcolor="#dafbe1", color="#1a7f37"];
+    "build" [label="build\nsucceeded", style="rounded,filled", fillcolor="#dafbe1", color="#1a7f37"];
+    "test" [label="test\nfailed", style="rounded,filled", fillcolor="#ffebe9", color="#cf222e"];
+    "deploy" [label="deploy\nwhen $(tasks.test.results.coverage) notin [low]\nskipped", style="rounded,filled,dashed", fillcolor="#f6f8fa", color="#8c959f"];
+    subgraph cluster_finally {
+        label="finally";
+        style=dashed;
+        "notify" [label="notify\nrunning", style="rounded,filled", fillcolor="#ddf4ff", color="#0969da"];
+    }
+    "fetch" -> "build";
+    "fetch" -> "test";
+    "build" -> "deploy" [label="image"];
+    "test" -> "deploy" [label="when coverage", style=dashed];
+    "build" -> "notify" [label="digest"];
+    "deploy" -> "notify" [style=dashed];
+}
+`,
+	}, {
+		name:      "pipelinerun as mermaid",
+		arguments: map[string]any{"kind": "pipelinerun", "name": "release-run"},
+		expected: `---
+title: release-run
+---
+flowchart TD
+    t0["fetch<br/>succeeded"]
+    t1["build<br/>succeeded"]
+    t2["test<br/>failed"]
+    t3["deploy<br/>when $(tasks.test.results.coverage) notin [low]<br/>skipped"]
+    subgraph finally
+        t4["notify<br/>running"]
+    end
+    t0 --> t1
+    t0 --> t2
+    t1 -->|image| t3
+    t2 -.->|when coverage| t3
+    t1 -->|digest| t4
+    t3 -.-> t4
+    classDef succeeded fill:#dafbe1,stroke:#1a7f37
+    class t0,t1 succeeded
+    classDef failed fill:#ffebe9,stroke:#cf222e
+    class t2 failed
+    classDef skipped fill:#f6f8fa,stroke:#8c959f
+    class t3 skipped
+    classDef running fill:#ddf4ff,stroke:#0969da
+    class t4 running
+`,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "render_pipeline_graph", Arguments: tc.arguments})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, response.Content[0].(*mcp.TextContent).Text); diff != "" {
+				t.Errorf("graph mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, arguments := range []map[string]any{
+		{"name": "missing"},
+		{"name": "release", "kind": "task"},
+		{"name": "release", "format": "svg"},
+	} {
+		if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "render_pipeline_graph", Arguments: arguments}); err == nil {
+			t.Errorf("expected an error for %v", arguments)
+		}
+	}
+}
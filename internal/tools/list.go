@@ -5,23 +5,133 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
-	v1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
-	pipelineinformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/pipeline"
-	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/pipelinerun"
-	taskinformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/task"
-	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/taskrun"
-	stepactioninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/stepaction"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
+const (
+	listOutputSummary = "summary"
+	listOutputFull    = "full"
+)
+
 type listParams struct {
-	Namespace     string `json:"namespace"`
-	LabelSelector string `json:"labelSelector"`
-	Prefix        string `json:"prefix"`
+	Namespace     string   `json:"namespace"`
+	LabelSelector string   `json:"labelSelector"`
+	Prefix        string   `json:"prefix"`
+	Filter        string   `json:"filter"`
+	Output        string   `json:"output"`
+	Limit         int      `json:"limit"`
+	Continue      string   `json:"continue"`
+	SortBy        string   `json:"sortBy"`
+	Order         string   `json:"order"`
+	Status        string   `json:"status"`
+	Pipeline      string   `json:"pipeline"`
+	Task          string   `json:"task"`
+	Since         string   `json:"since"`
+	Until         string   `json:"until"`
+	TimeField     string   `json:"timeField"`
+	Fields        []string `json:"fields"`
+}
+
+// listSchema returns the input schema of a list tool. ref is the property
+// filtering runs by their referenced Pipeline or Task, and is empty for the
+// kinds that are not runs.
+func listSchema(kind, ref string) (mcp.ToolOption, error) {
+	scheme, err := jsonschema.For[listParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["namespace"].Description = fmt.Sprintf("Namespace to list %s from, all namespaces if empty", kind)
+	scheme.Properties["labelSelector"].Description = fmt.Sprintf("Label selector to filter %s", kind)
+	scheme.Properties["prefix"].Description = fmt.Sprintf("Name prefix to filter %s", kind)
+	scheme.Properties["filter"].Description = celFilterDescription
+	scheme.Properties["output"].Description = "Output mode: summary (a compact projection of each object) or full (the complete objects)"
+	scheme.Properties["output"].Default = json.RawMessage(`"summary"`)
+	scheme.Properties["fields"].Description = fieldsDescription + ", in which case the name, namespace and values of the fields are returned for each object"
+	paginationDescriptions(scheme, ref != "")
+	runFilterDescriptions(scheme, kind, ref)
+	scheme.Required = nil
+
+	return mcp.Input(mcp.Schema(scheme)), nil
+}
+
+// listOutput validates the output mode of a list tool, summary being the
+// default.
+func listOutput(output string) (string, error) {
+	switch output {
+	case "":
+		return listOutputSummary, nil
+	case listOutputSummary, listOutputFull:
+		return output, nil
+	default:
+		return "", fmt.Errorf("invalid output %q, expected %s or %s", output, listOutputSummary, listOutputFull)
+	}
+}
+
+// listResult marshals a page of listed objects, their summaries or the values
+// of the requested fields to JSON. When more objects are available, the
+// continue token of the next page is returned as a second content. When the
+// informers do not cache every object, where the objects were read from is
+// returned as the last content.
+func listResult[T metav1.Object](page listPage[T], source scope.Source, output string, fields []string, summarize func(T) any) (*mcp.CallToolResultFor[string], error) {
+	var v any = page.items
+	switch {
+	case len(fields) > 0:
+		paths, err := parseFields(fields)
+		if err != nil {
+			return nil, err
+		}
+		projections := make([]map[string]any, 0, len(page.items))
+		for _, item := range page.items {
+			projection, err := projectFields(item, paths)
+			if err != nil {
+				return nil, err
+			}
+			projection["name"] = item.GetName()
+			projection["namespace"] = item.GetNamespace()
+			projections = append(projections, projection)
+		}
+		v = projections
+	case output == listOutputSummary:
+		summaries := make([]any, 0, len(page.items))
+		for _, item := range page.items {
+			summaries = append(summaries, summarize(item))
+		}
+		v = summaries
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource to JSON: %w", err)
+	}
+
+	res := result(string(data))
+	if page.next != "" {
+		res.Content = append(res.Content, &mcp.TextContent{
+			Text: fmt.Sprintf("%d more objects, use continue %q to get the next page", page.remaining, page.next),
+		})
+	}
+	// Only objects within the scope of the informers are cached, tell where
+	// the others were read from
+	if !source.Scope.All() {
+		res.Content = append(res.Content, &mcp.TextContent{Text: source.String()})
+	}
+	return res, nil
+}
+
+func pipelineRunTimes(pr *v1.PipelineRun) (*metav1.Time, *metav1.Time) {
+	return pr.Status.StartTime, pr.Status.CompletionTime
+}
+
+func taskRunTimes(tr *v1.TaskRun) (*metav1.Time, *metav1.Time) {
+	return tr.Status.StartTime, tr.Status.CompletionTime
 }
 
 func parseLabelSelector(lselector string) (labels.Selector, error) {
@@ -41,12 +151,17 @@ func filterList[T metav1.Object](in []T, prefix string) []T {
 	return out
 }
 
-func listTasks() *mcp.ServerTool {
+func listTasks() (*mcp.ServerTool, error) {
+	schema, err := listSchema("Tasks", "")
+	if err != nil {
+		return nil, err
+	}
 	return mcp.NewServerTool(
 		"list_tasks",
 		"List tasks in the cluster with filtering options",
 		handlerListTasks,
-	)
+		schema,
+	), nil
 }
 
 func handlerListTasks(
@@ -57,27 +172,19 @@ func handlerListTasks(
 	namespace := params.Arguments.Namespace
 	lselector := params.Arguments.LabelSelector
 	prefix := params.Arguments.Prefix
+	output, err := listOutput(params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
 
 	selector, err := parseLabelSelector(lselector)
 	if err != nil {
 		return nil, err
 	}
 
-	taskInformer := taskinformer.Get(ctx)
-
-	var trs []*v1.Task
-
-	if namespace == "" {
-		// No namespace, searching all PipelineRuns
-		trs, err = taskInformer.Lister().List(selector)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		trs, err = taskInformer.Lister().Tasks(namespace).List(selector)
-		if err != nil {
-			return nil, err
-		}
+	trs, source, err := scope.Tasks(ctx, namespace, selector)
+	if err != nil {
+		return nil, err
 	}
 
 	// Filter after the fact
@@ -85,20 +192,30 @@ func handlerListTasks(
 		trs = filterList(trs, prefix)
 	}
 
-	jsonData, err := json.Marshal(trs)
+	trs, err = filterCEL(ctx, trs, params.Arguments.Filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal resource to JSON: %w", err)
+		return nil, err
 	}
 
-	return result(string(jsonData)), nil
+	page, err := paginate(trs, params.Arguments, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return listResult(page, source, output, params.Arguments.Fields, summarizeTask)
 }
 
-func listTaskRuns() *mcp.ServerTool {
+func listTaskRuns() (*mcp.ServerTool, error) {
+	schema, err := listSchema("TaskRuns", "task")
+	if err != nil {
+		return nil, err
+	}
 	return mcp.NewServerTool(
 		"list_taskruns",
 		"List taskruns in the cluster with filtering options",
 		handlerListTaskRuns,
-	)
+		schema,
+	), nil
 }
 
 func handlerListTaskRuns(
@@ -109,47 +226,55 @@ func handlerListTaskRuns(
 	namespace := params.Arguments.Namespace
 	lselector := params.Arguments.LabelSelector
 	prefix := params.Arguments.Prefix
+	output, err := listOutput(params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
 
 	selector, err := parseLabelSelector(lselector)
 	if err != nil {
 		return nil, err
 	}
 
-	taskRunInformer := taskruninformer.Get(ctx)
-	var trs []*v1.TaskRun
-
-	if namespace == "" {
-		// No namespace, searching all PipelineRuns
-		trs, err = taskRunInformer.Lister().List(selector)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		trs, err = taskRunInformer.Lister().TaskRuns(namespace).List(selector)
-		if err != nil {
-			return nil, err
-		}
+	trs, source, err := scope.TaskRuns(ctx, namespace, selector)
+	if err != nil {
+		return nil, err
 	}
 
 	// Filter after the fact
 	if prefix != "" {
 		trs = filterList(trs, prefix)
 	}
+	filter, err := newRunFilter(params.Arguments, params.Arguments.Task, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	trs = filter.taskRuns(trs)
 
-	jsonData, err := json.Marshal(trs)
+	trs, err = filterCEL(ctx, trs, params.Arguments.Filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal resource to JSON: %w", err)
+		return nil, err
+	}
+
+	page, err := paginate(trs, params.Arguments, taskRunTimes)
+	if err != nil {
+		return nil, err
 	}
 
-	return result(string(jsonData)), nil
+	return listResult(page, source, output, params.Arguments.Fields, summarizeTaskRun)
 }
 
-func listStepactions() *mcp.ServerTool {
+func listStepactions() (*mcp.ServerTool, error) {
+	schema, err := listSchema("StepActions", "")
+	if err != nil {
+		return nil, err
+	}
 	return mcp.NewServerTool(
 		"list_stepactions",
 		"List stepactions in the cluster with filtering options",
 		handlerListStepactions,
-	)
+		schema,
+	), nil
 }
 
 func handlerListStepactions(
@@ -160,26 +285,19 @@ func handlerListStepactions(
 	namespace := params.Arguments.Namespace
 	lselector := params.Arguments.LabelSelector
 	prefix := params.Arguments.Prefix
+	output, err := listOutput(params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
 
 	selector, err := parseLabelSelector(lselector)
 	if err != nil {
 		return nil, err
 	}
 
-	stepactionInformer := stepactioninformer.Get(ctx)
-	var trs []*v1beta1.StepAction
-
-	if namespace == "" {
-		// No namespace, searching all PipelineRuns
-		trs, err = stepactionInformer.Lister().List(selector)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		trs, err = stepactionInformer.Lister().StepActions(namespace).List(selector)
-		if err != nil {
-			return nil, err
-		}
+	trs, source, err := scope.StepActions(ctx, namespace, selector)
+	if err != nil {
+		return nil, err
 	}
 
 	// Filter after the fact
@@ -187,20 +305,30 @@ func handlerListStepactions(
 		trs = filterList(trs, prefix)
 	}
 
-	jsonData, err := json.Marshal(trs)
+	trs, err = filterCEL(ctx, trs, params.Arguments.Filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal resource to JSON: %w", err)
+		return nil, err
 	}
 
-	return result(string(jsonData)), nil
+	page, err := paginate(trs, params.Arguments, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return listResult(page, source, output, params.Arguments.Fields, summarizeStepAction)
 }
 
-func listPipelines() *mcp.ServerTool {
+func listPipelines() (*mcp.ServerTool, error) {
+	schema, err := listSchema("Pipelines", "")
+	if err != nil {
+		return nil, err
+	}
 	return mcp.NewServerTool(
 		"list_pipelines",
 		"List pipelines in the cluster with filtering options",
 		handlerListPipelines,
-	)
+		schema,
+	), nil
 }
 
 func handlerListPipelines(
@@ -211,26 +339,19 @@ func handlerListPipelines(
 	namespace := params.Arguments.Namespace
 	lselector := params.Arguments.LabelSelector
 	prefix := params.Arguments.Prefix
+	output, err := listOutput(params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
 
 	selector, err := parseLabelSelector(lselector)
 	if err != nil {
 		return nil, err
 	}
 
-	pipelineInformer := pipelineinformer.Get(ctx)
-	var prs []*v1.Pipeline
-
-	if namespace == "" {
-		// No namespace, searching all Pipelines
-		prs, err = pipelineInformer.Lister().List(selector)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		prs, err = pipelineInformer.Lister().Pipelines(namespace).List(selector)
-		if err != nil {
-			return nil, err
-		}
+	prs, source, err := scope.Pipelines(ctx, namespace, selector)
+	if err != nil {
+		return nil, err
 	}
 
 	// Filter after the fact
@@ -238,20 +359,30 @@ func handlerListPipelines(
 		prs = filterList(prs, prefix)
 	}
 
-	jsonData, err := json.Marshal(prs)
+	prs, err = filterCEL(ctx, prs, params.Arguments.Filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal resource to JSON: %w", err)
+		return nil, err
+	}
+
+	page, err := paginate(prs, params.Arguments, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return result(string(jsonData)), nil
+	return listResult(page, source, output, params.Arguments.Fields, summarizePipeline)
 }
 
-func listPipelineRuns() *mcp.ServerTool {
+func listPipelineRuns() (*mcp.ServerTool, error) {
+	schema, err := listSchema("PipelineRuns", "pipeline")
+	if err != nil {
+		return nil, err
+	}
 	return mcp.NewServerTool(
 		"list_pipelineruns",
 		"List pipelineruns in the cluster with filtering options",
 		handlerListPipelineRuns,
-	)
+		schema,
+	), nil
 }
 
 func handlerListPipelineRuns(
@@ -262,37 +393,40 @@ func handlerListPipelineRuns(
 	namespace := params.Arguments.Namespace
 	lselector := params.Arguments.LabelSelector
 	prefix := params.Arguments.Prefix
+	output, err := listOutput(params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
 
 	selector, err := parseLabelSelector(lselector)
 	if err != nil {
 		return nil, err
 	}
 
-	pipelineRunInformer := pipelineruninformer.Get(ctx)
-	var prs []*v1.PipelineRun
-
-	if namespace == "" {
-		// No namespace, searching all PipelineRuns
-		prs, err = pipelineRunInformer.Lister().List(selector)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		prs, err = pipelineRunInformer.Lister().PipelineRuns(namespace).List(selector)
-		if err != nil {
-			return nil, err
-		}
+	prs, source, err := scope.PipelineRuns(ctx, namespace, selector)
+	if err != nil {
+		return nil, err
 	}
 
 	// Filter after the fact
 	if prefix != "" {
 		prs = filterList(prs, prefix)
 	}
+	filter, err := newRunFilter(params.Arguments, params.Arguments.Pipeline, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	prs = filter.pipelineRuns(prs)
 
-	jsonData, err := json.Marshal(prs)
+	prs, err = filterCEL(ctx, prs, params.Arguments.Filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal resource to JSON: %w", err)
+		return nil, err
+	}
+
+	page, err := paginate(prs, params.Arguments, pipelineRunTimes)
+	if err != nil {
+		return nil, err
 	}
 
-	return result(string(jsonData)), nil
+	return listResult(page, source, output, params.Arguments.Fields, summarizePipelineRun)
 }
@@ -2,14 +2,20 @@ package tools
 
 import (
 	"encoding/json"
+	"fmt"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	v1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
 	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -77,6 +83,11 @@ func TestList(t *testing.T) {
 			arguments: map[string]string{"prefix": "foo"},
 			expected:  []string{"foo"},
 		},
+		{
+			name:      "Full output",
+			arguments: map[string]string{"namespace": "ns2", "output": "full"},
+			expected:  []string{"bar"},
+		},
 	}
 
 	for _, tool := range tools {
@@ -97,8 +108,12 @@ func TestList(t *testing.T) {
 						t.Fatalf("failed to unmarshal objects: %v", err)
 					}
 					for _, object := range objects {
-						metadata := object["metadata"].(map[string]any)
-						if !slices.Contains(test.expected, metadata["name"].(string)) {
+						name, _ := object["name"].(string)
+						if test.arguments["output"] == "full" {
+							metadata := object["metadata"].(map[string]any)
+							name = metadata["name"].(string)
+						}
+						if !slices.Contains(test.expected, name) {
 							t.Fatalf("response contained unexpected result: %v", object)
 						}
 					}
@@ -107,3 +122,562 @@ func TestList(t *testing.T) {
 		})
 	}
 }
+
+func TestListSummary(t *testing.T) {
+	start := metav1.NewTime(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC))
+	completion := metav1.NewTime(start.Add(3 * time.Minute))
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+				Spec:       v1.PipelineRunSpec{PipelineRef: &v1.PipelineRef{Name: "release"}},
+				Status: v1.PipelineRunStatus{
+					Status: succeededCondition(corev1.ConditionFalse, "Failed"),
+					PipelineRunStatusFields: v1.PipelineRunStatusFields{
+						StartTime:      &start,
+						CompletionTime: &completion,
+						ChildReferences: []v1.ChildStatusReference{
+							{Name: "release-build", PipelineTaskName: "build"},
+							{Name: "release-test", PipelineTaskName: "test"},
+						},
+					},
+				},
+			},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "release-build",
+					Namespace: "default",
+					Labels:    map[string]string{"tekton.dev/pipelineRun": "release"},
+				},
+				Spec: v1.TaskRunSpec{TaskRef: &v1.TaskRef{ResolverRef: v1.ResolverRef{
+					Resolver: "bundles",
+					Params: v1.Params{
+						{Name: "bundle", Value: *v1.NewStructuredValues("registry/build:v1")},
+						{Name: "name", Value: *v1.NewStructuredValues("build")},
+					},
+				}}},
+				Status: v1.TaskRunStatus{
+					TaskRunStatusFields: v1.TaskRunStatusFields{
+						Steps: []v1.StepState{{Name: "compile"}, {Name: "push"}},
+					},
+				},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		tool     string
+		expected string
+	}{
+		{
+			tool:     "list_pipelineruns",
+			expected: `[{"name":"release","namespace":"default","pipeline":"release","status":"False","reason":"Failed","startTime":"2025-06-01T10:00:00Z","duration":"3m0s","taskRuns":2}]`,
+		},
+		{
+			tool:     "list_taskruns",
+			expected: `[{"name":"release-build","namespace":"default","task":"bundles:build","pipelineRun":"release","status":"Unknown","reason":"Pending","steps":2}]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.tool, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      test.tool,
+				Arguments: map[string]any{"namespace": "default"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := response.Content[0].(*mcp.TextContent)
+			if diff := cmp.Diff(test.expected, content.Text); diff != "" {
+				t.Errorf("summary mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name: "list_pipelineruns",
+			Arguments: map[string]any{"fields": []string{
+				".status.childReferences[*].pipelineTaskName",
+				".status.conditions[0].reason",
+				".status.results",
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `[{".status.childReferences[*].pipelineTaskName":["build","test"],` +
+			`".status.conditions[0].reason":"Failed",".status.results":null,"name":"release","namespace":"default"}]`
+		if diff := cmp.Diff(expected, response.Content[0].(*mcp.TextContent).Text); diff != "" {
+			t.Errorf("fields mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "list_tasks",
+			Arguments: map[string]any{"output": "wide"},
+		})
+		if err == nil {
+			t.Fatal("expected an error for an invalid output")
+		}
+	})
+}
+
+func TestListPagination(t *testing.T) {
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	pipelineRun := func(name string, created, started int) *v1.PipelineRun {
+		pr := &v1.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(base.Add(time.Duration(created) * time.Minute)),
+		}}
+		if started >= 0 {
+			start := metav1.NewTime(base.Add(time.Duration(started) * time.Minute))
+			pr.Status.StartTime = &start
+		}
+		return pr
+	}
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			pipelineRun("release-1", 0, 1),
+			pipelineRun("release-2", 1, 5),
+			pipelineRun("release-3", 2, 3),
+			pipelineRun("release-4", 3, -1),
+			pipelineRun("release-5", 4, 4),
+		},
+		Tasks: []*v1.Task{
+			{ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default"}},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	list := func(t *testing.T, tool string, arguments map[string]any) ([]string, string) {
+		t.Helper()
+		response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: arguments})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var objects []struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &objects); err != nil {
+			t.Fatalf("failed to unmarshal objects: %v", err)
+		}
+		names := make([]string, 0, len(objects))
+		for _, o := range objects {
+			names = append(names, o.Name)
+		}
+		var next string
+		if len(response.Content) > 1 {
+			next = response.Content[1].(*mcp.TextContent).Text
+		}
+		return names, next
+	}
+
+	t.Run("default sort", func(t *testing.T) {
+		names, next := list(t, "list_pipelineruns", map[string]any{"limit": 2})
+		if diff := cmp.Diff([]string{"release-5", "release-4"}, names); diff != "" {
+			t.Errorf("names mismatch (-want +got):\n%s", diff)
+		}
+		if !strings.HasPrefix(next, "3 more objects, use continue ") {
+			t.Errorf("invalid continue message: %q", next)
+		}
+	})
+
+	t.Run("pages by start time", func(t *testing.T) {
+		var all []string
+		arguments := map[string]any{"limit": 2, "sortBy": "startTime"}
+		for page := 0; page < 5; page++ {
+			names, next := list(t, "list_pipelineruns", arguments)
+			all = append(all, names...)
+			if next == "" {
+				break
+			}
+			token := strings.Split(next, `"`)[1]
+			arguments = map[string]any{"limit": 2, "sortBy": "startTime", "continue": token}
+		}
+		// Runs that did not start yet are the most recent ones
+		if diff := cmp.Diff([]string{"release-4", "release-2", "release-5", "release-3", "release-1"}, all); diff != "" {
+			t.Errorf("names mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		names, next := list(t, "list_pipelineruns", map[string]any{"sortBy": "name", "limit": 3})
+		if diff := cmp.Diff([]string{"release-1", "release-2", "release-3"}, names); diff != "" {
+			t.Errorf("names mismatch (-want +got):\n%s", diff)
+		}
+		token := strings.Split(next, `"`)[1]
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "list_pipelineruns",
+			Arguments: map[string]any{"sortBy": "name", "order": "desc", "continue": token},
+		})
+		if err == nil || !strings.Contains(err.Error(), "the continue token was returned for sortBy name and order asc") {
+			t.Errorf("expected a continue token mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("start time of a task", func(t *testing.T) {
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "list_tasks",
+			Arguments: map[string]any{"sortBy": "startTime"},
+		})
+		if err == nil || !strings.Contains(err.Error(), "sortBy startTime is only supported for runs") {
+			t.Errorf("expected an unsupported sort error, got %v", err)
+		}
+	})
+}
+
+func TestListScope(t *testing.T) {
+	data := test.Data{
+		Tasks: []*v1.Task{
+			{ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ci", Labels: map[string]string{"team": "ci"}}},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	// Objects not matching the label selector of the informers are only in
+	// the API server
+	if _, err := clients.Pipeline.TektonV1().Tasks("ci").Create(ctx, &v1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy", Namespace: "ci"},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx = scope.WithScope(ctx, scope.Scope{LabelSelector: "team=ci"})
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name          string
+		labelSelector string
+		expected      []string
+		source        string
+	}{
+		{
+			name:          "cached",
+			labelSelector: "team=ci",
+			expected:      []string{"build"},
+			source:        `Listed from the informer cache of the server, which holds the Tekton objects of all namespaces matching the label selector "team=ci"`,
+		},
+		{
+			name:     "live",
+			expected: []string{"build", "deploy"},
+			source:   `Listed from the API server, as the informer cache of the server only holds the Tekton objects of all namespaces matching the label selector "team=ci"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      "list_tasks",
+				Arguments: map[string]any{"namespace": "ci", "labelSelector": test.labelSelector, "sortBy": "name"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var objects []struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &objects); err != nil {
+				t.Fatalf("failed to unmarshal objects: %v", err)
+			}
+			var names []string
+			for _, o := range objects {
+				names = append(names, o.Name)
+			}
+			if diff := cmp.Diff(test.expected, names); diff != "" {
+				t.Errorf("names mismatch (-want +got):\n%s", diff)
+			}
+			source := response.Content[len(response.Content)-1].(*mcp.TextContent).Text
+			if diff := cmp.Diff(test.source, source); diff != "" {
+				t.Errorf("source mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListRunFilters(t *testing.T) {
+	now := time.Now()
+	pipelineRun := func(name string, age time.Duration, ref *v1.PipelineRef, status corev1.ConditionStatus, reason string) *v1.PipelineRun {
+		pr := &v1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: v1.PipelineRunSpec{PipelineRef: ref},
+		}
+		if status != "" {
+			pr.Status.Status = succeededCondition(status, reason)
+		}
+		if status != "" && status != corev1.ConditionUnknown {
+			completion := metav1.NewTime(now.Add(-age + 10*time.Minute))
+			pr.Status.CompletionTime = &completion
+		}
+		return pr
+	}
+	build := &v1.PipelineRef{Name: "build"}
+	release := &v1.PipelineRef{ResolverRef: v1.ResolverRef{
+		Resolver: "hub",
+		Params:   v1.Params{{Name: "name", Value: *v1.NewStructuredValues("release")}},
+	}}
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			pipelineRun("succeeded", 5*time.Hour, build, corev1.ConditionTrue, "Succeeded"),
+			pipelineRun("failed", 3*time.Hour, build, corev1.ConditionFalse, "Failed"),
+			pipelineRun("cancelled", 90*time.Minute, release, corev1.ConditionFalse, "Cancelled"),
+			pipelineRun("timedout", time.Hour, release, corev1.ConditionFalse, "PipelineRunTimeout"),
+			pipelineRun("running", 30*time.Minute, build, corev1.ConditionUnknown, "Running"),
+			pipelineRun("pending", time.Minute, release, "", ""),
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "unit-tests", Namespace: "default"},
+				Spec:       v1.TaskRunSpec{TaskRef: &v1.TaskRef{Name: "go-test"}},
+				Status:     v1.TaskRunStatus{Status: succeededCondition(corev1.ConditionFalse, "TaskRunTimeout")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "lint", Namespace: "default"},
+				Spec:       v1.TaskRunSpec{TaskRef: &v1.TaskRef{Name: "golangci-lint"}},
+				Status:     v1.TaskRunStatus{Status: succeededCondition(corev1.ConditionFalse, "Failed")},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		expected  []string
+	}{{
+		name:      "failed status",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"status": "failed"},
+		expected:  []string{"failed"},
+	}, {
+		name:      "several statuses",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"status": "cancelled, Timed Out"},
+		expected:  []string{"timedout", "cancelled"},
+	}, {
+		name:      "running and pending",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"status": "running,pending"},
+		expected:  []string{"pending", "running"},
+	}, {
+		name:      "pipeline name",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"pipeline": "build"},
+		expected:  []string{"running", "failed", "succeeded"},
+	}, {
+		name:      "remote pipeline name",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"pipeline": "release", "status": "cancelled"},
+		expected:  []string{"cancelled"},
+	}, {
+		name:      "created since",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"since": "2h"},
+		expected:  []string{"pending", "running", "timedout", "cancelled"},
+	}, {
+		name:      "created window",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"since": "4h", "until": "45m"},
+		expected:  []string{"timedout", "cancelled", "failed"},
+	}, {
+		name:      "completed since",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"since": "1h", "timeField": "completionTime"},
+		expected:  []string{"timedout"},
+	}, {
+		name:      "completed since a timestamp",
+		tool:      "list_pipelineruns",
+		arguments: map[string]any{"since": now.Add(-3 * time.Hour).Format(time.RFC3339), "timeField": "completionTime"},
+		expected:  []string{"timedout", "cancelled", "failed"},
+	}, {
+		name:      "taskruns by task and status",
+		tool:      "list_taskruns",
+		arguments: map[string]any{"task": "go-test", "status": "timedout"},
+		expected:  []string{"unit-tests"},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: tc.tool, Arguments: tc.arguments})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var objects []struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &objects); err != nil {
+				t.Fatalf("failed to unmarshal objects: %v", err)
+			}
+			names := make([]string, 0, len(objects))
+			for _, o := range objects {
+				names = append(names, o.Name)
+			}
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("names mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, arguments := range []map[string]any{
+		{"status": "done"},
+		{"since": "yesterday"},
+		{"timeField": "startTime"},
+	} {
+		if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "list_pipelineruns", Arguments: arguments}); err == nil {
+			t.Errorf("expected an error for %v", arguments)
+		}
+	}
+}
+
+func TestListCELFilter(t *testing.T) {
+	pipelineRun := func(name, env string, status corev1.ConditionStatus, reason string) *v1.PipelineRun {
+		pr := &v1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1.PipelineRunSpec{
+				PipelineRef: &v1.PipelineRef{Name: "deploy"},
+				Params:      v1.Params{{Name: "env", Value: *v1.NewStructuredValues(env)}},
+			},
+		}
+		if status != "" {
+			pr.Status.Status = succeededCondition(status, reason)
+		}
+		return pr
+	}
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			pipelineRun("prod-failed", "prod", corev1.ConditionFalse, "Failed"),
+			pipelineRun("prod-succeeded", "prod", corev1.ConditionTrue, "Succeeded"),
+			pipelineRun("dev-failed", "dev", corev1.ConditionFalse, "Failed"),
+			pipelineRun("prod-pending", "prod", "", ""),
+		},
+		Tasks: []*v1.Task{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default", Labels: map[string]string{"team": "ci"}},
+				Spec:       v1.TaskSpec{Steps: []v1.Step{{Name: "compile"}, {Name: "package"}}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "lint", Namespace: "default"},
+				Spec:       v1.TaskSpec{Steps: []v1.Step{{Name: "lint"}}},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	tests := []struct {
+		name     string
+		tool     string
+		filter   string
+		expected []string
+	}{{
+		name:     "params and status",
+		tool:     "list_pipelineruns",
+		filter:   "status.conditions[0].reason == 'Failed' && spec.params.exists(p, p.name == 'env' && p.value == 'prod')",
+		expected: []string{"prod-failed"},
+	}, {
+		name:     "missing status is filtered out",
+		tool:     "list_pipelineruns",
+		filter:   "status.conditions[0].status != 'True'",
+		expected: []string{"dev-failed", "prod-failed"},
+	}, {
+		name:     "optional status",
+		tool:     "list_pipelineruns",
+		filter:   "!has(status.conditions)",
+		expected: []string{"prod-pending"},
+	}, {
+		name:     "metadata",
+		tool:     "list_tasks",
+		filter:   "has(metadata.labels) && metadata.labels.team == 'ci'",
+		expected: []string{"build"},
+	}, {
+		name:     "spec size",
+		tool:     "list_tasks",
+		filter:   "size(spec.steps) == 1",
+		expected: []string{"lint"},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      tc.tool,
+				Arguments: map[string]any{"filter": tc.filter, "sortBy": "name"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var objects []struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &objects); err != nil {
+				t.Fatalf("failed to unmarshal objects: %v", err)
+			}
+			names := make([]string, 0, len(objects))
+			for _, o := range objects {
+				names = append(names, o.Name)
+			}
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("names mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("cost limit", func(t *testing.T) {
+		// 10^6 iterations on each Task
+		filter := "true"
+		for _, v := range []string{"a", "b", "c", "d", "e", "f"} {
+			filter = fmt.Sprintf("[1, 2, 3, 4, 5, 6, 7, 8, 9, 10].all(%s, %s)", v, filter)
+		}
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "list_tasks",
+			Arguments: map[string]any{"filter": filter},
+		})
+		if err == nil || !strings.Contains(err.Error(), "filter exceeded the cost limit of 1000000 on default/") {
+			t.Errorf("expected a cost limit error, got %v", err)
+		}
+	})
+
+	for _, filter := range []string{"status.conditions[0].reason ==", "metadata.name"} {
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "list_pipelineruns",
+			Arguments: map[string]any{"filter": filter},
+		})
+		if err == nil || !strings.Contains(err.Error(), "invalid filter") {
+			t.Errorf("expected an invalid filter error for %q, got %v", filter, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	sortByCreationTimestamp = "creationTimestamp"
+	sortByStartTime         = "startTime"
+	sortByCompletionTime    = "completionTime"
+	sortByName              = "name"
+
+	orderAsc  = "asc"
+	orderDesc = "desc"
+)
+
+// runTimes returns the start and completion times of a run.
+type runTimes[T any] func(T) (start, completion *metav1.Time)
+
+// listKey is the position of an object in a sorted list. Times are stored as
+// Unix nanoseconds, runs that did not start or complete yet being the most
+// recent ones.
+type listKey struct {
+	Time      int64  `json:"time,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+func compareListKeys(a, b listKey) int {
+	return cmp.Or(
+		cmp.Compare(a.Time, b.Time),
+		cmp.Compare(a.Name, b.Name),
+		cmp.Compare(a.Namespace, b.Namespace),
+	)
+}
+
+// listCursor is the content of a continue token: the sort of the list and the
+// key of the last returned object. The next page starts after this key, so
+// that objects created or deleted in between do not shift the pages.
+type listCursor struct {
+	SortBy string  `json:"sortBy"`
+	Order  string  `json:"order"`
+	After  listKey `json:"after"`
+}
+
+func (c listCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(token string) (listCursor, error) {
+	var c listCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, errors.New("invalid continue token")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, errors.New("invalid continue token")
+	}
+	return c, nil
+}
+
+// listPage is a page of a sorted list, with the continue token of the next
+// page if any.
+type listPage[T any] struct {
+	items     []T
+	remaining int
+	next      string
+}
+
+// paginationDescriptions documents the pagination and sort params of a list
+// tool. The start and completion times are only available for runs.
+func paginationDescriptions(scheme *jsonschema.Schema, runs bool) {
+	sortBy := []any{sortByCreationTimestamp, sortByName}
+	if runs {
+		sortBy = []any{sortByCreationTimestamp, sortByStartTime, sortByCompletionTime, sortByName}
+	}
+	scheme.Properties["limit"].Description = "Maximum number of objects to return, all if 0"
+	scheme.Properties["continue"].Description = "Continue token returned with the previous page"
+	scheme.Properties["sortBy"].Description = fmt.Sprintf("Field to sort the objects by: %v", sortBy)
+	scheme.Properties["sortBy"].Enum = sortBy
+	scheme.Properties["sortBy"].Default = json.RawMessage(`"creationTimestamp"`)
+	scheme.Properties["order"].Description = "Sort order, asc or desc (desc for times and asc for names by default)"
+	scheme.Properties["order"].Enum = []any{orderAsc, orderDesc}
+}
+
+// paginate sorts the listed objects and returns the requested page. times is
+// nil for the kinds that are not runs.
+func paginate[T metav1.Object](items []T, args listParams, times runTimes[T]) (listPage[T], error) {
+	sortBy := args.SortBy
+	if sortBy == "" {
+		sortBy = sortByCreationTimestamp
+	}
+	order := args.Order
+	switch {
+	case order != "":
+	case sortBy == sortByName:
+		order = orderAsc
+	default:
+		order = orderDesc
+	}
+
+	var key func(T) listKey
+	switch sortBy {
+	case sortByCreationTimestamp:
+		key = func(item T) listKey {
+			created := item.GetCreationTimestamp()
+			return listKey{Time: timeKey(&created), Name: item.GetName(), Namespace: item.GetNamespace()}
+		}
+	case sortByStartTime, sortByCompletionTime:
+		if times == nil {
+			return listPage[T]{}, fmt.Errorf("sortBy %s is only supported for runs", sortBy)
+		}
+		key = func(item T) listKey {
+			start, completion := times(item)
+			t := start
+			if sortBy == sortByCompletionTime {
+				t = completion
+			}
+			return listKey{Time: timeKey(t), Name: item.GetName(), Namespace: item.GetNamespace()}
+		}
+	case sortByName:
+		key = func(item T) listKey {
+			return listKey{Name: item.GetName(), Namespace: item.GetNamespace()}
+		}
+	default:
+		return listPage[T]{}, fmt.Errorf("invalid sortBy %q", sortBy)
+	}
+
+	direction := 1
+	switch order {
+	case orderAsc:
+	case orderDesc:
+		direction = -1
+	default:
+		return listPage[T]{}, fmt.Errorf("invalid order %q, expected %s or %s", order, orderAsc, orderDesc)
+	}
+	if args.Limit < 0 {
+		return listPage[T]{}, fmt.Errorf("invalid limit %d", args.Limit)
+	}
+
+	sorted := slices.Clone(items)
+	slices.SortFunc(sorted, func(a, b T) int {
+		return direction * compareListKeys(key(a), key(b))
+	})
+
+	if args.Continue != "" {
+		cursor, err := decodeListCursor(args.Continue)
+		if err != nil {
+			return listPage[T]{}, err
+		}
+		if cursor.SortBy != sortBy || cursor.Order != order {
+			return listPage[T]{}, fmt.Errorf("the continue token was returned for sortBy %s and order %s", cursor.SortBy, cursor.Order)
+		}
+		start, _ := slices.BinarySearchFunc(sorted, cursor.After, func(item T, after listKey) int {
+			if direction*compareListKeys(key(item), after) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		sorted = sorted[start:]
+	}
+
+	if args.Limit == 0 || len(sorted) <= args.Limit {
+		return listPage[T]{items: sorted}, nil
+	}
+	page := sorted[:args.Limit]
+	cursor := listCursor{SortBy: sortBy, Order: order, After: key(page[len(page)-1])}
+	return listPage[T]{items: page, remaining: len(sorted) - args.Limit, next: cursor.encode()}, nil
+}
+
+func timeKey(t *metav1.Time) int64 {
+	if t == nil || t.IsZero() {
+		return math.MaxInt64
+	}
+	return t.UnixNano()
+}
@@ -3,60 +3,94 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
-	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/pipelinerun"
-	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/taskrun"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
-type restartParams struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
+type restartPipelineRunParams struct {
+	Name               string            `json:"name"`
+	Namespace          string            `json:"namespace"`
+	Params             map[string]any    `json:"params"`
+	ServiceAccountName string            `json:"serviceAccountName"`
+	Timeout            string            `json:"timeout"`
+	TasksTimeout       string            `json:"tasksTimeout"`
+	FinallyTimeout     string            `json:"finallyTimeout"`
+	PipelineRef        string            `json:"pipelineRef"`
+	Version            string            `json:"version"`
+	Labels             map[string]string `json:"labels"`
+	DryRun             bool              `json:"dryRun"`
 }
 
-func restartSchema() (mcp.ToolOption, error) {
-	scheme, err := jsonschema.For[restartParams]()
-	if err != nil {
-		return nil, err
-	}
+type restartTaskRunParams struct {
+	Name               string            `json:"name"`
+	Namespace          string            `json:"namespace"`
+	Params             map[string]any    `json:"params"`
+	ServiceAccountName string            `json:"serviceAccountName"`
+	Timeout            string            `json:"timeout"`
+	TaskRef            string            `json:"taskRef"`
+	Version            string            `json:"version"`
+	Labels             map[string]string `json:"labels"`
+	DryRun             bool              `json:"dryRun"`
+}
 
+// restartOverridesDescriptions documents the overrides shared by the restart
+// tools.
+func restartOverridesDescriptions(scheme *jsonschema.Schema) {
 	scheme.Properties["name"].Description = "Name or referece of the object"
 	scheme.Properties["namespace"].Description = "Namespace of the object"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
-
-	return mcp.Input(mcp.Schema(scheme)), nil
+	scheme.Properties["params"].Description = "Param values to change or add, by name. The other params keep the values of the restarted run"
+	scheme.Properties["serviceAccountName"].Description = "Service account used to run the pods, instead of the one of the restarted run"
+	scheme.Properties["timeout"].Description = "Timeout of the new run, as a duration (e.g. 1h30m)"
+	scheme.Properties["version"].Description = "Version of the remote definition to run: the version of a hub reference, the tag of a bundle or the revision of a git reference"
+	scheme.Properties["labels"].Description = "Labels added to the new run, on top of the labels of the original run"
+	scheme.Properties["dryRun"].Description = dryRunDescription
+	scheme.Required = []string{"name"}
 }
 
 func restartPipelineRun() (*mcp.ServerTool, error) {
-	schema, err := restartSchema()
+	scheme, err := jsonschema.For[restartPipelineRunParams]()
 	if err != nil {
 		return nil, err
 	}
+	restartOverridesDescriptions(scheme)
+	scheme.Properties["tasksTimeout"].Description = "Timeout of the pipeline tasks, as a duration"
+	scheme.Properties["finallyTimeout"].Description = "Timeout of the finally tasks, as a duration"
+	scheme.Properties["pipelineRef"].Description = "Name of a Pipeline to run instead of the one of the restarted run"
+
 	return mcp.NewServerTool(
 		"restart_pipelinerun",
-		"Restart a PipelineRun",
+		"Restart a PipelineRun, optionally overriding some of its params and options, and return the changes made to its spec",
 		handlerRestartPipelineRun,
-		schema,
+		mcp.Input(mcp.Schema(scheme)),
 	), nil
 }
 
 func handlerRestartPipelineRun(
 	ctx context.Context,
 	cc *mcp.ServerSession,
-	params *mcp.CallToolParamsFor[restartParams],
+	params *mcp.CallToolParamsFor[restartPipelineRunParams],
 ) (*mcp.CallToolResultFor[string], error) {
 	name := params.Arguments.Name
 	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
 
-	pipelinerunInformer := pipelineruninformer.Get(ctx)
 	pipelineclientset := pipelineclient.Get(ctx)
 
-	usepr, err := pipelinerunInformer.Lister().PipelineRuns(namespace).Get(name)
+	usepr, err := scope.PipelineRun(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
 	}
@@ -68,47 +102,122 @@ func handlerRestartPipelineRun(
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    namespace,
 			GenerateName: name + "-",
+			Labels:       restartLabels(usepr.Labels, params.Arguments.Labels),
 		},
-		Spec: usepr.Spec,
+		Spec: *usepr.Spec.DeepCopy(),
 	}
 	pr.Spec.Status = ""
 	if len(usepr.ObjectMeta.GenerateName) > 0 {
 		pr.ObjectMeta.GenerateName = usepr.ObjectMeta.GenerateName
 	}
+	if err := overridePipelineRun(ctx, usepr, pr, params.Arguments); err != nil {
+		return nil, fmt.Errorf("invalid overrides to restart PipelineRun %s/%s: %w", namespace, name, err)
+	}
 
-	pr, err = pipelineclientset.TektonV1().PipelineRuns(namespace).Create(ctx, pr, metav1.CreateOptions{})
+	pr, err = pipelineclientset.TektonV1().PipelineRuns(namespace).Create(ctx, pr, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create PipelineRun %s/%s: %w", namespace, pr.ObjectMeta.Name, err)
 	}
 
-	return result(fmt.Sprintf("Restarting pipelinerun %s as %s in namespace %s", name, pr.ObjectMeta.Name, namespace)), nil
+	changes, err := restartChanges(usepr.Labels, pr.Labels, usepr.Spec, pr.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("PipelineRun %s would be restarted as %s in namespace %s%s", name, pr.ObjectMeta.Name, namespace, changes), pr)
+	}
+	return result(fmt.Sprintf("Restarting pipelinerun %s as %s in namespace %s%s", name, pr.ObjectMeta.Name, namespace, changes)), nil
+}
+
+// overridePipelineRun applies the restart overrides to the spec of a new
+// PipelineRun. The param values are checked against the pipeline spec of the
+// restarted run, unless another pipeline is referenced.
+func overridePipelineRun(ctx context.Context, usepr, pr *v1.PipelineRun, args restartPipelineRunParams) error {
+	var errs []error
+
+	if args.PipelineRef != "" {
+		pr.Spec.PipelineRef = &v1.PipelineRef{Name: args.PipelineRef}
+		pr.Spec.PipelineSpec = nil
+	}
+	if args.Version != "" {
+		if pr.Spec.PipelineRef == nil {
+			errs = append(errs, errors.New("version cannot be set on a PipelineRun with an embedded pipeline spec"))
+		} else {
+			errs = append(errs, setResolverVersion(&pr.Spec.PipelineRef.ResolverRef, args.Version))
+		}
+	}
+
+	var specs v1.ParamSpecs
+	if args.PipelineRef == "" && args.Version == "" {
+		if spec, err := pipelineSpecFor(ctx, usepr); err == nil {
+			specs = spec.Params
+		}
+	}
+	runParams, err := overrideParams(pr.Spec.Params, specs, args.Params)
+	errs = append(errs, err)
+	pr.Spec.Params = runParams
+
+	if args.ServiceAccountName != "" {
+		pr.Spec.TaskRunTemplate.ServiceAccountName = args.ServiceAccountName
+	}
+
+	timeouts := &v1.TimeoutFields{}
+	if pr.Spec.Timeouts != nil {
+		timeouts = pr.Spec.Timeouts
+	}
+	for _, timeout := range []struct {
+		field string
+		value string
+		dest  **metav1.Duration
+	}{
+		{"timeout", args.Timeout, &timeouts.Pipeline},
+		{"tasksTimeout", args.TasksTimeout, &timeouts.Tasks},
+		{"finallyTimeout", args.FinallyTimeout, &timeouts.Finally},
+	} {
+		d, err := parseDuration(timeout.field, timeout.value)
+		if err != nil {
+			errs = append(errs, err)
+		} else if d != nil {
+			*timeout.dest = d
+		}
+	}
+	if timeouts.Pipeline != nil || timeouts.Tasks != nil || timeouts.Finally != nil {
+		pr.Spec.Timeouts = timeouts
+	}
+
+	return errors.Join(errs...)
 }
 
 func restartTaskRun() (*mcp.ServerTool, error) {
-	schema, err := restartSchema()
+	scheme, err := jsonschema.For[restartTaskRunParams]()
 	if err != nil {
 		return nil, err
 	}
+	restartOverridesDescriptions(scheme)
+	scheme.Properties["taskRef"].Description = "Name of a Task to run instead of the one of the restarted run"
+
 	return mcp.NewServerTool(
 		"restart_taskrun",
-		"Restart a TaskRun",
+		"Restart a TaskRun, optionally overriding some of its params and options, and return the changes made to its spec",
 		handlerRestartTaskRun,
-		schema,
+		mcp.Input(mcp.Schema(scheme)),
 	), nil
 }
 
 func handlerRestartTaskRun(
 	ctx context.Context,
 	cc *mcp.ServerSession,
-	params *mcp.CallToolParamsFor[restartParams],
+	params *mcp.CallToolParamsFor[restartTaskRunParams],
 ) (*mcp.CallToolResultFor[string], error) {
 	name := params.Arguments.Name
 	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
 
-	taskrunInformer := taskruninformer.Get(ctx)
 	pipelineclientset := pipelineclient.Get(ctx)
 
-	usetr, err := taskrunInformer.Lister().TaskRuns(namespace).Get(name)
+	usetr, err := scope.TaskRun(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, name, err)
 	}
@@ -121,18 +230,215 @@ func handlerRestartTaskRun(
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    namespace,
 			GenerateName: name + "-",
+			Labels:       restartLabels(usetr.Labels, params.Arguments.Labels),
 		},
-		Spec: usetr.Spec,
+		Spec: *usetr.Spec.DeepCopy(),
 	}
 	tr.Spec.Status = ""
 	if len(usetr.ObjectMeta.GenerateName) > 0 {
 		tr.ObjectMeta.GenerateName = usetr.ObjectMeta.GenerateName
 	}
+	if err := overrideTaskRun(ctx, usetr, tr, params.Arguments); err != nil {
+		return nil, fmt.Errorf("invalid overrides to restart TaskRun %s/%s: %w", namespace, name, err)
+	}
 
-	tr, err = pipelineclientset.TektonV1().TaskRuns(namespace).Create(ctx, tr, metav1.CreateOptions{})
+	tr, err = pipelineclientset.TektonV1().TaskRuns(namespace).Create(ctx, tr, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TaskRun %s/%s: %w", namespace, tr.ObjectMeta.Name, err)
 	}
 
-	return result(fmt.Sprintf("Restarting taskrun %s as %s in namespace %s", name, tr.ObjectMeta.Name, namespace)), nil
+	changes, err := restartChanges(usetr.Labels, tr.Labels, usetr.Spec, tr.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("TaskRun %s would be restarted as %s in namespace %s%s", name, tr.ObjectMeta.Name, namespace, changes), tr)
+	}
+	return result(fmt.Sprintf("Restarting taskrun %s as %s in namespace %s%s", name, tr.ObjectMeta.Name, namespace, changes)), nil
+}
+
+// overrideTaskRun applies the restart overrides to the spec of a new TaskRun.
+// The param values are checked against the task spec of the restarted run,
+// unless another task is referenced.
+func overrideTaskRun(ctx context.Context, usetr, tr *v1.TaskRun, args restartTaskRunParams) error {
+	var errs []error
+
+	if args.TaskRef != "" {
+		tr.Spec.TaskRef = &v1.TaskRef{Name: args.TaskRef}
+		tr.Spec.TaskSpec = nil
+	}
+	if args.Version != "" {
+		if tr.Spec.TaskRef == nil {
+			errs = append(errs, errors.New("version cannot be set on a TaskRun with an embedded task spec"))
+		} else {
+			errs = append(errs, setResolverVersion(&tr.Spec.TaskRef.ResolverRef, args.Version))
+		}
+	}
+
+	var specs v1.ParamSpecs
+	if args.TaskRef == "" && args.Version == "" {
+		if spec, err := taskSpecFor(ctx, usetr); err == nil {
+			specs = spec.Params
+		}
+	}
+	runParams, err := overrideParams(tr.Spec.Params, specs, args.Params)
+	errs = append(errs, err)
+	tr.Spec.Params = runParams
+
+	if args.ServiceAccountName != "" {
+		tr.Spec.ServiceAccountName = args.ServiceAccountName
+	}
+	timeout, err := parseDuration("timeout", args.Timeout)
+	if err != nil {
+		errs = append(errs, err)
+	} else if timeout != nil {
+		tr.Spec.Timeout = timeout
+	}
+
+	return errors.Join(errs...)
+}
+
+// taskSpecFor returns the spec a TaskRun was (or will be) executed with.
+func taskSpecFor(ctx context.Context, tr *v1.TaskRun) (*v1.TaskSpec, error) {
+	if tr.Status.TaskSpec != nil {
+		return tr.Status.TaskSpec, nil
+	}
+	if tr.Spec.TaskSpec != nil {
+		return tr.Spec.TaskSpec, nil
+	}
+	if tr.Spec.TaskRef == nil || tr.Spec.TaskRef.Name == "" {
+		return nil, fmt.Errorf("TaskRun %s/%s has no resolved task spec", tr.Namespace, tr.Name)
+	}
+
+	task, err := scope.Task(ctx, tr.Namespace, tr.Spec.TaskRef.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Task %s/%s: %w", tr.Namespace, tr.Spec.TaskRef.Name, err)
+	}
+	return &task.Spec, nil
+}
+
+// overrideParams changes or adds the given values to the params of a run.
+// When the declared params are known, unknown params are rejected and the
+// values must match the declared types.
+func overrideParams(params v1.Params, specs v1.ParamSpecs, values map[string]any) (v1.Params, error) {
+	var errs []error
+	params = slices.Clone(params)
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		value := convertToParamValue(values[name])
+		if specs != nil {
+			i := slices.IndexFunc(specs, func(spec v1.ParamSpec) bool { return spec.Name == name })
+			if i < 0 {
+				errs = append(errs, fmt.Errorf("unknown param %q", name))
+				continue
+			}
+			if err := validateParamValue(specs[i], value); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if i := slices.IndexFunc(params, func(p v1.Param) bool { return p.Name == name }); i >= 0 {
+			params[i].Value = value
+		} else {
+			params = append(params, v1.Param{Name: name, Value: value})
+		}
+	}
+	return params, errors.Join(errs...)
+}
+
+// resolverVersionParams are the resolver params holding the version of the
+// resolved definition.
+var resolverVersionParams = map[v1.ResolverName]string{
+	"hub":     "version",
+	"git":     "revision",
+	"bundles": "bundle",
+}
+
+// setResolverVersion changes the version of a remote reference. For bundles,
+// the tag or digest of the bundle image is replaced.
+func setResolverVersion(ref *v1.ResolverRef, version string) error {
+	param, ok := resolverVersionParams[ref.Resolver]
+	if !ok {
+		if ref.Resolver == "" {
+			return errors.New("version can only be set on a remote reference, use the name of another definition instead")
+		}
+		return fmt.Errorf("version cannot be set on a reference resolved by the %s resolver", ref.Resolver)
+	}
+
+	i := slices.IndexFunc(ref.Params, func(p v1.Param) bool { return p.Name == param })
+	if ref.Resolver != "bundles" {
+		value := *v1.NewStructuredValues(version)
+		if i < 0 {
+			ref.Params = append(ref.Params, v1.Param{Name: param, Value: value})
+		} else {
+			ref.Params[i].Value = value
+		}
+		return nil
+	}
+
+	if i < 0 {
+		return errors.New("the bundle reference has no bundle param")
+	}
+	image := ref.Params[i].Value.StringVal
+	if at := strings.LastIndex(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	separator := ":"
+	if strings.Contains(version, ":") {
+		// A digest, such as sha256:...
+		separator = "@"
+	}
+	ref.Params[i].Value = *v1.NewStructuredValues(image + separator + version)
+	return nil
+}
+
+// restartLabels returns the labels of a restarted run: the labels of the
+// original run, with the added labels on top.
+func restartLabels(original, added map[string]string) map[string]string {
+	if len(original) == 0 && len(added) == 0 {
+		return nil
+	}
+	labels := maps.Clone(original)
+	if labels == nil {
+		labels = make(map[string]string, len(added))
+	}
+	maps.Copy(labels, added)
+	return labels
+}
+
+// restartChanges describes the labels of a restarted run against the labels
+// of the original run, and the diff of its spec against the spec of the
+// original run.
+func restartChanges(originalLabels, labels map[string]string, original, restarted any) (string, error) {
+	before, err := yaml.Marshal(original)
+	if err != nil {
+		return "", err
+	}
+	after, err := yaml.Marshal(restarted)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var kept []string
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		value, ok := originalLabels[key]
+		switch {
+		case !ok:
+			sb.WriteString(fmt.Sprintf("\nAdded label %s=%s", key, labels[key]))
+		case value != labels[key]:
+			sb.WriteString(fmt.Sprintf("\nOverridden label %s=%s, was %s", key, labels[key], value))
+		default:
+			kept = append(kept, key+"="+value)
+		}
+	}
+	if len(kept) > 0 {
+		sb.WriteString("\nKept labels " + strings.Join(kept, ", "))
+	}
+	if diff := lineDiff(string(before), string(after)); diff != "" {
+		sb.WriteString("\nSpec changes:\n" + diff)
+	}
+	return sb.String(), nil
 }
@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestRestartWithOverrides(t *testing.T) {
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default", Labels: map[string]string{"team": "ci", "trigger": "cron"}},
+				Spec: v1.PipelineRunSpec{
+					PipelineRef: &v1.PipelineRef{ResolverRef: v1.ResolverRef{
+						Resolver: "git",
+						Params: v1.Params{
+							{Name: "url", Value: *v1.NewStructuredValues("https://github.com/tektoncd/catalog")},
+							{Name: "revision", Value: *v1.NewStructuredValues("v1")},
+						},
+					}},
+					Params: v1.Params{
+						{Name: "revision", Value: *v1.NewStructuredValues("main")},
+						{Name: "image", Value: *v1.NewStructuredValues("registry/app")},
+					},
+				},
+				Status: v1.PipelineRunStatus{PipelineRunStatusFields: v1.PipelineRunStatusFields{
+					PipelineSpec: &v1.PipelineSpec{Params: v1.ParamSpecs{{Name: "revision"}, {Name: "image"}}},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: "default"},
+				Spec: v1.PipelineRunSpec{
+					PipelineRef: &v1.PipelineRef{Name: "build"},
+				},
+				Status: v1.PipelineRunStatus{PipelineRunStatusFields: v1.PipelineRunStatusFields{
+					PipelineSpec: &v1.PipelineSpec{Params: v1.ParamSpecs{{Name: "flags", Type: v1.ParamTypeArray}}},
+				}},
+			},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "lint", Namespace: "default", GenerateName: "lint-", Labels: map[string]string{"app": "lint"}},
+				Spec: v1.TaskRunSpec{
+					TaskRef: &v1.TaskRef{ResolverRef: v1.ResolverRef{
+						Resolver: "bundles",
+						Params: v1.Params{
+							{Name: "bundle", Value: *v1.NewStructuredValues("registry:5000/lint:0.1@sha256:abc")},
+							{Name: "name", Value: *v1.NewStructuredValues("lint")},
+						},
+					}},
+					ServiceAccountName: "default",
+				},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	// The fake clientset does not generate names
+	for _, resource := range []string{"pipelineruns", "taskruns"} {
+		clients.Pipeline.PrependReactor("create", resource, func(action ktesting.Action) (bool, runtime.Object, error) {
+			obj := action.(ktesting.CreateAction).GetObject().(metav1.Object)
+			obj.SetName(obj.GetGenerateName() + "abcde")
+			return false, nil, nil
+		})
+	}
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	t.Run("restart_pipelinerun", func(t *testing.T) {
+		response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name: "restart_pipelinerun",
+			Arguments: map[string]any{
+				"name":               "release",
+				"params":             map[string]any{"revision": "abc123"},
+				"serviceAccountName": "releaser",
+				"timeout":            "2h",
+				"version":            "v2",
+				"labels":             map[string]string{"trigger": "manual", "reason": "flaky"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := response.Content[0].(*mcp.TextContent)
+		expectedText := `Restarting pipelinerun release as release-abcde in namespace default
+Added label reason=flaky
+Overridden label trigger=manual, was cron
+Kept labels team=ci
+Spec changes:
+  params:
+  - name: revision
+-   value: main
++   value: abc123
+  - name: image
+    value: registry/app
+  pipelineRef:
+...
+    - name: url
+      value: https://github.com/tektoncd/catalog
+    - name: revision
+-     value: v1
++     value: v2
+    resolver: git
+- taskRunTemplate: {}
++ taskRunTemplate:
++   serviceAccountName: releaser
++ timeouts:
++   pipeline: 2h0m0s`
+		if diff := cmp.Diff(expectedText, content.Text); diff != "" {
+			t.Errorf("restart_pipelinerun mismatch (-want +got):\n%s", diff)
+		}
+
+		pr, err := clients.Pipeline.TektonV1().PipelineRuns("default").Get(ctx, "release-abcde", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(map[string]string{"reason": "flaky", "team": "ci", "trigger": "manual"}, pr.Labels); diff != "" {
+			t.Errorf("labels mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(&v1.TimeoutFields{Pipeline: &metav1.Duration{Duration: 2 * time.Hour}}, pr.Spec.Timeouts); diff != "" {
+			t.Errorf("timeouts mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("restart_taskrun", func(t *testing.T) {
+		response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "restart_taskrun",
+			Arguments: map[string]any{"name": "lint", "version": "0.2"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := response.Content[0].(*mcp.TextContent)
+		expectedText := `Restarting taskrun lint as lint-abcde in namespace default
+Kept labels app=lint
+Spec changes:
+  taskRef:
+    params:
+    - name: bundle
+-     value: registry:5000/lint:0.1@sha256:abc
++     value: registry:5000/lint:0.2
+    - name: name
+      value: lint
+    resolver: bundles`
+		if diff := cmp.Diff(expectedText, content.Text); diff != "" {
+			t.Errorf("restart_taskrun mismatch (-want +got):\n%s", diff)
+		}
+
+		tr, err := clients.Pipeline.TektonV1().TaskRuns("default").Get(ctx, "lint-abcde", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(map[string]string{"app": "lint"}, tr.Labels); diff != "" {
+			t.Errorf("labels mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	errorTests := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		err       string
+	}{
+		{
+			name:      "unknown param",
+			tool:      "restart_pipelinerun",
+			arguments: map[string]any{"name": "local", "params": map[string]any{"revision": "abc123"}},
+			err:       `unknown param "revision"`,
+		},
+		{
+			name:      "param type",
+			tool:      "restart_pipelinerun",
+			arguments: map[string]any{"name": "local", "params": map[string]any{"flags": "-v"}},
+			err:       `param "flags" expects a value of type array, got string`,
+		},
+		{
+			name:      "version of a local reference",
+			tool:      "restart_pipelinerun",
+			arguments: map[string]any{"name": "local", "version": "v2"},
+			err:       "version can only be set on a remote reference",
+		},
+		{
+			name:      "invalid timeout",
+			tool:      "restart_taskrun",
+			arguments: map[string]any{"name": "lint", "timeout": "forever"},
+			err:       "invalid timeout",
+		},
+	}
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: test.tool, Arguments: test.arguments})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+// The types of the results written to the logs of the results sidecar, when
+// the results-from feature flag is set to sidecar-logs.
+const (
+	sidecarLogTaskResult   = "task"
+	sidecarLogStepResult   = "step"
+	sidecarLogTaskArtifact = "taskArtifact"
+	sidecarLogStepArtifact = "stepArtifact"
+
+	// maxSidecarLogLine is the size of the longest line read from the logs
+	// of the results sidecar
+	maxSidecarLogLine = 4 * 1024 * 1024
+)
+
+type getRunResultsParams struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	SidecarLogs bool   `json:"sidecarLogs"`
+	Output      string `json:"output"`
+}
+
+// runResults holds the results of a PipelineRun and the results and
+// artifacts of its TaskRuns, or those of a single TaskRun. Results are
+// strings, arrays of strings or objects.
+type runResults struct {
+	Kind      string           `json:"kind"`
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	Results   map[string]any   `json:"results,omitempty"`
+	TaskRuns  []taskRunResults `json:"taskRuns,omitempty"`
+}
+
+type taskRunResults struct {
+	PipelineTask string         `json:"pipelineTask,omitempty"`
+	TaskRun      string         `json:"taskRun"`
+	Results      map[string]any `json:"results,omitempty"`
+	Artifacts    *v1.Artifacts  `json:"artifacts,omitempty"`
+	Steps        []stepResults  `json:"steps,omitempty"`
+	// SidecarLogResults are the results and artifacts missing from the
+	// status that were read from the logs of the results sidecar
+	SidecarLogResults []string `json:"sidecarLogResults,omitempty"`
+	SidecarLogsError  string   `json:"sidecarLogsError,omitempty"`
+}
+
+type stepResults struct {
+	Name    string         `json:"name"`
+	Results map[string]any `json:"results,omitempty"`
+	Inputs  []v1.Artifact  `json:"inputs,omitempty"`
+	Outputs []v1.Artifact  `json:"outputs,omitempty"`
+}
+
+// sidecarLogResult is a result written to the logs of the results sidecar.
+// The name of a step result is prefixed by the name of its step and a dot,
+// and the name of a step artifact is the name of its step.
+type sidecarLogResult struct {
+	Name  string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+func getRunResults() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[getRunResultsParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["kind"].Description = "Kind of the run (pipelinerun or taskrun)"
+	scheme.Properties["kind"].Enum = []any{kindPipelineRun, kindTaskRun}
+	scheme.Properties["kind"].Default = json.RawMessage(`"pipelinerun"`)
+	scheme.Properties["name"].Description = "Name of the run"
+	scheme.Properties["namespace"].Description = "Namespace of the run"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["sidecarLogs"].Description = "Read the results and artifacts missing from the status of the TaskRuns " +
+		"from the logs of their results sidecar, when results are written through sidecar logs"
+	scheme.Properties["sidecarLogs"].Default = json.RawMessage(`false`)
+	scheme.Properties["output"].Description = outputFormatDescription
+	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"get_run_results",
+		"Get the results of a PipelineRun, and the results and artifacts of its TaskRuns and their steps, or those of a TaskRun, "+
+			"as string, array or object values, such as the image digests and URLs emitted by a build",
+		handlerGetRunResults,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerGetRunResults(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[getRunResultsParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	kind := strings.ToLower(params.Arguments.Kind)
+	if kind == "" {
+		kind = kindPipelineRun
+	}
+
+	results := &runResults{Kind: kind, Name: name, Namespace: namespace}
+	var taskRuns []*v1.TaskRun
+	switch kind {
+	case kindPipelineRun:
+		pr, err := scope.PipelineRun(ctx, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
+		}
+		results.Results = make(map[string]any, len(pr.Status.Results))
+		for _, r := range pr.Status.Results {
+			results.Results[r.Name] = typedValue(r.Value)
+		}
+		for _, child := range pr.Status.ChildReferences {
+			if child.Kind != taskRunKind {
+				continue
+			}
+			// TaskRuns may have been pruned, only the results of the
+			// remaining ones are returned
+			tr, err := scope.TaskRun(ctx, namespace, child.Name)
+			if err != nil {
+				continue
+			}
+			taskRuns = append(taskRuns, tr)
+			results.TaskRuns = append(results.TaskRuns, newTaskRunResults(child.PipelineTaskName, tr))
+		}
+	case kindTaskRun:
+		tr, err := scope.TaskRun(ctx, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", namespace, name, err)
+		}
+		taskRuns = append(taskRuns, tr)
+		results.TaskRuns = append(results.TaskRuns, newTaskRunResults("", tr))
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, expected %s or %s", params.Arguments.Kind, kindPipelineRun, kindTaskRun)
+	}
+
+	if params.Arguments.SidecarLogs {
+		pods := kubeclient.Get(ctx).CoreV1().Pods(namespace)
+		for i, tr := range taskRuns {
+			if tr.Status.PodName == "" || !slices.ContainsFunc(tr.Status.Sidecars, func(s v1.SidecarState) bool {
+				return s.Name == pipeline.ReservedResultsSidecarName
+			}) {
+				continue
+			}
+			logs, err := pods.GetLogs(tr.Status.PodName, &corev1.PodLogOptions{Container: pipeline.ReservedResultsSidecarContainerName}).Stream(ctx)
+			if err != nil {
+				results.TaskRuns[i].SidecarLogsError = fmt.Sprintf("failed to get the logs of the results sidecar of Pod %s: %v", tr.Status.PodName, err)
+				continue
+			}
+			sidecarResults, err := parseSidecarLogResults(logs)
+			logs.Close()
+			if err != nil {
+				results.TaskRuns[i].SidecarLogsError = fmt.Sprintf("failed to read the logs of the results sidecar of Pod %s: %v", tr.Status.PodName, err)
+			}
+			results.TaskRuns[i].addSidecarLogResults(sidecarResults)
+		}
+	}
+
+	out, err := marshalOutput(results, params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
+	return result(out), nil
+}
+
+// newTaskRunResults returns the results and artifacts in the status of a
+// TaskRun. Only the steps with results or artifacts are listed.
+func newTaskRunResults(pipelineTask string, tr *v1.TaskRun) taskRunResults {
+	results := taskRunResults{
+		PipelineTask: pipelineTask,
+		TaskRun:      tr.Name,
+		Results:      make(map[string]any, len(tr.Status.Results)),
+		Artifacts:    tr.Status.Artifacts,
+	}
+	for _, r := range tr.Status.Results {
+		results.Results[r.Name] = typedValue(r.Value)
+	}
+	for _, step := range tr.Status.Steps {
+		if len(step.Results) == 0 && len(step.Inputs) == 0 && len(step.Outputs) == 0 {
+			continue
+		}
+		sr := stepResults{Name: step.Name, Inputs: step.Inputs, Outputs: step.Outputs}
+		for _, r := range step.Results {
+			if sr.Results == nil {
+				sr.Results = map[string]any{}
+			}
+			sr.Results[r.Name] = typedValue(r.Value)
+		}
+		results.Steps = append(results.Steps, sr)
+	}
+	return results
+}
+
+// addSidecarLogResults adds the results and artifacts read from the logs of
+// the results sidecar that are missing from the status of the TaskRun.
+func (t *taskRunResults) addSidecarLogResults(results []sidecarLogResult) {
+	for _, r := range results {
+		switch r.Type {
+		case sidecarLogTaskResult:
+			if _, ok := t.Results[r.Name]; !ok {
+				t.Results[r.Name] = typedValue(parseResultValue(r.Value))
+				t.SidecarLogResults = append(t.SidecarLogResults, r.Name)
+			}
+		case sidecarLogStepResult:
+			stepName, name, ok := strings.Cut(r.Name, ".")
+			if !ok {
+				continue
+			}
+			step := t.step(stepName)
+			if _, ok := step.Results[name]; !ok {
+				if step.Results == nil {
+					step.Results = map[string]any{}
+				}
+				step.Results[name] = typedValue(parseResultValue(r.Value))
+				t.SidecarLogResults = append(t.SidecarLogResults, r.Name)
+			}
+		case sidecarLogTaskArtifact:
+			var artifacts v1.Artifacts
+			if t.Artifacts == nil && json.Unmarshal([]byte(r.Value), &artifacts) == nil {
+				t.Artifacts = &artifacts
+				t.SidecarLogResults = append(t.SidecarLogResults, "artifacts")
+			}
+		case sidecarLogStepArtifact:
+			var artifacts v1.Artifacts
+			step := t.step(r.Name)
+			if len(step.Inputs) == 0 && len(step.Outputs) == 0 && json.Unmarshal([]byte(r.Value), &artifacts) == nil {
+				step.Inputs, step.Outputs = artifacts.Inputs, artifacts.Outputs
+				t.SidecarLogResults = append(t.SidecarLogResults, r.Name+".artifacts")
+			}
+		}
+	}
+}
+
+// step returns the results of a step, adding them if the step had none.
+func (t *taskRunResults) step(name string) *stepResults {
+	i := slices.IndexFunc(t.Steps, func(s stepResults) bool { return s.Name == name })
+	if i < 0 {
+		t.Steps = append(t.Steps, stepResults{Name: name})
+		i = len(t.Steps) - 1
+	}
+	return &t.Steps[i]
+}
+
+// parseSidecarLogResults reads the results written to the logs of the
+// results sidecar, one JSON object per line. The other lines are ignored.
+func parseSidecarLogResults(r io.Reader) ([]sidecarLogResult, error) {
+	var results []sidecarLogResult
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxSidecarLogLine)
+	for scanner.Scan() {
+		var result sidecarLogResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil || result.Name == "" {
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
+
+// parseResultValue parses a result the same way Tekton does, arrays and
+// objects being written as JSON.
+func parseResultValue(s string) v1.ParamValue {
+	var v v1.ParamValue
+	_ = v.UnmarshalJSON([]byte(s))
+	return v
+}
+
+// typedValue returns the string, array or object value of a result.
+func typedValue(v v1.ParamValue) any {
+	switch v.Type {
+	case v1.ParamTypeArray:
+		return v.ArrayVal
+	case v1.ParamTypeObject:
+		return v.ObjectVal
+	}
+	return v.StringVal
+}
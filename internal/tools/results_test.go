@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+func TestGetRunResults(t *testing.T) {
+	image := v1.Artifact{
+		Name:        "image",
+		BuildOutput: true,
+		Values: []v1.ArtifactValue{{
+			Uri:    "registry.example.com/app",
+			Digest: map[v1.Algorithm]string{"sha256": "abc"},
+		}},
+	}
+	source := v1.Artifact{
+		Name:   "source",
+		Values: []v1.ArtifactValue{{Uri: "git+https://example.com/app.git"}},
+	}
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+				Status: v1.PipelineRunStatus{
+					PipelineRunStatusFields: v1.PipelineRunStatusFields{
+						Results: []v1.PipelineRunResult{
+							{Name: "digest", Value: *v1.NewStructuredValues("sha256:abc")},
+							{Name: "tags", Value: *v1.NewStructuredValues("latest", "v1")},
+							{Name: "image", Value: *v1.NewObject(map[string]string{"url": "registry.example.com/app", "digest": "sha256:abc"})},
+						},
+						ChildReferences: []v1.ChildStatusReference{
+							{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-build", PipelineTaskName: "build"},
+							{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-scan", PipelineTaskName: "scan"},
+							{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-pruned", PipelineTaskName: "pruned"},
+						},
+					},
+				},
+			},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-build", Namespace: "default"},
+				Status: v1.TaskRunStatus{
+					TaskRunStatusFields: v1.TaskRunStatusFields{
+						Results: []v1.TaskRunResult{
+							{Name: "digest", Type: v1.ResultsTypeString, Value: *v1.NewStructuredValues("sha256:abc")},
+						},
+						Artifacts: &v1.Artifacts{Inputs: []v1.Artifact{source}, Outputs: []v1.Artifact{image}},
+						Steps: []v1.StepState{
+							{Name: "clone"},
+							{
+								Name:    "build",
+								Results: []v1.TaskRunStepResult{{Name: "files", Type: v1.ResultsTypeArray, Value: *v1.NewStructuredValues("a", "b")}},
+								Outputs: []v1.Artifact{image},
+							},
+						},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-scan", Namespace: "default"},
+				Status: v1.TaskRunStatus{
+					TaskRunStatusFields: v1.TaskRunStatusFields{
+						PodName: "release-scan-pod",
+						Results: []v1.TaskRunResult{
+							{Name: "report", Type: v1.ResultsTypeString, Value: *v1.NewStructuredValues("from the status")},
+						},
+						Sidecars: []v1.SidecarState{{Name: "tekton-log-results", Container: "sidecar-tekton-log-results"}},
+					},
+				},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	kubeclientset := &fakeClient{
+		Clientset: clients.Kube,
+		logs: map[string]map[string]string{
+			"release-scan-pod": {
+				"sidecar-tekton-log-results": `starting
+{"key":"report","value":"from the logs","type":"task"}
+{"key":"vulnerabilities","value":"{\"critical\":\"0\",\"high\":\"2\"}","type":"task"}
+{"key":"scan.cves","value":"[\"CVE-1\",\"CVE-2\"]","type":"step"}
+{"key":"scan","value":"{\"outputs\":[{\"name\":\"sbom\",\"values\":[{\"uri\":\"registry.example.com/app.sbom\"}]}]}","type":"stepArtifact"}
+not json
+`,
+			},
+		},
+	}
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeclientset)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	build := taskRunResults{
+		PipelineTask: "build",
+		TaskRun:      "release-build",
+		Results:      map[string]any{"digest": "sha256:abc"},
+		Artifacts:    &v1.Artifacts{Inputs: []v1.Artifact{source}, Outputs: []v1.Artifact{image}},
+		Steps: []stepResults{{
+			Name:    "build",
+			Results: map[string]any{"files": []any{"a", "b"}},
+			Outputs: []v1.Artifact{image},
+		}},
+	}
+	pipelineRunResults := map[string]any{
+		"digest": "sha256:abc",
+		"tags":   []any{"latest", "v1"},
+		"image":  map[string]any{"url": "registry.example.com/app", "digest": "sha256:abc"},
+	}
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		expected  runResults
+	}{
+		{
+			name:      "pipelinerun",
+			arguments: map[string]any{"name": "release"},
+			expected: runResults{
+				Kind:      "pipelinerun",
+				Name:      "release",
+				Namespace: "default",
+				Results:   pipelineRunResults,
+				TaskRuns: []taskRunResults{
+					build,
+					{PipelineTask: "scan", TaskRun: "release-scan", Results: map[string]any{"report": "from the status"}},
+				},
+			},
+		},
+		{
+			name:      "sidecar logs",
+			arguments: map[string]any{"name": "release", "sidecarLogs": true},
+			expected: runResults{
+				Kind:      "pipelinerun",
+				Name:      "release",
+				Namespace: "default",
+				Results:   pipelineRunResults,
+				TaskRuns: []taskRunResults{
+					build,
+					{
+						PipelineTask: "scan",
+						TaskRun:      "release-scan",
+						Results: map[string]any{
+							"report":          "from the status",
+							"vulnerabilities": map[string]any{"critical": "0", "high": "2"},
+						},
+						Steps: []stepResults{{
+							Name:    "scan",
+							Results: map[string]any{"cves": []any{"CVE-1", "CVE-2"}},
+							Outputs: []v1.Artifact{{Name: "sbom", Values: []v1.ArtifactValue{{Uri: "registry.example.com/app.sbom"}}}},
+						}},
+						SidecarLogResults: []string{"vulnerabilities", "scan.cves", "scan.artifacts"},
+					},
+				},
+			},
+		},
+		{
+			name:      "taskrun",
+			arguments: map[string]any{"kind": "taskrun", "name": "release-build"},
+			expected: runResults{
+				Kind:      "taskrun",
+				Name:      "release-build",
+				Namespace: "default",
+				TaskRuns: []taskRunResults{func() taskRunResults {
+					tr := build
+					tr.PipelineTask = ""
+					return tr
+				}()},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.arguments["output"] = "json"
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "get_run_results", Arguments: tc.arguments})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got runResults
+			if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("results mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
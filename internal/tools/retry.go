@@ -0,0 +1,303 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// retryOfAnnotation is set on a retried PipelineRun to the name of the
+	// PipelineRun it retries
+	retryOfAnnotation = "mcp.tekton.dev/retry-of"
+	// retryOfPipelineAnnotation is set on a retried PipelineRun to the name of
+	// the Pipeline of the PipelineRun it retries, as the pipeline spec of the
+	// retry is embedded
+	retryOfPipelineAnnotation = "mcp.tekton.dev/retry-of-pipeline"
+)
+
+type retryFailedPipelineRunParams struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	DryRun    bool   `json:"dryRun"`
+}
+
+func retryFailedPipelineRun() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[retryFailedPipelineRunParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["name"].Description = "Name of the failed PipelineRun"
+	scheme.Properties["namespace"].Description = "Namespace of the PipelineRun"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["dryRun"].Description = dryRunDescription
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"retry_failed_pipelinerun",
+		"Retry the failed part of a PipelineRun: the pipeline tasks that succeeded are skipped and their results are reused",
+		handlerRetryFailedPipelineRun,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerRetryFailedPipelineRun(
+	ctx context.Context,
+	cc *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[retryFailedPipelineRunParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	pipelineclientset := pipelineclient.Get(ctx)
+
+	usepr, err := scope.PipelineRun(ctx, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
+	}
+	if !usepr.Status.GetCondition(apis.ConditionSucceeded).IsFalse() {
+		return nil, fmt.Errorf("PipelineRun %s/%s has not failed (%s)", namespace, name, conditionReason(usepr.Status.GetCondition(apis.ConditionSucceeded)))
+	}
+	spec, err := pipelineSpecFor(ctx, usepr)
+	if err != nil {
+		return nil, err
+	}
+
+	reused, err := succeededPipelineTasks(ctx, usepr, spec)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := newRetryPlan(spec, usepr.Spec.Params, reused)
+	if err != nil {
+		return nil, fmt.Errorf("cannot retry PipelineRun %s/%s: %w", namespace, name, err)
+	}
+
+	pr := &v1.PipelineRun{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "tekton.dev/v1",
+			Kind:       "PipelineRun",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    namespace,
+			GenerateName: name + "-",
+			Labels:       maps.Clone(usepr.Labels),
+			Annotations:  retryAnnotations(usepr),
+		},
+		Spec: *usepr.Spec.DeepCopy(),
+	}
+	pr.Spec.Status = ""
+	pr.Spec.PipelineRef = nil
+	pr.Spec.PipelineSpec = plan.spec
+	pr.Spec.Params = append(pr.Spec.Params, plan.params...)
+	pr.Spec.TaskRunSpecs = slices.DeleteFunc(pr.Spec.TaskRunSpecs, func(s v1.PipelineTaskRunSpec) bool {
+		return reused[s.PipelineTaskName] != nil
+	})
+	if len(usepr.ObjectMeta.GenerateName) > 0 {
+		pr.ObjectMeta.GenerateName = usepr.ObjectMeta.GenerateName
+	}
+
+	pr, err = pipelineclientset.TektonV1().PipelineRuns(namespace).Create(ctx, pr, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PipelineRun %s/%s: %w", namespace, pr.ObjectMeta.Name, err)
+	}
+
+	var annotations strings.Builder
+	for _, key := range []string{retryOfAnnotation, retryOfPipelineAnnotation} {
+		if value, ok := pr.Annotations[key]; ok {
+			annotations.WriteString(fmt.Sprintf("\nAdded annotation %s=%s", key, value))
+		}
+	}
+
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("Failed PipelineRun %s would be retried as %s in namespace %s\n%s%s", name, pr.ObjectMeta.Name, namespace, plan, annotations.String()), pr)
+	}
+	return result(fmt.Sprintf("Retrying failed pipelinerun %s as %s in namespace %s\n%s%s", name, pr.ObjectMeta.Name, namespace, plan, annotations.String())), nil
+}
+
+// retryAnnotations returns the annotations linking a retried PipelineRun to
+// the failed one and to its Pipeline. The controller sets the
+// tekton.dev/pipeline label of a PipelineRun embedding its pipeline spec to
+// the name of the run, the annotation keeps the name of the Pipeline across
+// retries.
+func retryAnnotations(pr *v1.PipelineRun) map[string]string {
+	pipelineName := pr.Annotations[retryOfPipelineAnnotation]
+	if pipelineName == "" && pr.Spec.PipelineRef != nil {
+		pipelineName = pr.Spec.PipelineRef.Name
+	}
+	if pipelineName == "" {
+		pipelineName = pr.Labels[pipeline.PipelineLabelKey]
+	}
+
+	annotations := map[string]string{retryOfAnnotation: pr.Name}
+	if pipelineName != "" {
+		annotations[retryOfPipelineAnnotation] = pipelineName
+	}
+	return annotations
+}
+
+// succeededPipelineTasks returns the TaskRuns of the pipeline tasks that
+// succeeded in a PipelineRun, by pipeline task name. Matrixed pipeline tasks,
+// whose results are aggregated from several TaskRuns, and finally tasks are
+// always executed again.
+func succeededPipelineTasks(ctx context.Context, pr *v1.PipelineRun, spec *v1.PipelineSpec) (map[string]*v1.TaskRun, error) {
+	children := make(map[string][]v1.ChildStatusReference)
+	for _, child := range pr.Status.ChildReferences {
+		children[child.PipelineTaskName] = append(children[child.PipelineTaskName], child)
+	}
+
+	succeeded := make(map[string]*v1.TaskRun)
+	for _, pt := range spec.Tasks {
+		refs := children[pt.Name]
+		if len(refs) == 0 || pt.IsMatrixed() {
+			continue
+		}
+
+		child := refs[len(refs)-1]
+		if child.Kind != taskRunKind {
+			continue
+		}
+		tr, err := scope.TaskRun(ctx, pr.Namespace, child.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TaskRun %s/%s: %w", pr.Namespace, child.Name, err)
+		}
+		if tr.Status.GetCondition(apis.ConditionSucceeded).IsTrue() {
+			succeeded[pt.Name] = tr
+		}
+	}
+	return succeeded, nil
+}
+
+// retryPlan is the pipeline spec used to retry the failed part of a
+// PipelineRun, with the params carrying the results of the reused tasks.
+type retryPlan struct {
+	spec   *v1.PipelineSpec
+	params v1.Params
+
+	rerun   []string
+	reused  []string
+	dropped []string
+}
+
+func (p *retryPlan) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Re-executed tasks: %s", strings.Join(p.rerun, ", ")))
+	if len(p.reused) > 0 {
+		sb.WriteString(fmt.Sprintf("\nReused tasks: %s", strings.Join(p.reused, ", ")))
+	}
+	for _, param := range p.params {
+		sb.WriteString(fmt.Sprintf("\nReused result as param %s", param.Name))
+	}
+	for _, result := range p.dropped {
+		sb.WriteString(fmt.Sprintf("\nDropped pipeline result %s, it references reused tasks", result))
+	}
+	return sb.String()
+}
+
+// newRetryPlan removes the reused tasks from a pipeline spec. The references
+// to their results are replaced by references to new params, set to the
+// recorded values, and their status is replaced by Succeeded.
+func newRetryPlan(spec *v1.PipelineSpec, runParams v1.Params, reused map[string]*v1.TaskRun) (*retryPlan, error) {
+	plan := &retryPlan{spec: spec.DeepCopy()}
+
+	plan.spec.Tasks = slices.DeleteFunc(plan.spec.Tasks, func(pt v1.PipelineTask) bool {
+		if reused[pt.Name] != nil {
+			plan.reused = append(plan.reused, pt.Name)
+			return true
+		}
+		plan.rerun = append(plan.rerun, pt.Name)
+		return false
+	})
+	if len(plan.spec.Tasks) == 0 {
+		return nil, errors.New("all the pipeline tasks succeeded")
+	}
+	for _, pt := range plan.spec.Finally {
+		plan.rerun = append(plan.rerun, pt.Name)
+	}
+	for i := range plan.spec.Tasks {
+		plan.spec.Tasks[i].RunAfter = slices.DeleteFunc(plan.spec.Tasks[i].RunAfter, func(name string) bool {
+			return reused[name] != nil
+		})
+	}
+
+	// The tasks are rewritten as JSON, which covers params, when expressions,
+	// matrix and embedded specs at once
+	tasks, err := json.Marshal(struct {
+		Tasks   []v1.PipelineTask `json:"tasks"`
+		Finally []v1.PipelineTask `json:"finally"`
+	}{plan.spec.Tasks, plan.spec.Finally})
+	if err != nil {
+		return nil, err
+	}
+	rewritten := string(tasks)
+
+	var errs []error
+	for _, name := range plan.reused {
+		rewritten = strings.ReplaceAll(rewritten, fmt.Sprintf("$(tasks.%s.status)", name), string(v1.TaskRunReasonSuccessful))
+		for _, result := range reused[name].Status.Results {
+			ref := regexp.MustCompile(`\$\(tasks\.` + regexp.QuoteMeta(name) + `\.results\.` + regexp.QuoteMeta(result.Name) + `([\[.)])`)
+			if !ref.MatchString(rewritten) {
+				continue
+			}
+			paramName := fmt.Sprintf("%s-%s", name, result.Name)
+			if slices.ContainsFunc(plan.spec.Params, func(p v1.ParamSpec) bool { return p.Name == paramName }) ||
+				slices.ContainsFunc(runParams, func(p v1.Param) bool { return p.Name == paramName }) {
+				errs = append(errs, fmt.Errorf("param %q, used to reuse a result of task %s, already exists", paramName, name))
+				continue
+			}
+			rewritten = ref.ReplaceAllString(rewritten, "$$(params."+paramName+"$1")
+			plan.spec.Params = append(plan.spec.Params, v1.ParamSpec{
+				Name:        paramName,
+				Type:        v1.ParamType(result.Value.Type),
+				Description: fmt.Sprintf("Result %s of the reused task %s", result.Name, name),
+			})
+			plan.params = append(plan.params, v1.Param{Name: paramName, Value: result.Value})
+		}
+		if strings.Contains(rewritten, fmt.Sprintf("tasks.%s.results.", name)) {
+			errs = append(errs, fmt.Errorf("a result of task %s is used by the re-executed tasks but was not recorded", name))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var rewrittenTasks struct {
+		Tasks   []v1.PipelineTask `json:"tasks"`
+		Finally []v1.PipelineTask `json:"finally"`
+	}
+	if err := json.Unmarshal([]byte(rewritten), &rewrittenTasks); err != nil {
+		return nil, err
+	}
+	plan.spec.Tasks, plan.spec.Finally = rewrittenTasks.Tasks, rewrittenTasks.Finally
+
+	// Pipeline results can only reference task results
+	plan.spec.Results = slices.DeleteFunc(plan.spec.Results, func(r v1.PipelineResult) bool {
+		value, _ := json.Marshal(r.Value)
+		for _, name := range plan.reused {
+			if strings.Contains(string(value), fmt.Sprintf("tasks.%s.", name)) {
+				plan.dropped = append(plan.dropped, r.Name)
+				return true
+			}
+		}
+		return false
+	})
+
+	return plan, nil
+}
@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestRetryFailedPipelineRun(t *testing.T) {
+	spec := &v1.PipelineSpec{
+		Params: v1.ParamSpecs{{Name: "revision"}},
+		Tasks: []v1.PipelineTask{
+			{
+				Name:    "build",
+				TaskRef: &v1.TaskRef{Name: "build"},
+				Params:  v1.Params{{Name: "revision", Value: *v1.NewStructuredValues("$(params.revision)")}},
+			},
+			{
+				Name:     "lint",
+				TaskRef:  &v1.TaskRef{Name: "lint"},
+				RunAfter: []string{"build"},
+			},
+			{
+				Name:     "test",
+				TaskRef:  &v1.TaskRef{Name: "test"},
+				RunAfter: []string{"lint"},
+				Params:   v1.Params{{Name: "image", Value: *v1.NewStructuredValues("$(tasks.build.results.digest)")}},
+			},
+			{
+				Name:    "publish",
+				TaskRef: &v1.TaskRef{Name: "publish"},
+				Params:  v1.Params{{Name: "tags", Value: *v1.NewStructuredValues("$(tasks.build.results.tags[*])")}},
+				When:    v1.WhenExpressions{{Input: "$(tasks.test.results.passed)", Operator: "in", Values: []string{"true"}}},
+			},
+		},
+		Finally: []v1.PipelineTask{
+			{
+				Name:    "notify",
+				TaskRef: &v1.TaskRef{Name: "notify"},
+				Params:  v1.Params{{Name: "build-status", Value: *v1.NewStructuredValues("$(tasks.build.status)")}},
+			},
+		},
+		Results: []v1.PipelineResult{
+			{Name: "image", Value: *v1.NewStructuredValues("$(tasks.build.results.digest)")},
+			{Name: "report", Value: *v1.NewStructuredValues("$(tasks.test.results.report)")},
+		},
+	}
+	child := func(task string) v1.ChildStatusReference {
+		return v1.ChildStatusReference{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-" + task, PipelineTaskName: task}
+	}
+	taskRun := func(task string, status corev1.ConditionStatus, results ...v1.TaskRunResult) *v1.TaskRun {
+		return &v1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "release-" + task, Namespace: "default"},
+			Status: v1.TaskRunStatus{
+				Status:              succeededCondition(status, ""),
+				TaskRunStatusFields: v1.TaskRunStatusFields{Results: results},
+			},
+		}
+	}
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "release",
+					Namespace:   "default",
+					Labels:      map[string]string{"tekton.dev/pipeline": "release", "team": "ci"},
+					Annotations: map[string]string{"chains.tekton.dev/signed": "true"},
+				},
+				Spec: v1.PipelineRunSpec{
+					PipelineRef: &v1.PipelineRef{Name: "release"},
+					Params:      v1.Params{{Name: "revision", Value: *v1.NewStructuredValues("abc123")}},
+					TaskRunSpecs: []v1.PipelineTaskRunSpec{
+						{PipelineTaskName: "build", ServiceAccountName: "builder"},
+						{PipelineTaskName: "test", ServiceAccountName: "tester"},
+					},
+				},
+				Status: v1.PipelineRunStatus{
+					Status: succeededCondition(corev1.ConditionFalse, "Failed"),
+					PipelineRunStatusFields: v1.PipelineRunStatusFields{
+						PipelineSpec:    spec,
+						ChildReferences: []v1.ChildStatusReference{child("build"), child("lint"), child("test"), child("notify")},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+				Status:     v1.PipelineRunStatus{Status: succeededCondition(corev1.ConditionUnknown, "Running")},
+			},
+		},
+		TaskRuns: []*v1.TaskRun{
+			taskRun("build", corev1.ConditionTrue,
+				v1.TaskRunResult{Name: "digest", Type: v1.ResultsTypeString, Value: *v1.NewStructuredValues("sha256:1234")},
+				v1.TaskRunResult{Name: "tags", Type: v1.ResultsTypeArray, Value: *v1.NewStructuredValues("latest", "v1")},
+			),
+			taskRun("lint", corev1.ConditionTrue),
+			taskRun("test", corev1.ConditionFalse),
+			taskRun("notify", corev1.ConditionTrue),
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	// The fake clientset does not generate names
+	clients.Pipeline.PrependReactor("create", "pipelineruns", func(action ktesting.Action) (bool, runtime.Object, error) {
+		obj := action.(ktesting.CreateAction).GetObject().(metav1.Object)
+		obj.SetName(obj.GetGenerateName() + "abcde")
+		return false, nil, nil
+	})
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "retry_failed_pipelinerun",
+		Arguments: map[string]any{"name": "release"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := response.Content[0].(*mcp.TextContent)
+	expectedText := `Retrying failed pipelinerun release as release-abcde in namespace default
+Re-executed tasks: test, publish, notify
+Reused tasks: build, lint
+Reused result as param build-digest
+Reused result as param build-tags
+Dropped pipeline result image, it references reused tasks
+Added annotation mcp.tekton.dev/retry-of=release
+Added annotation mcp.tekton.dev/retry-of-pipeline=release`
+	if diff := cmp.Diff(expectedText, content.Text); diff != "" {
+		t.Errorf("retry_failed_pipelinerun mismatch (-want +got):\n%s", diff)
+	}
+
+	pr, err := clients.Pipeline.TektonV1().PipelineRuns("default").Get(ctx, "release-abcde", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := v1.PipelineRunSpec{
+		Params: v1.Params{
+			{Name: "revision", Value: *v1.NewStructuredValues("abc123")},
+			{Name: "build-digest", Value: *v1.NewStructuredValues("sha256:1234")},
+			{Name: "build-tags", Value: *v1.NewStructuredValues("latest", "v1")},
+		},
+		TaskRunSpecs: []v1.PipelineTaskRunSpec{{PipelineTaskName: "test", ServiceAccountName: "tester"}},
+		PipelineSpec: &v1.PipelineSpec{
+			Params: v1.ParamSpecs{
+				{Name: "revision"},
+				{Name: "build-digest", Type: v1.ParamTypeString, Description: "Result digest of the reused task build"},
+				{Name: "build-tags", Type: v1.ParamTypeArray, Description: "Result tags of the reused task build"},
+			},
+			Tasks: []v1.PipelineTask{
+				{
+					Name:    "test",
+					TaskRef: &v1.TaskRef{Name: "test"},
+					Params:  v1.Params{{Name: "image", Value: *v1.NewStructuredValues("$(params.build-digest)")}},
+				},
+				{
+					Name:    "publish",
+					TaskRef: &v1.TaskRef{Name: "publish"},
+					Params:  v1.Params{{Name: "tags", Value: *v1.NewStructuredValues("$(params.build-tags[*])")}},
+					When:    v1.WhenExpressions{{Input: "$(tasks.test.results.passed)", Operator: "in", Values: []string{"true"}}},
+				},
+			},
+			Finally: []v1.PipelineTask{
+				{
+					Name:    "notify",
+					TaskRef: &v1.TaskRef{Name: "notify"},
+					Params:  v1.Params{{Name: "build-status", Value: *v1.NewStructuredValues("Succeeded")}},
+				},
+			},
+			Results: []v1.PipelineResult{
+				{Name: "report", Value: *v1.NewStructuredValues("$(tasks.test.results.report)")},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, pr.Spec); diff != "" {
+		t.Errorf("PipelineRun spec mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"tekton.dev/pipeline": "release", "team": "ci"}, pr.Labels); diff != "" {
+		t.Errorf("PipelineRun labels mismatch (-want +got):\n%s", diff)
+	}
+	expectedAnnotations := map[string]string{
+		"mcp.tekton.dev/retry-of":          "release",
+		"mcp.tekton.dev/retry-of-pipeline": "release",
+	}
+	if diff := cmp.Diff(expectedAnnotations, pr.Annotations); diff != "" {
+		t.Errorf("PipelineRun annotations mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("running pipelinerun", func(t *testing.T) {
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "retry_failed_pipelinerun",
+			Arguments: map[string]any{"name": "running"},
+		})
+		if err == nil || !strings.Contains(err.Error(), "PipelineRun default/running has not failed (Running)") {
+			t.Fatalf("expected not failed error, got %v", err)
+		}
+	})
+}
+
+func TestRetryAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		pr       *v1.PipelineRun
+		expected map[string]string
+	}{
+		{
+			name: "pipeline reference",
+			pr: &v1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-1"},
+				Spec:       v1.PipelineRunSpec{PipelineRef: &v1.PipelineRef{Name: "release"}},
+			},
+			expected: map[string]string{"mcp.tekton.dev/retry-of": "release-1", "mcp.tekton.dev/retry-of-pipeline": "release"},
+		},
+		{
+			name: "retried pipelinerun",
+			pr: &v1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "release-1-abcde",
+					Labels:      map[string]string{"tekton.dev/pipeline": "release-1-abcde"},
+					Annotations: map[string]string{"mcp.tekton.dev/retry-of": "release-1", "mcp.tekton.dev/retry-of-pipeline": "release"},
+				},
+				Spec: v1.PipelineRunSpec{PipelineSpec: &v1.PipelineSpec{}},
+			},
+			expected: map[string]string{"mcp.tekton.dev/retry-of": "release-1-abcde", "mcp.tekton.dev/retry-of-pipeline": "release"},
+		},
+		{
+			name: "resolver reference",
+			pr: &v1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-1", Labels: map[string]string{"tekton.dev/pipeline": "release"}},
+				Spec:       v1.PipelineRunSpec{PipelineRef: &v1.PipelineRef{ResolverRef: v1.ResolverRef{Resolver: "git"}}},
+			},
+			expected: map[string]string{"mcp.tekton.dev/retry-of": "release-1", "mcp.tekton.dev/retry-of-pipeline": "release"},
+		},
+		{
+			name: "embedded spec",
+			pr: &v1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-1"},
+				Spec:       v1.PipelineRunSpec{PipelineSpec: &v1.PipelineSpec{}},
+			},
+			expected: map[string]string{"mcp.tekton.dev/retry-of": "release-1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.expected, retryAnnotations(test.pr)); diff != "" {
+				t.Errorf("annotations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+// The statuses a run can be filtered by, derived from its Succeeded
+// condition.
+const (
+	runStatusPending   = "pending"
+	runStatusRunning   = "running"
+	runStatusSucceeded = "succeeded"
+	runStatusFailed    = "failed"
+	runStatusCancelled = "cancelled"
+	runStatusTimedOut  = "timedout"
+)
+
+var runStatuses = []any{
+	runStatusPending, runStatusRunning, runStatusSucceeded,
+	runStatusFailed, runStatusCancelled, runStatusTimedOut,
+}
+
+// runFilterProperties are the params of listParams only available for runs.
+var runFilterProperties = []string{"status", "pipeline", "task", "since", "until", "timeField"}
+
+// runFilterDescriptions documents the run filters of a list tool, ref being
+// the property matching the referenced Pipeline or Task of the runs. The
+// filters are removed from the schema of the kinds that are not runs.
+func runFilterDescriptions(scheme *jsonschema.Schema, kind, ref string) {
+	for _, property := range runFilterProperties {
+		if ref == "" || (property != ref && (property == "pipeline" || property == "task")) {
+			delete(scheme.Properties, property)
+		}
+	}
+	if ref == "" {
+		return
+	}
+	scheme.Properties["status"].Description = fmt.Sprintf(
+		"Comma separated statuses to filter %s by: %v", kind, runStatuses)
+	scheme.Properties[ref].Description = fmt.Sprintf(
+		"Name of the referenced %s to filter %s by, also matching the name param of remote references", ref, kind)
+	scheme.Properties["since"].Description = fmt.Sprintf(
+		"Only list the %s created (or completed, see timeField) after this time, as a duration before now like 2h or a RFC3339 timestamp", kind)
+	scheme.Properties["until"].Description = fmt.Sprintf(
+		"Only list the %s created (or completed, see timeField) before this time, as a duration before now like 30m or a RFC3339 timestamp", kind)
+	scheme.Properties["timeField"].Description = fmt.Sprintf(
+		"Time compared to since and until: %s (default) or %s, runs that did not complete being excluded", sortByCreationTimestamp, sortByCompletionTime)
+	scheme.Properties["timeField"].Enum = []any{sortByCreationTimestamp, sortByCompletionTime}
+}
+
+// runFilter selects the runs matching the run filters of a list tool.
+type runFilter struct {
+	statuses   []string
+	ref        string
+	since      time.Time
+	until      time.Time
+	completion bool
+}
+
+func newRunFilter(args listParams, ref string, now time.Time) (*runFilter, error) {
+	f := &runFilter{ref: ref}
+	for _, status := range strings.Split(args.Status, ",") {
+		status = strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(status))
+		if status == "" {
+			continue
+		}
+		if !slices.Contains(runStatuses, any(status)) {
+			return nil, fmt.Errorf("invalid status %q, expected one of %v", status, runStatuses)
+		}
+		f.statuses = append(f.statuses, status)
+	}
+
+	var err error
+	if f.since, err = parseTimeBound("since", args.Since, now); err != nil {
+		return nil, err
+	}
+	if f.until, err = parseTimeBound("until", args.Until, now); err != nil {
+		return nil, err
+	}
+
+	switch args.TimeField {
+	case "", sortByCreationTimestamp:
+	case sortByCompletionTime:
+		f.completion = true
+	default:
+		return nil, fmt.Errorf("invalid timeField %q, expected %s or %s", args.TimeField, sortByCreationTimestamp, sortByCompletionTime)
+	}
+	return f, nil
+}
+
+// parseTimeBound parses a duration before now or a RFC3339 timestamp.
+func parseTimeBound(field, s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, expected a duration like 2h or a RFC3339 timestamp", field, s)
+	}
+	return t, nil
+}
+
+func (f *runFilter) matches(c *apis.Condition, ref v1.ResolverRef, refName string, created metav1.Time, completion *metav1.Time) bool {
+	if len(f.statuses) > 0 && !slices.Contains(f.statuses, runStatus(c)) {
+		return false
+	}
+	if f.ref != "" && !refMatches(f.ref, refName, ref) {
+		return false
+	}
+	if f.since.IsZero() && f.until.IsZero() {
+		return true
+	}
+
+	t := created.Time
+	if f.completion {
+		if completion == nil {
+			return false
+		}
+		t = completion.Time
+	}
+	if !f.since.IsZero() && t.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && t.After(f.until) {
+		return false
+	}
+	return true
+}
+
+func (f *runFilter) pipelineRuns(prs []*v1.PipelineRun) []*v1.PipelineRun {
+	out := make([]*v1.PipelineRun, 0, len(prs))
+	for _, pr := range prs {
+		var ref v1.PipelineRef
+		if pr.Spec.PipelineRef != nil {
+			ref = *pr.Spec.PipelineRef
+		}
+		if f.matches(pr.Status.GetCondition(apis.ConditionSucceeded), ref.ResolverRef, ref.Name, pr.CreationTimestamp, pr.Status.CompletionTime) {
+			out = append(out, pr)
+		}
+	}
+	return out
+}
+
+func (f *runFilter) taskRuns(trs []*v1.TaskRun) []*v1.TaskRun {
+	out := make([]*v1.TaskRun, 0, len(trs))
+	for _, tr := range trs {
+		var ref v1.TaskRef
+		if tr.Spec.TaskRef != nil {
+			ref = *tr.Spec.TaskRef
+		}
+		if f.matches(tr.Status.GetCondition(apis.ConditionSucceeded), ref.ResolverRef, ref.Name, tr.CreationTimestamp, tr.Status.CompletionTime) {
+			out = append(out, tr)
+		}
+	}
+	return out
+}
+
+// runStatus maps the Succeeded condition of a run to one of the statuses it
+// can be filtered by.
+func runStatus(c *apis.Condition) string {
+	if c == nil {
+		return runStatusPending
+	}
+	switch c.Status {
+	case corev1.ConditionTrue:
+		return runStatusSucceeded
+	case corev1.ConditionFalse:
+		switch c.Reason {
+		case v1.PipelineRunReasonCancelled.String(), v1.TaskRunReasonCancelled.String():
+			return runStatusCancelled
+		case v1.PipelineRunReasonTimedOut.String(), v1.TaskRunReasonTimedOut.String():
+			return runStatusTimedOut
+		}
+		return runStatusFailed
+	}
+	switch c.Reason {
+	case "", "Pending", v1.PipelineRunReasonPending.String(),
+		v1.PipelineRunReasonResolvingPipelineRef.String(), v1.TaskRunReasonResolvingTaskRef:
+		return runStatusPending
+	}
+	return runStatusRunning
+}
+
+// refMatches reports whether a run references the named Pipeline or Task,
+// directly or through the name param of a resolver.
+func refMatches(name, refName string, ref v1.ResolverRef) bool {
+	if refName != "" {
+		return refName == name
+	}
+	for _, p := range ref.Params {
+		if p.Name == "name" && p.Value.StringVal == name {
+			return true
+		}
+	}
+	return false
+}
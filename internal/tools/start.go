@@ -3,35 +3,75 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
-	pipelineinformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/pipeline"
-	taskinformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1/task"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
-type startParams struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
+type startPipelineParams struct {
+	Name               string           `json:"name"`
+	Namespace          string           `json:"namespace"`
+	Params             map[string]any   `json:"params"`
+	Workspaces         []workspaceParam `json:"workspaces"`
+	ServiceAccountName string           `json:"serviceAccountName"`
+	Timeout            string           `json:"timeout"`
+	TasksTimeout       string           `json:"tasksTimeout"`
+	FinallyTimeout     string           `json:"finallyTimeout"`
+	PodTemplate        string           `json:"podTemplate"`
+	TaskRunSpecs       string           `json:"taskRunSpecs"`
+	DryRun             bool             `json:"dryRun"`
+}
+
+type startTaskParams struct {
+	Name               string           `json:"name"`
+	Namespace          string           `json:"namespace"`
+	Params             map[string]any   `json:"params"`
+	Workspaces         []workspaceParam `json:"workspaces"`
+	ServiceAccountName string           `json:"serviceAccountName"`
+	Timeout            string           `json:"timeout"`
+	PodTemplate        string           `json:"podTemplate"`
+	DryRun             bool             `json:"dryRun"`
+}
+
+// runOptionsDescriptions documents the run options shared by the start tools.
+func runOptionsDescriptions(scheme *jsonschema.Schema) {
+	scheme.Properties["params"].Description = "Values of the params, by name. Arrays and objects are passed as JSON arrays and objects"
+	workspacesDescriptions(scheme.Properties["workspaces"])
+	scheme.Properties["serviceAccountName"].Description = "Service account used to run the pods"
+	scheme.Properties["timeout"].Description = "Timeout of the run, as a duration (e.g. 1h30m)"
+	scheme.Properties["podTemplate"].Description = "Pod template of the run, in YAML or JSON"
+	scheme.Properties["dryRun"].Description = dryRunDescription
 }
 
 func startPipeline() (*mcp.ServerTool, error) {
-	scheme, err := jsonschema.For[startParams]()
+	scheme, err := jsonschema.For[startPipelineParams]()
 	if err != nil {
 		return nil, err
 	}
 
-	scheme.Properties["name"].Description = "Name or referece of the pipeline"
+	scheme.Properties["name"].Description = "Name or reference of the pipeline"
 	scheme.Properties["namespace"].Description = "Namespace of the pipeline"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	runOptionsDescriptions(scheme)
+	scheme.Properties["tasksTimeout"].Description = "Timeout of the tasks of the pipeline, as a duration"
+	scheme.Properties["finallyTimeout"].Description = "Timeout of the finally tasks of the pipeline, as a duration"
+	scheme.Properties["taskRunSpecs"].Description = "List of per pipeline task run specifications (pipelineTaskName, serviceAccountName, podTemplate, ...), in YAML or JSON"
+	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
 		"start_pipeline",
-		"Start a Pipeline",
+		"Start a Pipeline, validating the params against the Pipeline spec, and return the name of the created PipelineRun",
 		handlerStartPipeline,
 		mcp.Input(mcp.Schema(scheme)),
 	), nil
@@ -40,15 +80,18 @@ func startPipeline() (*mcp.ServerTool, error) {
 func handlerStartPipeline(
 	ctx context.Context,
 	cc *mcp.ServerSession,
-	params *mcp.CallToolParamsFor[startParams],
+	params *mcp.CallToolParamsFor[startPipelineParams],
 ) (*mcp.CallToolResultFor[string], error) {
 	name := params.Arguments.Name
 	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
 
-	pipelineInformer := pipelineinformer.Get(ctx)
 	pipelineclientset := pipelineclient.Get(ctx)
 
-	if _, err := pipelineInformer.Lister().Pipelines(namespace).Get(name); err != nil {
+	pipeline, err := scope.Pipeline(ctx, namespace, name)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get Pipeline %s/%s: %w", namespace, name, err)
 	}
 
@@ -67,27 +110,77 @@ func handlerStartPipeline(
 			},
 		},
 	}
+	notes, err := setPipelineRunOptions(pr, &pipeline.Spec, params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options to start Pipeline %s/%s: %w", namespace, name, err)
+	}
 
-	if _, err := pipelineclientset.TektonV1().PipelineRuns(namespace).Create(ctx, pr, metav1.CreateOptions{}); err != nil {
+	created, err := pipelineclientset.TektonV1().PipelineRuns(namespace).Create(ctx, pr, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create PipelineRun %s/%s: %w", namespace, name, err)
 	}
 
-	return result(fmt.Sprintf("Starting pipeline %s in namespace %s", name, namespace)), nil
+	if params.Arguments.DryRun {
+		return dryRunResult(withNotes(fmt.Sprintf("Pipeline %s would be started in namespace %s with PipelineRun %s", name, namespace, created.Name), notes), created)
+	}
+	return result(withNotes(fmt.Sprintf("Starting pipeline %s in namespace %s with PipelineRun %s", name, namespace, created.Name), notes)), nil
+}
+
+// setPipelineRunOptions sets the params, workspaces and run options of a
+// PipelineRun. Notes about the unbound optional workspaces are returned.
+func setPipelineRunOptions(pr *v1.PipelineRun, spec *v1.PipelineSpec, args startPipelineParams) ([]string, error) {
+	var errs []error
+
+	runParams, err := buildParams(spec.Params, args.Params)
+	errs = append(errs, err)
+	pr.Spec.Params = runParams
+
+	workspaces, notes, err := bindWorkspaces(pipelineWorkspaces(spec), args.Workspaces)
+	errs = append(errs, err)
+	pr.Spec.Workspaces = workspaces
+
+	pr.Spec.TaskRunTemplate.ServiceAccountName = args.ServiceAccountName
+	if args.PodTemplate != "" {
+		pr.Spec.TaskRunTemplate.PodTemplate = &pod.Template{}
+		if err := yaml.Unmarshal([]byte(args.PodTemplate), pr.Spec.TaskRunTemplate.PodTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("invalid podTemplate: %w", err))
+		}
+	}
+	if args.TaskRunSpecs != "" {
+		if err := yaml.Unmarshal([]byte(args.TaskRunSpecs), &pr.Spec.TaskRunSpecs); err != nil {
+			errs = append(errs, fmt.Errorf("invalid taskRunSpecs: %w", err))
+		}
+	}
+
+	timeouts := &v1.TimeoutFields{}
+	timeouts.Pipeline, err = parseDuration("timeout", args.Timeout)
+	errs = append(errs, err)
+	timeouts.Tasks, err = parseDuration("tasksTimeout", args.TasksTimeout)
+	errs = append(errs, err)
+	timeouts.Finally, err = parseDuration("finallyTimeout", args.FinallyTimeout)
+	errs = append(errs, err)
+	if timeouts.Pipeline != nil || timeouts.Tasks != nil || timeouts.Finally != nil {
+		pr.Spec.Timeouts = timeouts
+	}
+
+	return notes, errors.Join(errs...)
 }
 
 func startTask() (*mcp.ServerTool, error) {
-	scheme, err := jsonschema.For[startParams]()
+	scheme, err := jsonschema.For[startTaskParams]()
 	if err != nil {
 		return nil, err
 	}
 
-	scheme.Properties["name"].Description = "Name or referece of the task"
+	scheme.Properties["name"].Description = "Name or reference of the task"
 	scheme.Properties["namespace"].Description = "Namespace of the task"
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	runOptionsDescriptions(scheme)
+	scheme.Required = []string{"name"}
 
 	return mcp.NewServerTool(
 		"start_task",
-		"Start a Task",
+		"Start a Task, validating the params against the Task spec, and return the name of the created TaskRun",
 		handlerStartTask,
 		mcp.Input(mcp.Schema(scheme)),
 	), nil
@@ -96,7 +189,7 @@ func startTask() (*mcp.ServerTool, error) {
 func handlerStartTask(
 	ctx context.Context,
 	cc *mcp.ServerSession,
-	params *mcp.CallToolParamsFor[startParams],
+	params *mcp.CallToolParamsFor[startTaskParams],
 ) (*mcp.CallToolResultFor[string], error) {
 	name := params.Arguments.Name
 	namespace := params.Arguments.Namespace
@@ -104,14 +197,14 @@ func handlerStartTask(
 		namespace = "default"
 	}
 
-	taskInformer := taskinformer.Get(ctx)
 	pipelineclientset := pipelineclient.Get(ctx)
 
-	if _, err := taskInformer.Lister().Tasks(namespace).Get(name); err != nil {
+	task, err := scope.Task(ctx, namespace, name)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get Task %s/%s: %w", namespace, name, err)
 	}
 
-	pr := &v1.TaskRun{
+	tr := &v1.TaskRun{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "tekton.dev/v1",
 			Kind:       "TaskRun",
@@ -126,10 +219,127 @@ func handlerStartTask(
 			},
 		},
 	}
+	notes, err := setTaskRunOptions(tr, &task.Spec, params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options to start Task %s/%s: %w", namespace, name, err)
+	}
 
-	if _, err := pipelineclientset.TektonV1().TaskRuns(namespace).Create(ctx, pr, metav1.CreateOptions{}); err != nil {
+	created, err := pipelineclientset.TektonV1().TaskRuns(namespace).Create(ctx, tr, metav1.CreateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create TaskRun %s/%s: %w", namespace, name, err)
 	}
 
-	return result(fmt.Sprintf("Starting task %s in namespace %s", name, namespace)), nil
+	if params.Arguments.DryRun {
+		return dryRunResult(withNotes(fmt.Sprintf("Task %s would be started in namespace %s with TaskRun %s", name, namespace, created.Name), notes), created)
+	}
+	return result(withNotes(fmt.Sprintf("Starting task %s in namespace %s with TaskRun %s", name, namespace, created.Name), notes)), nil
+}
+
+// setTaskRunOptions sets the params, workspaces and run options of a TaskRun.
+// Notes about the unbound optional workspaces are returned.
+func setTaskRunOptions(tr *v1.TaskRun, spec *v1.TaskSpec, args startTaskParams) ([]string, error) {
+	var errs []error
+
+	runParams, err := buildParams(spec.Params, args.Params)
+	errs = append(errs, err)
+	tr.Spec.Params = runParams
+
+	workspaces, notes, err := bindWorkspaces(taskWorkspaces(spec), args.Workspaces)
+	errs = append(errs, err)
+	tr.Spec.Workspaces = workspaces
+
+	tr.Spec.ServiceAccountName = args.ServiceAccountName
+	if args.PodTemplate != "" {
+		tr.Spec.PodTemplate = &pod.Template{}
+		if err := yaml.Unmarshal([]byte(args.PodTemplate), tr.Spec.PodTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("invalid podTemplate: %w", err))
+		}
+	}
+
+	tr.Spec.Timeout, err = parseDuration("timeout", args.Timeout)
+	errs = append(errs, err)
+
+	return notes, errors.Join(errs...)
+}
+
+// buildParams converts the supplied values to Tekton params, sorted by name,
+// after checking them against the declared params: every param without a
+// default must be set, unknown params are rejected and the values must match
+// the declared types.
+func buildParams(specs v1.ParamSpecs, values map[string]any) (v1.Params, error) {
+	var errs []error
+	for _, spec := range specs {
+		if _, ok := values[spec.Name]; !ok && spec.Default == nil {
+			errs = append(errs, fmt.Errorf("missing value for required param %q", spec.Name))
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	params := make(v1.Params, 0, len(values))
+	for _, name := range names {
+		value := convertToParamValue(values[name])
+		i := slices.IndexFunc(specs, func(spec v1.ParamSpec) bool { return spec.Name == name })
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("unknown param %q", name))
+			continue
+		}
+		if err := validateParamValue(specs[i], value); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		params = append(params, v1.Param{Name: name, Value: value})
+	}
+	return params, errors.Join(errs...)
+}
+
+// validateParamValue checks that a value matches the type of a param and,
+// for objects, that every declared key is set.
+func validateParamValue(spec v1.ParamSpec, value v1.ParamValue) error {
+	expected := spec.Type
+	if expected == "" {
+		expected = v1.ParamTypeString
+	}
+	if value.Type != expected {
+		return fmt.Errorf("param %q expects a value of type %s, got %s", spec.Name, expected, value.Type)
+	}
+	if expected != v1.ParamTypeObject || spec.Default != nil {
+		return nil
+	}
+
+	var missing []string
+	for key := range spec.Properties {
+		if _, ok := value.ObjectVal[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("param %q is missing the keys %s", spec.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// parseDuration parses an optional duration param.
+func parseDuration(field, s string) (*metav1.Duration, error) {
+	if s == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return &metav1.Duration{Duration: d}, nil
+}
+
+// withNotes appends notes to the message of a tool.
+func withNotes(message string, notes []string) string {
+	if len(notes) == 0 {
+		return message
+	}
+	return message + "\nNote: " + strings.Join(notes, "\nNote: ")
 }
@@ -3,12 +3,18 @@ package tools
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
 	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktesting "k8s.io/client-go/testing"
 )
 
 func TestStart(t *testing.T) {
@@ -69,3 +75,208 @@ func TestStart(t *testing.T) {
 		})
 	}
 }
+
+func TestStartWithOptions(t *testing.T) {
+	data := test.Data{
+		Pipelines: []*v1.Pipeline{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+				Spec: v1.PipelineSpec{
+					Params: v1.ParamSpecs{
+						{Name: "revision", Type: v1.ParamTypeString},
+						{Name: "flags", Type: v1.ParamTypeArray, Default: v1.NewStructuredValues("-v")},
+						{Name: "image", Type: v1.ParamTypeObject, Properties: map[string]v1.PropertySpec{"url": {}, "tag": {}}},
+					},
+					Workspaces: []v1.PipelineWorkspaceDeclaration{
+						{Name: "source"},
+						{Name: "cache", Optional: true},
+					},
+				},
+			},
+		},
+		Tasks: []*v1.Task{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default"},
+				Spec: v1.TaskSpec{
+					Params:     v1.ParamSpecs{{Name: "revision"}},
+					Workspaces: []v1.WorkspaceDeclaration{{Name: "cache"}},
+				},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	// The fake clientset does not generate names
+	generateName := func(action ktesting.Action) (bool, runtime.Object, error) {
+		obj := action.(ktesting.CreateAction).GetObject().(metav1.Object)
+		obj.SetName(obj.GetGenerateName() + "abcde")
+		return false, nil, nil
+	}
+	clients.Pipeline.PrependReactor("create", "pipelineruns", generateName)
+	clients.Pipeline.PrependReactor("create", "taskruns", generateName)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	t.Run("start_pipeline", func(t *testing.T) {
+		response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name: "start_pipeline",
+			Arguments: map[string]any{
+				"name": "release",
+				"params": map[string]any{
+					"revision": "abc123",
+					"image":    map[string]any{"url": "registry/app", "tag": "v1"},
+				},
+				"workspaces":         []any{map[string]any{"name": "source", "persistentVolumeClaim": "source-pvc"}},
+				"serviceAccountName": "builder",
+				"timeout":            "1h",
+				"tasksTimeout":       "50m",
+				"podTemplate":        "nodeSelector:\n  disktype: ssd\n",
+				"taskRunSpecs":       `[{"pipelineTaskName": "push", "serviceAccountName": "pusher"}]`,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := response.Content[0].(*mcp.TextContent)
+		expectedText := "Starting pipeline release in namespace default with PipelineRun release-abcde\nNote: optional workspace \"cache\" is not bound"
+		if diff := cmp.Diff(expectedText, content.Text); diff != "" {
+			t.Errorf("start_pipeline mismatch (-want +got):\n%s", diff)
+		}
+
+		pr, err := clients.Pipeline.TektonV1().PipelineRuns("default").Get(ctx, "release-abcde", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := v1.PipelineRunSpec{
+			PipelineRef: &v1.PipelineRef{Name: "release"},
+			Params: v1.Params{
+				{Name: "image", Value: *v1.NewObject(map[string]string{"url": "registry/app", "tag": "v1"})},
+				{Name: "revision", Value: *v1.NewStructuredValues("abc123")},
+			},
+			Workspaces: []v1.WorkspaceBinding{{
+				Name:                  "source",
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "source-pvc"},
+			}},
+			Timeouts: &v1.TimeoutFields{
+				Pipeline: &metav1.Duration{Duration: time.Hour},
+				Tasks:    &metav1.Duration{Duration: 50 * time.Minute},
+			},
+			TaskRunTemplate: v1.PipelineTaskRunTemplate{
+				ServiceAccountName: "builder",
+				PodTemplate:        &pod.Template{NodeSelector: map[string]string{"disktype": "ssd"}},
+			},
+			TaskRunSpecs: []v1.PipelineTaskRunSpec{{PipelineTaskName: "push", ServiceAccountName: "pusher"}},
+		}
+		if diff := cmp.Diff(expected, pr.Spec); diff != "" {
+			t.Errorf("PipelineRun spec mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("start_task", func(t *testing.T) {
+		response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name: "start_task",
+			Arguments: map[string]any{
+				"name":       "build",
+				"params":     map[string]any{"revision": "abc123"},
+				"workspaces": []any{map[string]any{"name": "cache", "emptyDir": true}},
+				"timeout":    "10m",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := response.Content[0].(*mcp.TextContent)
+		if diff := cmp.Diff("Starting task build in namespace default with TaskRun build-abcde", content.Text); diff != "" {
+			t.Errorf("start_task mismatch (-want +got):\n%s", diff)
+		}
+
+		tr, err := clients.Pipeline.TektonV1().TaskRuns("default").Get(ctx, "build-abcde", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := v1.TaskRunSpec{
+			TaskRef:    &v1.TaskRef{Name: "build"},
+			Params:     v1.Params{{Name: "revision", Value: *v1.NewStructuredValues("abc123")}},
+			Workspaces: []v1.WorkspaceBinding{{Name: "cache", EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			Timeout:    &metav1.Duration{Duration: 10 * time.Minute},
+		}
+		if diff := cmp.Diff(expected, tr.Spec); diff != "" {
+			t.Errorf("TaskRun spec mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	errorTests := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		expected  []string
+	}{
+		{
+			name:      "missing required params",
+			tool:      "start_pipeline",
+			arguments: map[string]any{"name": "release"},
+			expected:  []string{`missing value for required param "revision"`, `missing value for required param "image"`},
+		},
+		{
+			name: "unknown param and type mismatch",
+			tool: "start_pipeline",
+			arguments: map[string]any{"name": "release", "params": map[string]any{
+				"revision": []any{"a", "b"},
+				"flags":    "-v",
+				"image":    map[string]any{"url": "registry/app"},
+				"unknown":  "value",
+			}},
+			expected: []string{
+				`param "revision" expects a value of type string, got array`,
+				`param "flags" expects a value of type array, got string`,
+				`param "image" is missing the keys tag`,
+				`unknown param "unknown"`,
+			},
+		},
+		{
+			name: "missing and unknown workspaces",
+			tool: "start_pipeline",
+			arguments: map[string]any{
+				"name":       "release",
+				"params":     map[string]any{"revision": "abc123", "image": map[string]any{"url": "registry/app", "tag": "v1"}},
+				"workspaces": []any{map[string]any{"name": "output", "emptyDir": true}},
+			},
+			expected: []string{`missing binding for workspace "source"`, `unknown workspace "output"`},
+		},
+		{
+			name: "invalid run options",
+			tool: "start_task",
+			arguments: map[string]any{
+				"name":       "build",
+				"params":     map[string]any{"revision": "abc123"},
+				"workspaces": []any{map[string]any{"name": "cache"}, map[string]any{"name": "source", "emptyDir": true, "secret": "creds"}},
+				"timeout":    "ten minutes",
+			},
+			expected: []string{
+				`workspace "cache" has no volume source`,
+				`workspace "source" has more than one volume source`,
+				"invalid timeout",
+			},
+		},
+	}
+
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+				Name:      test.tool,
+				Arguments: test.arguments,
+			})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	kindTask = "task"
+
+	defaultStatsSince   = "168h"
+	defaultStatsBuckets = 7
+	maxStatsBuckets     = 100
+	// maxStatsEntries is the number of failure reasons and failing tasks
+	// reported
+	maxStatsEntries = 5
+	// statsTrendThreshold is the difference of success rate, in percentage
+	// points, between the two halves of the window for the trend to change
+	statsTrendThreshold = 5.0
+
+	statsTrendImproving = "improving"
+	statsTrendDegrading = "degrading"
+	statsTrendStable    = "stable"
+)
+
+type pipelineStatsParams struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Since     string `json:"since"`
+	Until     string `json:"until"`
+	Buckets   int    `json:"buckets"`
+	Output    string `json:"output"`
+}
+
+// runStats aggregates the runs of a Pipeline or Task created in a time
+// window. The success rate is a percentage of the completed runs that were
+// not cancelled.
+type runStats struct {
+	Kind           string         `json:"kind"`
+	Name           string         `json:"name"`
+	Namespace      string         `json:"namespace"`
+	Since          metav1.Time    `json:"since"`
+	Until          metav1.Time    `json:"until"`
+	Summary        string         `json:"summary"`
+	Runs           int            `json:"runs"`
+	Statuses       map[string]int `json:"statuses"`
+	SuccessRate    *float64       `json:"successRate,omitempty"`
+	Durations      *runDurations  `json:"durations,omitempty"`
+	FailureReasons []reasonCount  `json:"failureReasons,omitempty"`
+	FailingTasks   []taskFailures `json:"failingTasks,omitempty"`
+	// Trend compares the success rate of the two halves of the window
+	Trend   string        `json:"trend,omitempty"`
+	History []statsBucket `json:"history"`
+}
+
+// runDurations are percentiles of the durations of the completed runs.
+type runDurations struct {
+	P50 string `json:"p50"`
+	P90 string `json:"p90"`
+	P99 string `json:"p99"`
+}
+
+type reasonCount struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+	// LastRun is the last run created that failed with this reason
+	LastRun string `json:"lastRun"`
+}
+
+// taskFailures is the number of PipelineRuns in which a pipeline task failed.
+type taskFailures struct {
+	PipelineTask string `json:"pipelineTask"`
+	Failures     int    `json:"failures"`
+}
+
+type statsBucket struct {
+	Start       metav1.Time `json:"start"`
+	Runs        int         `json:"runs"`
+	Succeeded   int         `json:"succeeded"`
+	Failed      int         `json:"failed"`
+	SuccessRate *float64    `json:"successRate,omitempty"`
+	P50         string      `json:"p50,omitempty"`
+}
+
+// runSample is what the stats are computed from, for both PipelineRuns and
+// TaskRuns.
+type runSample struct {
+	name     string
+	created  time.Time
+	status   string
+	reason   string
+	duration time.Duration
+	// failedTasks are the pipeline tasks that failed in a PipelineRun
+	failedTasks []string
+}
+
+func pipelineStats() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[pipelineStatsParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["kind"].Description = "Kind of the object to aggregate the runs of (pipeline or task)"
+	scheme.Properties["kind"].Enum = []any{kindPipeline, kindTask}
+	scheme.Properties["kind"].Default = json.RawMessage(`"pipeline"`)
+	scheme.Properties["name"].Description = "Name of the Pipeline or Task, also matching the name param of remote references"
+	scheme.Properties["namespace"].Description = "Namespace of the runs"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["since"].Description = "Start of the window of the runs, by creation time, as a duration before now like 24h or a RFC3339 timestamp"
+	scheme.Properties["since"].Default = json.RawMessage(`"168h"`)
+	scheme.Properties["until"].Description = "End of the window of the runs, by creation time, as a duration before now like 30m or a RFC3339 timestamp, defaults to now"
+	scheme.Properties["buckets"].Description = fmt.Sprintf("Number of intervals the window is split into for the history (max %d)", maxStatsBuckets)
+	scheme.Properties["buckets"].Default = json.RawMessage(`7`)
+	scheme.Properties["output"].Description = outputFormatDescription
+	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"pipeline_stats",
+		"Aggregate the PipelineRuns of a Pipeline or the TaskRuns of a Task over a time window: success rate, "+
+			"p50/p90/p99 durations, most common failure reasons, most frequently failing pipeline tasks "+
+			"and the history of the success rate, to tell whether it is getting flakier or slower",
+		handlerPipelineStats,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerPipelineStats(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[pipelineStatsParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	kind := strings.ToLower(params.Arguments.Kind)
+	if kind == "" {
+		kind = kindPipeline
+	}
+	since := params.Arguments.Since
+	if since == "" {
+		since = defaultStatsSince
+	}
+	buckets := params.Arguments.Buckets
+	if buckets <= 0 {
+		buckets = defaultStatsBuckets
+	}
+	if buckets > maxStatsBuckets {
+		return nil, fmt.Errorf("invalid buckets %d, expected at most %d", buckets, maxStatsBuckets)
+	}
+
+	now := time.Now()
+	filter, err := newRunFilter(listParams{Since: since, Until: params.Arguments.Until}, name, now)
+	if err != nil {
+		return nil, err
+	}
+	if filter.until.IsZero() {
+		filter.until = now
+	}
+	if !filter.since.Before(filter.until) {
+		return nil, fmt.Errorf("invalid window, since %s is not before until %s", filter.since.Format(time.RFC3339), filter.until.Format(time.RFC3339))
+	}
+
+	var samples []runSample
+	var source scope.Source
+	switch kind {
+	case kindPipeline:
+		var prs []*v1.PipelineRun
+		prs, source, err = scope.PipelineRuns(ctx, namespace, labels.Everything())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PipelineRuns in namespace %s: %w", namespace, err)
+		}
+		for _, pr := range filter.pipelineRuns(prs) {
+			sample := newRunSample(pr.Name, pr.CreationTimestamp, pr.Status.GetCondition(apis.ConditionSucceeded), pr.Status.StartTime, pr.Status.CompletionTime)
+			if sample.status == runStatusFailed || sample.status == runStatusTimedOut {
+				for _, child := range pr.Status.ChildReferences {
+					if child.Kind != taskRunKind || slices.Contains(sample.failedTasks, child.PipelineTaskName) {
+						continue
+					}
+					// TaskRuns may have been pruned, only the remaining
+					// ones are counted
+					tr, err := scope.TaskRun(ctx, namespace, child.Name)
+					if err != nil {
+						continue
+					}
+					if status := runStatus(tr.Status.GetCondition(apis.ConditionSucceeded)); status == runStatusFailed || status == runStatusTimedOut {
+						sample.failedTasks = append(sample.failedTasks, child.PipelineTaskName)
+					}
+				}
+			}
+			samples = append(samples, sample)
+		}
+	case kindTask:
+		var trs []*v1.TaskRun
+		trs, source, err = scope.TaskRuns(ctx, namespace, labels.Everything())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list TaskRuns in namespace %s: %w", namespace, err)
+		}
+		for _, tr := range filter.taskRuns(trs) {
+			samples = append(samples, newRunSample(tr.Name, tr.CreationTimestamp, tr.Status.GetCondition(apis.ConditionSucceeded), tr.Status.StartTime, tr.Status.CompletionTime))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, expected %s or %s", params.Arguments.Kind, kindPipeline, kindTask)
+	}
+
+	stats := newRunStats(kind, name, namespace, samples, filter.since, filter.until, buckets)
+	out, err := marshalOutput(stats, params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
+	res := result(out)
+	if !source.Scope.All() {
+		res.Content = append(res.Content, &mcp.TextContent{Text: source.String()})
+	}
+	return res, nil
+}
+
+func newRunSample(name string, created metav1.Time, c *apis.Condition, start, completion *metav1.Time) runSample {
+	sample := runSample{name: name, created: created.Time, status: runStatus(c)}
+	if c != nil {
+		sample.reason = c.Reason
+	}
+	if completion != nil {
+		if start == nil {
+			start = &created
+		}
+		sample.duration = max(completion.Sub(start.Time), 0)
+	}
+	return sample
+}
+
+// newRunStats aggregates the runs created between since and until, the
+// history splitting the window in buckets of equal length.
+func newRunStats(kind, name, namespace string, samples []runSample, since, until time.Time, buckets int) *runStats {
+	stats := &runStats{
+		Kind:      kind,
+		Name:      name,
+		Namespace: namespace,
+		Since:     metav1.NewTime(since),
+		Until:     metav1.NewTime(until),
+		Runs:      len(samples),
+		Statuses:  map[string]int{},
+		History:   make([]statsBucket, buckets),
+	}
+
+	slices.SortStableFunc(samples, func(a, b runSample) int {
+		return cmp.Or(a.created.Compare(b.created), cmp.Compare(a.name, b.name))
+	})
+
+	width := max(until.Sub(since)/time.Duration(buckets), 1)
+	bucketSamples := make([][]runSample, buckets)
+	for i := range stats.History {
+		stats.History[i].Start = metav1.NewTime(since.Add(time.Duration(i) * width))
+	}
+	reasons := map[string]*reasonCount{}
+	tasks := map[string]int{}
+	for _, s := range samples {
+		stats.Statuses[s.status]++
+		i := min(int(s.created.Sub(since)/width), buckets-1)
+		bucketSamples[i] = append(bucketSamples[i], s)
+		if s.status == runStatusFailed || s.status == runStatusTimedOut {
+			if reasons[s.reason] == nil {
+				reasons[s.reason] = &reasonCount{Reason: s.reason}
+			}
+			reasons[s.reason].Count++
+			reasons[s.reason].LastRun = s.name
+		}
+		for _, task := range s.failedTasks {
+			tasks[task]++
+		}
+	}
+
+	stats.SuccessRate = successRate(samples)
+	if p50, p90, p99, ok := durationPercentiles(samples); ok {
+		stats.Durations = &runDurations{P50: formatDuration(p50), P90: formatDuration(p90), P99: formatDuration(p99)}
+	}
+	for i, b := range bucketSamples {
+		stats.History[i].Runs = len(b)
+		for _, s := range b {
+			switch s.status {
+			case runStatusSucceeded:
+				stats.History[i].Succeeded++
+			case runStatusFailed, runStatusTimedOut:
+				stats.History[i].Failed++
+			}
+		}
+		stats.History[i].SuccessRate = successRate(b)
+		if p50, _, _, ok := durationPercentiles(b); ok {
+			stats.History[i].P50 = formatDuration(p50)
+		}
+	}
+
+	for _, r := range reasons {
+		stats.FailureReasons = append(stats.FailureReasons, *r)
+	}
+	slices.SortFunc(stats.FailureReasons, func(a, b reasonCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Reason, b.Reason))
+	})
+	stats.FailureReasons = stats.FailureReasons[:min(len(stats.FailureReasons), maxStatsEntries)]
+	for task, failures := range tasks {
+		stats.FailingTasks = append(stats.FailingTasks, taskFailures{PipelineTask: task, Failures: failures})
+	}
+	slices.SortFunc(stats.FailingTasks, func(a, b taskFailures) int {
+		return cmp.Or(cmp.Compare(b.Failures, a.Failures), cmp.Compare(a.PipelineTask, b.PipelineTask))
+	})
+	stats.FailingTasks = stats.FailingTasks[:min(len(stats.FailingTasks), maxStatsEntries)]
+
+	// The halves of the window are made of whole buckets, the middle one
+	// being left out when there is an odd number of them
+	var first, second []runSample
+	for i, b := range bucketSamples {
+		switch {
+		case i < buckets/2:
+			first = append(first, b...)
+		case i >= (buckets+1)/2:
+			second = append(second, b...)
+		}
+	}
+	before, after := successRate(first), successRate(second)
+	if before != nil && after != nil {
+		switch {
+		case *after-*before >= statsTrendThreshold:
+			stats.Trend = statsTrendImproving
+		case *before-*after >= statsTrendThreshold:
+			stats.Trend = statsTrendDegrading
+		default:
+			stats.Trend = statsTrendStable
+		}
+	}
+
+	stats.Summary = summarizeRunStats(stats, before, after)
+	return stats
+}
+
+func summarizeRunStats(stats *runStats, before, after *float64) string {
+	runs := "PipelineRuns"
+	if stats.Kind == kindTask {
+		runs = "TaskRuns"
+	}
+	if stats.Runs == 0 {
+		return fmt.Sprintf("No %s of %s %s in namespace %s since %s", runs, stats.Kind, stats.Name, stats.Namespace, stats.Since.Format(time.RFC3339))
+	}
+
+	parts := []string{fmt.Sprintf("%d %s of %s %s since %s", stats.Runs, runs, stats.Kind, stats.Name, stats.Since.Format(time.RFC3339))}
+	if stats.SuccessRate != nil {
+		parts = append(parts, fmt.Sprintf("%.1f%% succeeded", *stats.SuccessRate))
+	}
+	if stats.Durations != nil {
+		parts = append(parts, fmt.Sprintf("p50 %s, p90 %s, p99 %s", stats.Durations.P50, stats.Durations.P90, stats.Durations.P99))
+	}
+	if len(stats.FailureReasons) > 0 {
+		parts = append(parts, fmt.Sprintf("most common failure reason: %s (%d)", stats.FailureReasons[0].Reason, stats.FailureReasons[0].Count))
+	}
+	if len(stats.FailingTasks) > 0 {
+		parts = append(parts, fmt.Sprintf("most failing task: %s (%d)", stats.FailingTasks[0].PipelineTask, stats.FailingTasks[0].Failures))
+	}
+	summary := strings.Join(parts, ", ")
+	if stats.Trend != "" {
+		summary += fmt.Sprintf(". Success rate %s, from %.1f%% to %.1f%% between the two halves of the window", stats.Trend, *before, *after)
+	}
+	return summary
+}
+
+// successRate returns the percentage of the completed runs that succeeded,
+// ignoring the cancelled ones, or nil if none completed.
+func successRate(samples []runSample) *float64 {
+	var succeeded, completed int
+	for _, s := range samples {
+		switch s.status {
+		case runStatusSucceeded:
+			succeeded++
+			completed++
+		case runStatusFailed, runStatusTimedOut:
+			completed++
+		}
+	}
+	if completed == 0 {
+		return nil
+	}
+	rate := math.Round(float64(succeeded)*1000/float64(completed)) / 10
+	return &rate
+}
+
+// durationPercentiles returns the nearest-rank percentiles of the durations
+// of the completed runs, ok being false if none completed.
+func durationPercentiles(samples []runSample) (p50, p90, p99 time.Duration, ok bool) {
+	var durations []time.Duration
+	for _, s := range samples {
+		switch s.status {
+		case runStatusSucceeded, runStatusFailed, runStatusTimedOut, runStatusCancelled:
+			durations = append(durations, s.duration)
+		}
+	}
+	if len(durations) == 0 {
+		return 0, 0, 0, false
+	}
+	slices.Sort(durations)
+	percentile := func(p float64) time.Duration {
+		return durations[max(int(math.Ceil(p*float64(len(durations))))-1, 0)]
+	}
+	return percentile(0.5), percentile(0.9), percentile(0.99), true
+}
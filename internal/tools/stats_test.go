@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestPipelineStats(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(4 * 24 * time.Hour)
+	at := func(day int, hour int, minutes int) *metav1.Time {
+		t := metav1.NewTime(since.Add(time.Duration(day)*24*time.Hour + time.Duration(hour)*time.Hour + time.Duration(minutes)*time.Minute))
+		return &t
+	}
+	pipelineRun := func(name, pipeline string, day, minutes int, status corev1.ConditionStatus, reason string, children ...string) *v1.PipelineRun {
+		pr := &v1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: *at(day, 1, 0)},
+			Spec:       v1.PipelineRunSpec{PipelineRef: &v1.PipelineRef{Name: pipeline}},
+			Status: v1.PipelineRunStatus{
+				Status:                  succeededCondition(status, reason),
+				PipelineRunStatusFields: v1.PipelineRunStatusFields{StartTime: at(day, 1, 0)},
+			},
+		}
+		if status != corev1.ConditionUnknown {
+			pr.Status.CompletionTime = at(day, 1, minutes)
+		}
+		for _, child := range children {
+			pr.Status.ChildReferences = append(pr.Status.ChildReferences, v1.ChildStatusReference{
+				TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: name + "-" + child, PipelineTaskName: child,
+			})
+		}
+		return pr
+	}
+	taskRun := func(name, task string, day int, status corev1.ConditionStatus, reason string) *v1.TaskRun {
+		return &v1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: *at(day, 1, 0)},
+			Spec:       v1.TaskRunSpec{TaskRef: &v1.TaskRef{Name: task}},
+			Status: v1.TaskRunStatus{
+				Status: succeededCondition(status, reason),
+				TaskRunStatusFields: v1.TaskRunStatusFields{
+					StartTime:      at(day, 1, 0),
+					CompletionTime: at(day, 1, 3),
+				},
+			},
+		}
+	}
+
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			pipelineRun("build-1", "build", 0, 10, corev1.ConditionTrue, "Succeeded"),
+			pipelineRun("build-2", "build", 0, 12, corev1.ConditionTrue, "Succeeded"),
+			pipelineRun("build-3", "build", 1, 11, corev1.ConditionTrue, "Succeeded"),
+			pipelineRun("build-4", "build", 1, 5, corev1.ConditionFalse, "Failed", "compile", "test"),
+			pipelineRun("build-5", "build", 2, 6, corev1.ConditionFalse, "Failed", "test"),
+			pipelineRun("build-6", "build", 2, 60, corev1.ConditionFalse, "PipelineRunTimeout", "deploy"),
+			pipelineRun("build-7", "build", 3, 20, corev1.ConditionTrue, "Succeeded"),
+			pipelineRun("build-8", "build", 3, 2, corev1.ConditionFalse, "Cancelled"),
+			pipelineRun("build-9", "build", 3, 0, corev1.ConditionUnknown, "Running"),
+			pipelineRun("build-old", "build", -2, 10, corev1.ConditionFalse, "Failed"),
+			pipelineRun("release-1", "release", 1, 10, corev1.ConditionFalse, "Failed"),
+		},
+		TaskRuns: []*v1.TaskRun{
+			taskRun("build-4-compile", "compile", 1, corev1.ConditionTrue, "Succeeded"),
+			taskRun("build-4-test", "run-tests", 1, corev1.ConditionFalse, "Failed"),
+			taskRun("build-5-test", "run-tests", 2, corev1.ConditionFalse, "Failed"),
+			taskRun("build-6-deploy", "deploy", 2, corev1.ConditionFalse, "TaskRunTimeout"),
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	rate := func(r float64) *float64 { return &r }
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		expected  runStats
+	}{
+		{
+			name:      "pipeline",
+			arguments: map[string]any{"name": "build", "buckets": 4},
+			expected: runStats{
+				Kind:        "pipeline",
+				Name:        "build",
+				Namespace:   "default",
+				Since:       metav1.NewTime(since),
+				Until:       metav1.NewTime(until),
+				Summary:     "9 PipelineRuns of pipeline build since 2025-06-01T00:00:00Z, 57.1% succeeded, p50 10m0s, p90 1h0m0s, p99 1h0m0s, most common failure reason: Failed (2), most failing task: test (2). Success rate degrading, from 75.0% to 33.3% between the two halves of the window",
+				Runs:        9,
+				Statuses:    map[string]int{"succeeded": 4, "failed": 2, "timedout": 1, "cancelled": 1, "running": 1},
+				SuccessRate: rate(57.1),
+				Durations:   &runDurations{P50: "10m0s", P90: "1h0m0s", P99: "1h0m0s"},
+				FailureReasons: []reasonCount{
+					{Reason: "Failed", Count: 2, LastRun: "build-5"},
+					{Reason: "PipelineRunTimeout", Count: 1, LastRun: "build-6"},
+				},
+				FailingTasks: []taskFailures{{PipelineTask: "test", Failures: 2}, {PipelineTask: "deploy", Failures: 1}},
+				Trend:        "degrading",
+				History: []statsBucket{
+					{Start: metav1.NewTime(since), Runs: 2, Succeeded: 2, SuccessRate: rate(100), P50: "10m0s"},
+					{Start: *at(1, 0, 0), Runs: 2, Succeeded: 1, Failed: 1, SuccessRate: rate(50), P50: "5m0s"},
+					{Start: *at(2, 0, 0), Runs: 2, Failed: 2, SuccessRate: rate(0), P50: "6m0s"},
+					{Start: *at(3, 0, 0), Runs: 3, Succeeded: 1, SuccessRate: rate(100), P50: "2m0s"},
+				},
+			},
+		},
+		{
+			name:      "task",
+			arguments: map[string]any{"kind": "task", "name": "run-tests", "buckets": 2},
+			expected: runStats{
+				Kind:        "task",
+				Name:        "run-tests",
+				Namespace:   "default",
+				Since:       metav1.NewTime(since),
+				Until:       metav1.NewTime(until),
+				Summary:     "2 TaskRuns of task run-tests since 2025-06-01T00:00:00Z, 0.0% succeeded, p50 3m0s, p90 3m0s, p99 3m0s, most common failure reason: Failed (2). Success rate stable, from 0.0% to 0.0% between the two halves of the window",
+				Runs:        2,
+				Statuses:    map[string]int{"failed": 2},
+				SuccessRate: rate(0),
+				Durations:   &runDurations{P50: "3m0s", P90: "3m0s", P99: "3m0s"},
+				FailureReasons: []reasonCount{
+					{Reason: "Failed", Count: 2, LastRun: "build-5-test"},
+				},
+				Trend: "stable",
+				History: []statsBucket{
+					{Start: metav1.NewTime(since), Runs: 1, Failed: 1, SuccessRate: rate(0), P50: "3m0s"},
+					{Start: *at(2, 0, 0), Runs: 1, Failed: 1, SuccessRate: rate(0), P50: "3m0s"},
+				},
+			},
+		},
+		{
+			name:      "no runs",
+			arguments: map[string]any{"name": "missing", "buckets": 1},
+			expected: runStats{
+				Kind:      "pipeline",
+				Name:      "missing",
+				Namespace: "default",
+				Since:     metav1.NewTime(since),
+				Until:     metav1.NewTime(until),
+				Summary:   "No PipelineRuns of pipeline missing in namespace default since 2025-06-01T00:00:00Z",
+				Statuses:  map[string]int{},
+				History:   []statsBucket{{Start: metav1.NewTime(since)}},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.arguments["since"] = since.Format(time.RFC3339)
+			tc.arguments["until"] = until.Format(time.RFC3339)
+			tc.arguments["output"] = "json"
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "pipeline_stats", Arguments: tc.arguments})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got runStats
+			if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("stats mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "pipeline_stats",
+		Arguments: map[string]any{"name": "build", "since": "1h", "until": "2h"},
+	}); err == nil {
+		t.Error("expected an error for a window ending before it starts")
+	}
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	v1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+// The summaries are the compact projections returned by the list tools.
+
+type pipelineRunSummary struct {
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace"`
+	Pipeline  string       `json:"pipeline,omitempty"`
+	Status    string       `json:"status"`
+	Reason    string       `json:"reason"`
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	Duration  string       `json:"duration,omitempty"`
+	TaskRuns  int          `json:"taskRuns"`
+}
+
+type taskRunSummary struct {
+	Name        string       `json:"name"`
+	Namespace   string       `json:"namespace"`
+	Task        string       `json:"task,omitempty"`
+	PipelineRun string       `json:"pipelineRun,omitempty"`
+	Status      string       `json:"status"`
+	Reason      string       `json:"reason"`
+	StartTime   *metav1.Time `json:"startTime,omitempty"`
+	Duration    string       `json:"duration,omitempty"`
+	Steps       int          `json:"steps"`
+}
+
+type pipelineSummary struct {
+	Name              string      `json:"name"`
+	Namespace         string      `json:"namespace"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+	Params            []string    `json:"params,omitempty"`
+	Tasks             int         `json:"tasks"`
+	Finally           int         `json:"finally,omitempty"`
+}
+
+type taskSummary struct {
+	Name              string      `json:"name"`
+	Namespace         string      `json:"namespace"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+	Params            []string    `json:"params,omitempty"`
+	Steps             int         `json:"steps"`
+}
+
+type stepActionSummary struct {
+	Name              string      `json:"name"`
+	Namespace         string      `json:"namespace"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+	Image             string      `json:"image,omitempty"`
+}
+
+func summarizePipelineRun(pr *v1.PipelineRun) any {
+	status, reason := succeededStatus(pr.Status.GetCondition(apis.ConditionSucceeded))
+	s := pipelineRunSummary{
+		Name:      pr.Name,
+		Namespace: pr.Namespace,
+		Status:    status,
+		Reason:    reason,
+		StartTime: pr.Status.StartTime,
+		TaskRuns:  len(pr.Status.ChildReferences),
+	}
+	switch {
+	case pr.Spec.PipelineRef != nil:
+		s.Pipeline = describeRef(pr.Spec.PipelineRef.Name, pr.Spec.PipelineRef.ResolverRef)
+	case pr.Spec.PipelineSpec != nil:
+		s.Pipeline = "(embedded)"
+	}
+	if pr.Status.StartTime != nil {
+		s.Duration = runDuration(pr.Status.StartTime, pr.Status.CompletionTime)
+	}
+	return s
+}
+
+func summarizeTaskRun(tr *v1.TaskRun) any {
+	status, reason := succeededStatus(tr.Status.GetCondition(apis.ConditionSucceeded))
+	s := taskRunSummary{
+		Name:        tr.Name,
+		Namespace:   tr.Namespace,
+		PipelineRun: tr.Labels[pipeline.PipelineRunLabelKey],
+		Status:      status,
+		Reason:      reason,
+		StartTime:   tr.Status.StartTime,
+		Steps:       len(tr.Status.Steps),
+	}
+	switch {
+	case tr.Spec.TaskRef != nil:
+		s.Task = describeRef(tr.Spec.TaskRef.Name, tr.Spec.TaskRef.ResolverRef)
+	case tr.Spec.TaskSpec != nil:
+		s.Task = "(embedded)"
+	}
+	if tr.Status.TaskSpec != nil {
+		s.Steps = max(s.Steps, len(tr.Status.TaskSpec.Steps))
+	}
+	if tr.Status.StartTime != nil {
+		s.Duration = runDuration(tr.Status.StartTime, tr.Status.CompletionTime)
+	}
+	return s
+}
+
+func summarizePipeline(p *v1.Pipeline) any {
+	return pipelineSummary{
+		Name:              p.Name,
+		Namespace:         p.Namespace,
+		CreationTimestamp: p.CreationTimestamp,
+		Params:            paramNames(p.Spec.Params),
+		Tasks:             len(p.Spec.Tasks),
+		Finally:           len(p.Spec.Finally),
+	}
+}
+
+func summarizeTask(t *v1.Task) any {
+	return taskSummary{
+		Name:              t.Name,
+		Namespace:         t.Namespace,
+		CreationTimestamp: t.CreationTimestamp,
+		Params:            paramNames(t.Spec.Params),
+		Steps:             len(t.Spec.Steps),
+	}
+}
+
+func summarizeStepAction(sa *v1beta1.StepAction) any {
+	return stepActionSummary{
+		Name:              sa.Name,
+		Namespace:         sa.Namespace,
+		CreationTimestamp: sa.CreationTimestamp,
+		Image:             sa.Spec.Image,
+	}
+}
+
+// succeededStatus returns the status and reason of the Succeeded condition
+// of a run, which is Unknown and Pending until the run is picked up.
+func succeededStatus(c *apis.Condition) (string, string) {
+	if c == nil {
+		return "Unknown", "Pending"
+	}
+	return string(c.Status), conditionReason(c)
+}
+
+// describeRef returns the name of a referenced Pipeline or Task, prefixed by
+// the resolver for remote references.
+func describeRef(name string, ref v1.ResolverRef) string {
+	if name != "" || ref.Resolver == "" {
+		return name
+	}
+	for _, param := range []string{"name", "pathInRepo", "bundle", "url"} {
+		for _, rp := range ref.Params {
+			if rp.Name == param && rp.Value.StringVal != "" {
+				return fmt.Sprintf("%s:%s", ref.Resolver, rp.Value.StringVal)
+			}
+		}
+	}
+	return string(ref.Resolver)
+}
+
+func paramNames(specs v1.ParamSpecs) []string {
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	return names
+}
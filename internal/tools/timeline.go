@@ -0,0 +1,339 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	"github.com/tektoncd/mcp-server/internal/trace"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+type pipelineRunTimelineParams struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Output    string `json:"output"`
+}
+
+// timelineReport is the timeline of the TaskRuns of a PipelineRun. The
+// offsets are relative to the start of the PipelineRun.
+type timelineReport struct {
+	Name           string            `json:"name"`
+	Namespace      string            `json:"namespace"`
+	Status         string            `json:"status"`
+	StartTime      *metav1.Time      `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time      `json:"completionTime,omitempty"`
+	Duration       string            `json:"duration"`
+	Summary        string            `json:"summary"`
+	CriticalPath   *criticalPath     `json:"criticalPath,omitempty"`
+	TaskRuns       []taskRunTimeline `json:"taskRuns"`
+}
+
+// criticalPath is the chain of pipeline tasks that determined the duration of
+// a PipelineRun. Its duration is split between the time the tasks were queued,
+// executed, and waiting to be started after their dependencies completed.
+type criticalPath struct {
+	Tasks     []string `json:"tasks"`
+	Queue     string   `json:"queue"`
+	Execution string   `json:"execution"`
+	Waiting   string   `json:"waiting"`
+}
+
+type taskRunTimeline struct {
+	PipelineTask string `json:"pipelineTask"`
+	TaskRun      string `json:"taskRun"`
+	Finally      bool   `json:"finally,omitempty"`
+	Status       string `json:"status"`
+	Offset       string `json:"offset"`
+	Duration     string `json:"duration"`
+	// Queue is the time between the start of the TaskRun and the start of its
+	// first step: pod scheduling, image pulls and init containers
+	Queue     string         `json:"queue"`
+	Execution string         `json:"execution"`
+	Critical  bool           `json:"critical,omitempty"`
+	Steps     []stepTimeline `json:"steps,omitempty"`
+}
+
+type stepTimeline struct {
+	Name     string `json:"name"`
+	Offset   string `json:"offset"`
+	Duration string `json:"duration"`
+}
+
+// taskSpan holds the times of a TaskRun, or of the TaskRuns of a matrixed
+// pipeline task, the end being now for the ones still running.
+type taskSpan struct {
+	start, end       time.Time
+	queue, execution time.Duration
+}
+
+func pipelineRunTimeline() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[pipelineRunTimelineParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["name"].Description = "Name of the PipelineRun"
+	scheme.Properties["namespace"].Description = "Namespace of the PipelineRun"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["output"].Description = outputFormatDescription
+	scheme.Properties["output"].Default = json.RawMessage(`"yaml"`)
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"pipelinerun_timeline",
+		"Get the timeline of the TaskRuns and steps of a PipelineRun, with the time each TaskRun was queued (pod scheduling) "+
+			"versus executing, and the critical path of tasks that determined the duration of the PipelineRun",
+		handlerPipelineRunTimeline,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerPipelineRunTimeline(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[pipelineRunTimelineParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	pr, err := scope.PipelineRun(ctx, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
+	}
+	if pr.Status.StartTime == nil {
+		return nil, fmt.Errorf("PipelineRun %s/%s has not started yet", namespace, name)
+	}
+
+	var taskRuns []*v1.TaskRun
+	for _, child := range pr.Status.ChildReferences {
+		if child.Kind != taskRunKind {
+			continue
+		}
+		// TaskRuns may have been pruned, the timeline is built from the
+		// remaining ones
+		if tr, err := scope.TaskRun(ctx, namespace, child.Name); err == nil {
+			taskRuns = append(taskRuns, tr)
+		}
+	}
+	spec, _ := pipelineSpecFor(ctx, pr)
+
+	timeline := newPipelineRunTimeline(pr, spec, taskRuns, time.Now())
+	out, err := marshalOutput(timeline, params.Arguments.Output)
+	if err != nil {
+		return nil, err
+	}
+	return result(out), nil
+}
+
+// newPipelineRunTimeline builds the timeline of a started PipelineRun. The
+// critical path is only computed when the pipeline spec is known.
+func newPipelineRunTimeline(pr *v1.PipelineRun, spec *v1.PipelineSpec, taskRuns []*v1.TaskRun, now time.Time) *timelineReport {
+	start := pr.Status.StartTime.Time
+	end := now
+	if pr.Status.CompletionTime != nil {
+		end = pr.Status.CompletionTime.Time
+	}
+	timeline := &timelineReport{
+		Name:           pr.Name,
+		Namespace:      pr.Namespace,
+		Status:         runStatus(pr.Status.GetCondition(apis.ConditionSucceeded)),
+		StartTime:      pr.Status.StartTime,
+		CompletionTime: pr.Status.CompletionTime,
+		Duration:       formatDuration(end.Sub(start)),
+		TaskRuns:       []taskRunTimeline{},
+	}
+
+	finally := map[string]bool{}
+	if spec != nil {
+		for _, pt := range spec.Finally {
+			finally[pt.Name] = true
+		}
+	}
+
+	pipelineTasks := map[string]string{}
+	for _, child := range pr.Status.ChildReferences {
+		pipelineTasks[child.Name] = child.PipelineTaskName
+	}
+
+	// The span of a matrixed pipeline task goes from the start of its first
+	// TaskRun to the end of the last one, whose queue and execution times are
+	// kept
+	tasks := map[string]taskSpan{}
+	spans := map[string]taskSpan{}
+	for _, tr := range taskRuns {
+		pipelineTask := pipelineTasks[tr.Name]
+		span := newTaskSpan(tr, now)
+		spans[tr.Name] = span
+		merged := span
+		if current, ok := tasks[pipelineTask]; ok {
+			if current.end.After(span.end) {
+				merged = current
+			}
+			merged.start = minTime(current.start, span.start)
+		}
+		tasks[pipelineTask] = merged
+
+		entry := taskRunTimeline{
+			PipelineTask: pipelineTask,
+			TaskRun:      tr.Name,
+			Finally:      finally[pipelineTask],
+			Status:       runStatus(tr.Status.GetCondition(apis.ConditionSucceeded)),
+			Offset:       formatDuration(span.start.Sub(start)),
+			Duration:     formatDuration(span.end.Sub(span.start)),
+			Queue:        formatDuration(span.queue),
+			Execution:    formatDuration(span.execution),
+		}
+		for _, step := range tr.Status.Steps {
+			stepStart, stepEnd := trace.StepTimes(step, now)
+			if stepStart.IsZero() {
+				continue
+			}
+			entry.Steps = append(entry.Steps, stepTimeline{
+				Name:     step.Name,
+				Offset:   formatDuration(stepStart.Sub(start)),
+				Duration: formatDuration(stepEnd.Sub(stepStart)),
+			})
+		}
+		timeline.TaskRuns = append(timeline.TaskRuns, entry)
+	}
+	slices.SortStableFunc(timeline.TaskRuns, func(a, b taskRunTimeline) int {
+		return cmp.Or(spans[a.TaskRun].start.Compare(spans[b.TaskRun].start), cmp.Compare(a.TaskRun, b.TaskRun))
+	})
+
+	var path []string
+	if spec != nil {
+		path = criticalPathTasks(spec, tasks)
+	}
+	if len(path) == 0 {
+		timeline.Summary = fmt.Sprintf("PipelineRun %s %s %s", pr.Name, describeRunStatus(timeline.Status), timeline.Duration)
+		return timeline
+	}
+
+	cp := &criticalPath{Tasks: path}
+	var queue, execution, waiting time.Duration
+	previousEnd := start
+	longest := path[0]
+	for _, name := range path {
+		span := tasks[name]
+		queue += span.queue
+		execution += span.execution
+		waiting += max(span.start.Sub(previousEnd), 0)
+		previousEnd = span.end
+		if span.end.Sub(span.start) > tasks[longest].end.Sub(tasks[longest].start) {
+			longest = name
+		}
+	}
+	cp.Queue, cp.Execution, cp.Waiting = formatDuration(queue), formatDuration(execution), formatDuration(waiting)
+	timeline.CriticalPath = cp
+	for i := range timeline.TaskRuns {
+		timeline.TaskRuns[i].Critical = slices.Contains(path, timeline.TaskRuns[i].PipelineTask)
+	}
+
+	span := tasks[longest]
+	timeline.Summary = fmt.Sprintf(
+		"PipelineRun %s %s %s. Critical path: %s (%s executing, %s queued, %s waiting). "+
+			"Longest task on the critical path: %s, %s of which %s queued",
+		pr.Name, describeRunStatus(timeline.Status), timeline.Duration,
+		strings.Join(path, " -> "), cp.Execution, cp.Queue, cp.Waiting,
+		longest, formatDuration(span.end.Sub(span.start)), formatDuration(span.queue))
+	return timeline
+}
+
+// newTaskSpan returns the times of a TaskRun. The queue time lasts until the
+// first step starts, the whole TaskRun being queued if none did.
+func newTaskSpan(tr *v1.TaskRun, now time.Time) taskSpan {
+	span := taskSpan{start: tr.CreationTimestamp.Time, end: now}
+	if tr.Status.StartTime != nil {
+		span.start = tr.Status.StartTime.Time
+	}
+	if tr.Status.CompletionTime != nil {
+		span.end = tr.Status.CompletionTime.Time
+	}
+
+	var firstStep time.Time
+	for _, step := range tr.Status.Steps {
+		if stepStart, _ := trace.StepTimes(step, now); !stepStart.IsZero() && (firstStep.IsZero() || stepStart.Before(firstStep)) {
+			firstStep = stepStart
+		}
+	}
+	if firstStep.IsZero() {
+		span.queue = span.end.Sub(span.start)
+		return span
+	}
+	span.queue = max(firstStep.Sub(span.start), 0)
+	span.execution = max(span.end.Sub(firstStep), 0)
+	return span
+}
+
+// criticalPathTasks walks the DAG back from the last task to complete, going
+// each time through the dependency that completed last, as it is the one that
+// delayed the start of the task. The finally task that completed last ends the
+// path, finally tasks starting after all the other tasks.
+func criticalPathTasks(spec *v1.PipelineSpec, tasks map[string]taskSpan) []string {
+	last := func(names []string) string {
+		var found string
+		for _, name := range names {
+			span, ok := tasks[name]
+			if ok && (found == "" || span.end.After(tasks[found].end)) {
+				found = name
+			}
+		}
+		return found
+	}
+
+	names := make([]string, 0, len(spec.Tasks))
+	for _, pt := range spec.Tasks {
+		names = append(names, pt.Name)
+	}
+	deps := v1.PipelineTaskList(spec.Tasks).Deps()
+
+	var path []string
+	for current := last(names); current != "" && !slices.Contains(path, current); current = last(deps[current]) {
+		path = append(path, current)
+	}
+	slices.Reverse(path)
+
+	finallyNames := make([]string, 0, len(spec.Finally))
+	for _, pt := range spec.Finally {
+		finallyNames = append(finallyNames, pt.Name)
+	}
+	if f := last(finallyNames); f != "" {
+		path = append(path, f)
+	}
+	return path
+}
+
+// describeRunStatus describes the status of a run, followed by its duration.
+func describeRunStatus(status string) string {
+	switch status {
+	case runStatusRunning, runStatusPending:
+		return "has been " + status + " for"
+	case runStatusTimedOut:
+		return "timed out in"
+	}
+	return status + " in"
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.IsZero() || (!a.IsZero() && a.Before(b)) {
+		return a
+	}
+	return b
+}
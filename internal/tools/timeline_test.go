@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestPipelineRunTimeline(t *testing.T) {
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) *metav1.Time {
+		t := metav1.NewTime(base.Add(time.Duration(seconds) * time.Second))
+		return &t
+	}
+	step := func(name string, start, end int) v1.StepState {
+		return v1.StepState{Name: name, ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			StartedAt:  *at(start),
+			FinishedAt: *at(end),
+		}}}
+	}
+	taskRun := func(name string, start, end int, steps ...v1.StepState) *v1.TaskRun {
+		return &v1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "release-" + name, Namespace: "default"},
+			Status: v1.TaskRunStatus{
+				Status: succeededCondition(corev1.ConditionTrue, "Succeeded"),
+				TaskRunStatusFields: v1.TaskRunStatusFields{
+					StartTime:      at(start),
+					CompletionTime: at(end),
+					Steps:          steps,
+				},
+			},
+		}
+	}
+	child := func(name string) v1.ChildStatusReference {
+		return v1.ChildStatusReference{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-" + name, PipelineTaskName: name}
+	}
+
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+				Status: v1.PipelineRunStatus{
+					Status: succeededCondition(corev1.ConditionTrue, "Succeeded"),
+					PipelineRunStatusFields: v1.PipelineRunStatusFields{
+						StartTime:      at(0),
+						CompletionTime: at(905),
+						PipelineSpec: &v1.PipelineSpec{
+							Tasks: []v1.PipelineTask{
+								{Name: "fetch"},
+								{Name: "build", RunAfter: []string{"fetch"}},
+								{Name: "test", RunAfter: []string{"fetch"}},
+								{Name: "deploy", RunAfter: []string{"build", "test"}},
+							},
+							Finally: []v1.PipelineTask{{Name: "notify"}},
+						},
+						ChildReferences: []v1.ChildStatusReference{
+							child("fetch"), child("build"), child("test"), child("deploy"), child("notify"),
+						},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "queued", Namespace: "default"},
+			},
+		},
+		TaskRuns: []*v1.TaskRun{
+			taskRun("fetch", 5, 125, step("clone", 35, 100), step("checksum", 100, 124)),
+			taskRun("build", 130, 730, step("compile", 190, 729)),
+			taskRun("test", 130, 310, step("unit", 140, 309)),
+			taskRun("deploy", 740, 860, step("apply", 750, 859)),
+			taskRun("notify", 865, 900, step("send", 875, 899)),
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "pipelinerun_timeline",
+		Arguments: map[string]any{"name": "release", "output": "json"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got timelineReport
+	if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+		t.Fatalf("failed to unmarshal the timeline: %v", err)
+	}
+
+	expected := timelineReport{
+		Name:           "release",
+		Namespace:      "default",
+		Status:         "succeeded",
+		StartTime:      at(0),
+		CompletionTime: at(905),
+		Duration:       "15m5s",
+		Summary: "PipelineRun release succeeded in 15m5s. Critical path: fetch -> build -> deploy -> notify " +
+			"(12m45s executing, 1m50s queued, 25s waiting). Longest task on the critical path: build, 10m0s of which 1m0s queued",
+		CriticalPath: &criticalPath{
+			Tasks:     []string{"fetch", "build", "deploy", "notify"},
+			Queue:     "1m50s",
+			Execution: "12m45s",
+			Waiting:   "25s",
+		},
+		TaskRuns: []taskRunTimeline{
+			{
+				PipelineTask: "fetch", TaskRun: "release-fetch", Status: "succeeded", Critical: true,
+				Offset: "5s", Duration: "2m0s", Queue: "30s", Execution: "1m30s",
+				Steps: []stepTimeline{{Name: "clone", Offset: "35s", Duration: "1m5s"}, {Name: "checksum", Offset: "1m40s", Duration: "24s"}},
+			},
+			{
+				PipelineTask: "build", TaskRun: "release-build", Status: "succeeded", Critical: true,
+				Offset: "2m10s", Duration: "10m0s", Queue: "1m0s", Execution: "9m0s",
+				Steps: []stepTimeline{{Name: "compile", Offset: "3m10s", Duration: "8m59s"}},
+			},
+			{
+				PipelineTask: "test", TaskRun: "release-test", Status: "succeeded",
+				Offset: "2m10s", Duration: "3m0s", Queue: "10s", Execution: "2m50s",
+				Steps: []stepTimeline{{Name: "unit", Offset: "2m20s", Duration: "2m49s"}},
+			},
+			{
+				PipelineTask: "deploy", TaskRun: "release-deploy", Status: "succeeded", Critical: true,
+				Offset: "12m20s", Duration: "2m0s", Queue: "10s", Execution: "1m50s",
+				Steps: []stepTimeline{{Name: "apply", Offset: "12m30s", Duration: "1m49s"}},
+			},
+			{
+				PipelineTask: "notify", TaskRun: "release-notify", Finally: true, Status: "succeeded", Critical: true,
+				Offset: "14m25s", Duration: "35s", Queue: "10s", Execution: "25s",
+				Steps: []stepTimeline{{Name: "send", Offset: "14m35s", Duration: "24s"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("timeline mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: "pipelinerun_timeline", Arguments: map[string]any{"name": "queued"}})
+	if err == nil {
+		t.Error("expected an error for a PipelineRun that did not start")
+	}
+}
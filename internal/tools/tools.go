@@ -2,8 +2,11 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"slices"
 
 	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
 )
 
 const defaultNamespace = "default"
@@ -28,12 +31,90 @@ func Add(_ context.Context, s *mcp.Server) error {
 	if err != nil {
 		return err
 	}
+	retryFailedPipelineRunTool, err := retryFailedPipelineRun()
+	if err != nil {
+		return err
+	}
+
+	// Cancel tools
+	cancelPipelineRunTool, err := cancelPipelineRun()
+	if err != nil {
+		return err
+	}
+	cancelTaskRunTool, err := cancelTaskRun()
+	if err != nil {
+		return err
+	}
+	cancelAllPipelineRunsTool, err := cancelAllPipelineRuns()
+	if err != nil {
+		return err
+	}
+
+	// Wait tools
+	waitForRunTool, err := waitForRun()
+	if err != nil {
+		return err
+	}
+
+	// List tools
+	listPipelineRunsTool, err := listPipelineRuns()
+	if err != nil {
+		return err
+	}
+	listPipelinesTool, err := listPipelines()
+	if err != nil {
+		return err
+	}
+	listTaskRunsTool, err := listTaskRuns()
+	if err != nil {
+		return err
+	}
+	listTasksTool, err := listTasks()
+	if err != nil {
+		return err
+	}
+	listStepactionsTool, err := listStepactions()
+	if err != nil {
+		return err
+	}
 
 	// Log tools
 	getTaskRunLogsTool, err := getTaskRunLogs()
 	if err != nil {
 		return err
 	}
+	getPipelineRunLogsTool, err := getPipelineRunLogs()
+	if err != nil {
+		return err
+	}
+
+	// Diagnosis tools
+	diagnoseRunTool, err := diagnoseRun()
+	if err != nil {
+		return err
+	}
+	renderPipelineGraphTool, err := renderPipelineGraph()
+	if err != nil {
+		return err
+	}
+	pipelineRunTimelineTool, err := pipelineRunTimeline()
+	if err != nil {
+		return err
+	}
+	getPipelineRunTraceTool, err := getPipelineRunTrace()
+	if err != nil {
+		return err
+	}
+
+	// Analytics tools
+	pipelineStatsTool, err := pipelineStats()
+	if err != nil {
+		return err
+	}
+	diffRunsTool, err := diffRuns()
+	if err != nil {
+		return err
+	}
 
 	// Create tools
 	createPipelineTool, err := createPipeline()
@@ -106,6 +187,10 @@ func Add(_ context.Context, s *mcp.Server) error {
 	if err != nil {
 		return err
 	}
+	getRunResultsTool, err := getRunResults()
+	if err != nil {
+		return err
+	}
 
 	// Artifact Hub tools
 	listArtifactHubTasksTool := listArtifactHubTasks()
@@ -121,18 +206,30 @@ func Add(_ context.Context, s *mcp.Server) error {
 	triggerArtifactHubTaskTool := triggerArtifactHubTask()
 	triggerArtifactHubPipelineTool := triggerArtifactHubPipeline()
 
-	s.AddTools(
+	tools := []*mcp.ServerTool{
 		// Existing tools
 		startPipelineTool,
 		startTaskTool,
 		restartPipelineRunTool,
 		restartTaskRunTool,
+		retryFailedPipelineRunTool,
+		cancelPipelineRunTool,
+		cancelTaskRunTool,
+		cancelAllPipelineRunsTool,
+		waitForRunTool,
 		getTaskRunLogsTool,
-		listPipelineRuns(),
-		listPipelines(),
-		listTaskRuns(),
-		listTasks(),
-		listStepactions(),
+		getPipelineRunLogsTool,
+		diagnoseRunTool,
+		renderPipelineGraphTool,
+		pipelineRunTimelineTool,
+		getPipelineRunTraceTool,
+		pipelineStatsTool,
+		diffRunsTool,
+		listPipelineRunsTool,
+		listPipelinesTool,
+		listTaskRunsTool,
+		listTasksTool,
+		listStepactionsTool,
 
 		// Create operations
 		createPipelineTool,
@@ -145,6 +242,7 @@ func Add(_ context.Context, s *mcp.Server) error {
 		getTaskTool,
 		getPipelineRunTool,
 		getTaskRunTool,
+		getRunResultsTool,
 
 		// Update operations
 		updatePipelineTool,
@@ -165,10 +263,59 @@ func Add(_ context.Context, s *mcp.Server) error {
 		installArtifactHubPipelineTool,
 		triggerArtifactHubTaskTool,
 		triggerArtifactHubPipelineTool,
-	)
+	}
+	s.AddTools(tools...)
+
+	// In the list tools, an empty namespace stands for all the namespaces
+	s.AddReceivingMiddleware(namespaceMiddleware(tools,
+		listPipelineRunsTool, listPipelinesTool, listTaskRunsTool, listTasksTool, listStepactionsTool))
+	// Followed log lines are dropped without a progress token or a logging level
+	s.AddReceivingMiddleware(followMiddleware(getTaskRunLogsTool, getPipelineRunLogsTool))
 	return nil
 }
 
+// namespaceMiddleware rejects the calls of tools on a namespace outside of
+// the namespaces the server is restricted to, before their arguments are
+// decoded. An empty namespace is the default namespace, except for the tools
+// listing all namespaces.
+func namespaceMiddleware(tools []*mcp.ServerTool, allNamespaces ...*mcp.ServerTool) mcp.Middleware[*mcp.ServerSession] {
+	namespaced := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		if _, ok := t.Tool.InputSchema.Properties["namespace"]; ok {
+			namespaced[t.Tool.Name] = !slices.Contains(allNamespaces, t)
+		}
+	}
+
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, ss *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			p, ok := params.(*mcp.CallToolParamsFor[json.RawMessage])
+			if !ok {
+				return next(ctx, ss, method, params)
+			}
+			defaultsToNamespace, ok := namespaced[p.Name]
+			if !ok {
+				return next(ctx, ss, method, params)
+			}
+
+			var args struct {
+				Namespace string `json:"namespace"`
+			}
+			// Invalid arguments are reported by the tool
+			_ = json.Unmarshal(p.Arguments, &args)
+			if args.Namespace == "" && !defaultsToNamespace {
+				return next(ctx, ss, method, params)
+			}
+			if args.Namespace == "" {
+				args.Namespace = defaultNamespace
+			}
+			if err := scope.Get(ctx).Check(args.Namespace); err != nil {
+				return nil, err
+			}
+			return next(ctx, ss, method, params)
+		}
+	}
+}
+
 func result(s string) *mcp.CallToolResultFor[string] {
 	return &mcp.CallToolResultFor[string]{
 		Content: []mcp.Content{&mcp.TextContent{Text: s}},
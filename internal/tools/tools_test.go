@@ -2,15 +2,28 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/tektoncd/mcp-server/internal/resources"
+	"github.com/tektoncd/mcp-server/internal/scope"
 	"github.com/tektoncd/mcp-server/internal/version"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func newSession(t *testing.T, ctx context.Context) (*mcp.ServerSession, *mcp.ClientSession) {
 	t.Helper()
+	return newSessionWithClientOptions(t, ctx, nil)
+}
+
+func newSessionWithClientOptions(t *testing.T, ctx context.Context, opts *mcp.ClientOptions) (*mcp.ServerSession, *mcp.ClientSession) {
+	t.Helper()
 
 	ct, st := mcp.NewInMemoryTransports()
 	s := mcp.NewServer("Tekton", version.Version, nil)
@@ -18,7 +31,7 @@ func newSession(t *testing.T, ctx context.Context) (*mcp.ServerSession, *mcp.Cli
 		t.Fatal(err)
 	}
 	resources.Add(ctx, s)
-	c := mcp.NewClient("TektonClient", version.Version, nil)
+	c := mcp.NewClient("TektonClient", version.Version, opts)
 
 	ss, err := s.Connect(ctx, st)
 	if err != nil {
@@ -32,3 +45,93 @@ func newSession(t *testing.T, ctx context.Context) (*mcp.ServerSession, *mcp.Cli
 
 	return ss, cs
 }
+
+func TestNamespaceScope(t *testing.T) {
+	data := test.Data{
+		Pipelines: []*v1.Pipeline{
+			{ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ci"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "deploy", Namespace: "prod"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+	ctx = scope.WithScope(ctx, scope.Scope{Namespaces: []string{"ci", "prod"}})
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	const outside = "namespace default is outside of the namespaces the server is restricted to: ci, prod"
+	tests := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		expected  string
+		err       string
+	}{
+		{
+			name:      "namespace of the scope",
+			tool:      "list_pipelines",
+			arguments: map[string]any{"namespace": "ci"},
+			expected:  "build",
+		},
+		{
+			name:      "all the namespaces of the scope",
+			tool:      "list_pipelines",
+			arguments: map[string]any{"sortBy": "name"},
+			expected:  "build,deploy",
+		},
+		{
+			name:      "list outside of the scope",
+			tool:      "list_pipelines",
+			arguments: map[string]any{"namespace": "default"},
+			err:       outside,
+		},
+		{
+			name:      "default namespace",
+			tool:      "get_pipeline",
+			arguments: map[string]any{"name": "other"},
+			err:       outside,
+		},
+		{
+			name:      "delete outside of the scope",
+			tool:      "delete_pipeline",
+			arguments: map[string]any{"name": "other", "namespace": "default"},
+			err:       outside,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: test.tool, Arguments: test.arguments})
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var objects []struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal([]byte(response.Content[0].(*mcp.TextContent).Text), &objects); err != nil {
+				t.Fatalf("failed to unmarshal objects: %v", err)
+			}
+			var names []string
+			for _, o := range objects {
+				names = append(names, o.Name)
+			}
+			if diff := cmp.Diff(test.expected, strings.Join(names, ",")); diff != "" {
+				t.Errorf("names mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Resources are restricted to the namespaces of the scope too
+	if _, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "tekton://pipeline/default/other"}); err == nil || !strings.Contains(err.Error(), outside) {
+		t.Errorf("expected error %q, got %v", outside, err)
+	}
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/trace"
+)
+
+type getPipelineRunTraceParams struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+func getPipelineRunTrace() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[getPipelineRunTraceParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["name"].Description = "Name of the PipelineRun"
+	scheme.Properties["namespace"].Description = "Namespace of the PipelineRun"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"get_pipelinerun_trace",
+		"Export the TaskRuns and steps of a PipelineRun as Chrome Trace Event JSON, viewable as a flame chart in Perfetto or chrome://tracing, "+
+			"with a track for each TaskRun. Also available as the tekton://pipelinerun/{namespace}/{name}/trace resource",
+		handlerGetPipelineRunTrace,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerGetPipelineRunTrace(
+	ctx context.Context,
+	_ *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[getPipelineRunTraceParams],
+) (*mcp.CallToolResultFor[string], error) {
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	data, err := trace.PipelineRun(ctx, namespace, params.Arguments.Name)
+	if err != nil {
+		return nil, err
+	}
+	return result(string(data)), nil
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/trace"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGetPipelineRunTrace(t *testing.T) {
+	start := metav1.NewTime(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC))
+	completion := metav1.NewTime(start.Add(2 * time.Minute))
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "ci"},
+				Status: v1.PipelineRunStatus{
+					Status: succeededCondition(corev1.ConditionTrue, "Succeeded"),
+					PipelineRunStatusFields: v1.PipelineRunStatusFields{
+						StartTime:      &start,
+						CompletionTime: &completion,
+						ChildReferences: []v1.ChildStatusReference{
+							{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-build", PipelineTaskName: "build"},
+						},
+					},
+				},
+			},
+			{ObjectMeta: metav1.ObjectMeta{Name: "queued", Namespace: "ci"}},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release-build", Namespace: "ci"},
+				Status: v1.TaskRunStatus{
+					Status: succeededCondition(corev1.ConditionTrue, "Succeeded"),
+					TaskRunStatusFields: v1.TaskRunStatusFields{
+						StartTime:      &start,
+						CompletionTime: &completion,
+						Steps: []v1.StepState{{Name: "compile", ContainerState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{StartedAt: start, FinishedAt: completion},
+						}}},
+					},
+				},
+			},
+		},
+	}
+
+	ctx, _ := ttesting.SetupFakeContext(t)
+	_, _ = test.SeedTestData(t, ctx, data)
+
+	ss, cs := newSession(t, ctx)
+	defer ss.Close()
+	defer cs.Close()
+
+	names := func(t *testing.T, text string) []string {
+		t.Helper()
+		var got trace.Trace
+		if err := json.Unmarshal([]byte(text), &got); err != nil {
+			t.Fatalf("failed to unmarshal the trace: %v", err)
+		}
+		var names []string
+		for _, e := range got.TraceEvents {
+			if e.Phase == "X" {
+				names = append(names, e.Name)
+			}
+		}
+		return names
+	}
+
+	response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "get_pipelinerun_trace",
+		Arguments: map[string]any{"name": "release", "namespace": "ci"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	toolText := response.Content[0].(*mcp.TextContent).Text
+	if got := names(t, toolText); len(got) != 3 || got[0] != "release" || got[1] != "build" || got[2] != "compile" {
+		t.Errorf("unexpected slices %v", got)
+	}
+
+	resource, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "tekton://pipelinerun/ci/release/trace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Contents[0].Text != toolText {
+		t.Errorf("the resource and the tool returned different traces:\n%s\n%s", resource.Contents[0].Text, toolText)
+	}
+
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "get_pipelinerun_trace",
+		Arguments: map[string]any{"name": "queued", "namespace": "ci"},
+	}); err == nil {
+		t.Error("expected an error for a PipelineRun that did not start")
+	}
+}
@@ -23,6 +23,7 @@ type updatePipelineParams struct {
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 	Yaml      string `json:"yaml"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func updatePipeline() (*mcp.ServerTool, error) {
@@ -35,6 +36,7 @@ func updatePipeline() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Description = namespacePipelineDescription
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["yaml"].Description = "Updated YAML definition of the Pipeline"
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"name", "yaml"}
 
 	return mcp.NewServerTool(
@@ -77,11 +79,14 @@ func handlerUpdatePipeline(
 	pipeline.Namespace = namespace
 	pipeline.ResourceVersion = existing.ResourceVersion
 
-	updated, err := pipelineClient.TektonV1().Pipelines(namespace).Update(ctx, &pipeline, metav1.UpdateOptions{})
+	updated, err := pipelineClient.TektonV1().Pipelines(namespace).Update(ctx, &pipeline, metav1.UpdateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error updating Pipeline: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("Pipeline '%s' would be updated in namespace '%s'", updated.Name, namespace), updated)
+	}
 	return result(fmt.Sprintf("Pipeline '%s' updated successfully in namespace '%s'", updated.Name, namespace)), nil
 }
 
@@ -89,6 +94,7 @@ type updateTaskParams struct {
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 	Yaml      string `json:"yaml"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func updateTask() (*mcp.ServerTool, error) {
@@ -101,6 +107,7 @@ func updateTask() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Description = namespaceTaskDescription
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["yaml"].Description = "Updated YAML definition of the Task"
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"name", "yaml"}
 
 	return mcp.NewServerTool(
@@ -143,11 +150,14 @@ func handlerUpdateTask(
 	task.Namespace = namespace
 	task.ResourceVersion = existing.ResourceVersion
 
-	updated, err := pipelineClient.TektonV1().Tasks(namespace).Update(ctx, &task, metav1.UpdateOptions{})
+	updated, err := pipelineClient.TektonV1().Tasks(namespace).Update(ctx, &task, metav1.UpdateOptions{DryRun: dryRunOption(params.Arguments.DryRun)})
 	if err != nil {
 		return result(fmt.Sprintf("Error updating Task: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("Task '%s' would be updated in namespace '%s'", updated.Name, namespace), updated)
+	}
 	return result(fmt.Sprintf("Task '%s' updated successfully in namespace '%s'", updated.Name, namespace)), nil
 }
 
@@ -155,6 +165,7 @@ type patchPipelineParams struct {
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 	Patch     string `json:"patch"`
+	DryRun    bool   `json:"dryRun"`
 }
 
 func patchPipeline() (*mcp.ServerTool, error) {
@@ -167,6 +178,7 @@ func patchPipeline() (*mcp.ServerTool, error) {
 	scheme.Properties["namespace"].Description = namespacePipelineDescription
 	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
 	scheme.Properties["patch"].Description = "JSON patch to apply to the Pipeline"
+	scheme.Properties["dryRun"].Description = dryRunDescription
 	scheme.Required = []string{"name", "patch"}
 
 	return mcp.NewServerTool(
@@ -200,11 +212,14 @@ func handlerPatchPipeline(
 		name,
 		types.JSONPatchType,
 		[]byte(patchStr),
-		metav1.PatchOptions{},
+		metav1.PatchOptions{DryRun: dryRunOption(params.Arguments.DryRun)},
 	)
 	if err != nil {
 		return result(fmt.Sprintf("Error patching Pipeline: %v", err)), nil
 	}
 
+	if params.Arguments.DryRun {
+		return dryRunResult(fmt.Sprintf("Pipeline '%s' would be patched in namespace '%s'", patched.Name, namespace), patched)
+	}
 	return result(fmt.Sprintf("Pipeline '%s' patched successfully in namespace '%s'", patched.Name, namespace)), nil
 }
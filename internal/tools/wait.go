@@ -0,0 +1,294 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	mcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tektoncd/mcp-server/internal/scope"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+)
+
+const defaultWaitTimeout = 10 * time.Minute
+
+type waitForRunParams struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Timeout   string `json:"timeout"`
+}
+
+func waitForRun() (*mcp.ServerTool, error) {
+	scheme, err := jsonschema.For[waitForRunParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Properties["kind"].Description = "Kind of the run to wait for (pipelinerun or taskrun)"
+	scheme.Properties["kind"].Default = json.RawMessage(`"pipelinerun"`)
+	scheme.Properties["name"].Description = "Name of the run to wait for"
+	scheme.Properties["namespace"].Description = "Namespace of the run"
+	scheme.Properties["namespace"].Default = json.RawMessage(`"default"`)
+	scheme.Properties["timeout"].Description = "Maximum time to wait, as a duration (e.g. 30m)"
+	scheme.Properties["timeout"].Default = json.RawMessage(`"10m"`)
+	scheme.Required = []string{"name"}
+
+	return mcp.NewServerTool(
+		"wait_for_run",
+		"Wait for a PipelineRun or TaskRun to complete, reporting progress as its TaskRuns or steps start and finish, and return its final status",
+		handlerWaitForRun,
+		mcp.Input(mcp.Schema(scheme)),
+	), nil
+}
+
+func handlerWaitForRun(
+	ctx context.Context,
+	cc *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[waitForRunParams],
+) (*mcp.CallToolResultFor[string], error) {
+	name := params.Arguments.Name
+	namespace := params.Arguments.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	kind := strings.ToLower(params.Arguments.Kind)
+	if kind == "" {
+		kind = kindPipelineRun
+	}
+	if kind != kindPipelineRun && kind != kindTaskRun {
+		return nil, fmt.Errorf("unsupported kind %q, expected %s or %s", params.Arguments.Kind, kindPipelineRun, kindTaskRun)
+	}
+	timeout := defaultWaitTimeout
+	if d, err := parseDuration("timeout", params.Arguments.Timeout); err != nil {
+		return nil, err
+	} else if d != nil {
+		timeout = d.Duration
+	}
+
+	w := &runWaiter{
+		kind:          kind,
+		namespace:     namespace,
+		name:          name,
+		states:        make(map[string]string),
+		changed:       make(chan struct{}, 1),
+		notify:        logNotifier(ctx, cc, params.GetProgressToken()),
+		progressToken: params.GetProgressToken(),
+		cc:            cc,
+	}
+
+	// The handlers are registered before the first check so that no update
+	// can be missed
+	for _, informer := range scope.RunInformers(ctx, namespace) {
+		registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    w.onEvent,
+			UpdateFunc: func(_, obj any) { w.onEvent(obj) },
+			DeleteFunc: w.onEvent,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch %s %s/%s: %w", kind, namespace, name, err)
+		}
+		defer func() { _ = informer.RemoveEventHandler(registration) }()
+	}
+
+	// The informers raise no events for the runs not matching their label
+	// selector, which are polled instead
+	var poll <-chan time.Time
+	if scope.Get(ctx).LabelSelector != "" {
+		ticker := time.NewTicker(followPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		done, summary, err := w.check(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return result(summary), nil
+		}
+
+		select {
+		case <-w.changed:
+		case <-poll:
+		case <-waitCtx.Done():
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("stopped waiting for %s %s/%s: %w", kind, namespace, name, err)
+			}
+			return result(fmt.Sprintf("Timed out after %s waiting for %s", timeout, summary)), nil
+		}
+	}
+}
+
+// runWaiter tracks the progress of a run, and of its TaskRuns or steps, as
+// the informers report changes.
+type runWaiter struct {
+	kind      string
+	namespace string
+	name      string
+
+	// states holds the last reported state of the TaskRuns and steps
+	states   map[string]string
+	finished int
+	changed  chan struct{}
+
+	notify        func(string)
+	progressToken any
+	cc            *mcp.ServerSession
+}
+
+// onEvent wakes up the waiter when the run or one of its TaskRuns changed.
+func (w *runWaiter) onEvent(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	o, ok := obj.(metav1.Object)
+	if !ok || o.GetNamespace() != w.namespace {
+		return
+	}
+	if o.GetName() != w.name && o.GetLabels()[pipeline.PipelineRunLabelKey] != w.name {
+		return
+	}
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// report sends a progress notification, with the number of TaskRuns or steps
+// that finished so far.
+func (w *runWaiter) report(ctx context.Context, message string, total int) {
+	if w.progressToken == nil {
+		w.notify(message)
+		return
+	}
+	_ = w.cc.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: w.progressToken,
+		Progress:      float64(w.finished),
+		Total:         float64(total),
+		Message:       message,
+	})
+}
+
+// update records the state of a TaskRun or step, reporting it when it changed.
+func (w *runWaiter) update(ctx context.Context, key, state string, finished bool, message string, total int) {
+	if w.states[key] == state {
+		return
+	}
+	w.states[key] = state
+	if finished {
+		w.finished++
+	}
+	w.report(ctx, message, total)
+}
+
+func (w *runWaiter) check(ctx context.Context) (bool, string, error) {
+	if w.kind == kindTaskRun {
+		return w.checkTaskRun(ctx)
+	}
+	return w.checkPipelineRun(ctx)
+}
+
+func (w *runWaiter) checkPipelineRun(ctx context.Context) (bool, string, error) {
+	pr, err := scope.PipelineRun(ctx, w.namespace, w.name)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get PipelineRun %s/%s: %w", w.namespace, w.name, err)
+	}
+
+	var order []string
+	total := len(pr.Status.ChildReferences)
+	if spec, err := pipelineSpecFor(ctx, pr); err == nil {
+		order = dagOrder(spec)
+		total = max(total, len(order))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("PipelineRun %s/%s %s: %s", w.namespace, w.name, doneState(pr.IsDone()), describeCondition(pr.Status.GetCondition(apis.ConditionSucceeded))))
+	sb.WriteString(fmt.Sprintf("\nDuration: %s", runDuration(pr.Status.StartTime, pr.Status.CompletionTime)))
+
+	for _, child := range orderedChildReferences(pr, order) {
+		if child.Kind != taskRunKind {
+			continue
+		}
+		tr, err := scope.TaskRun(ctx, w.namespace, child.Name)
+		if err != nil {
+			// Not in the cache yet
+			continue
+		}
+		state := conditionReason(tr.Status.GetCondition(apis.ConditionSucceeded))
+		line := fmt.Sprintf("PipelineTask %s TaskRun %s: %s", child.PipelineTaskName, tr.Name, state)
+		w.update(ctx, tr.Name, state, tr.IsDone(), line, total)
+		sb.WriteString("\n" + line)
+	}
+
+	return pr.IsDone(), sb.String(), nil
+}
+
+func (w *runWaiter) checkTaskRun(ctx context.Context) (bool, string, error) {
+	tr, err := scope.TaskRun(ctx, w.namespace, w.name)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get TaskRun %s/%s: %w", w.namespace, w.name, err)
+	}
+
+	total := len(tr.Status.Steps)
+	if tr.Status.TaskSpec != nil {
+		total = max(total, len(tr.Status.TaskSpec.Steps))
+	}
+
+	var sb strings.Builder
+	succeeded := tr.Status.GetCondition(apis.ConditionSucceeded)
+	sb.WriteString(fmt.Sprintf("TaskRun %s/%s %s: %s", w.namespace, w.name, doneState(tr.IsDone()), describeCondition(succeeded)))
+	sb.WriteString(fmt.Sprintf("\nDuration: %s", runDuration(tr.Status.StartTime, tr.Status.CompletionTime)))
+	w.update(ctx, tr.Name, conditionReason(succeeded), false, fmt.Sprintf("TaskRun %s: %s", tr.Name, conditionReason(succeeded)), total)
+
+	for _, step := range tr.Status.Steps {
+		line, state, finished := stepState(step)
+		w.update(ctx, "step/"+step.Name, state, finished, line, total)
+		sb.WriteString("\n" + line)
+	}
+
+	return tr.IsDone(), sb.String(), nil
+}
+
+// stepState describes the state of a step.
+func stepState(step pipelinev1.StepState) (string, string, bool) {
+	switch {
+	case step.Terminated != nil:
+		state := fmt.Sprintf("%s (exit code %d)", step.Terminated.Reason, step.Terminated.ExitCode)
+		return fmt.Sprintf("Step %s: %s", step.Name, state), state, true
+	case step.Running != nil:
+		return fmt.Sprintf("Step %s: Running", step.Name), "Running", false
+	default:
+		return fmt.Sprintf("Step %s: Waiting", step.Name), "Waiting", false
+	}
+}
+
+func doneState(done bool) string {
+	if done {
+		return "completed"
+	}
+	return "not completed"
+}
+
+// runDuration returns the duration of a run, or the time elapsed since it
+// started if it is still running.
+func runDuration(start, completion *metav1.Time) string {
+	switch {
+	case start == nil:
+		return "not started"
+	case completion == nil:
+		return time.Since(start.Time).Round(time.Second).String() + " (running)"
+	default:
+		return completion.Sub(start.Time).Round(time.Second).String()
+	}
+}
@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	ttesting "github.com/tektoncd/pipeline/pkg/reconciler/testing"
+	"github.com/tektoncd/pipeline/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
+)
+
+func succeededCondition(status corev1.ConditionStatus, reason string) duckv1.Status {
+	return duckv1.Status{Conditions: duckv1.Conditions{{
+		Type:   apis.ConditionSucceeded,
+		Status: status,
+		Reason: reason,
+	}}}
+}
+
+func TestWaitForRun(t *testing.T) {
+	start := metav1.NewTime(time.Now().Add(-5 * time.Minute))
+	data := test.Data{
+		PipelineRuns: []*v1.PipelineRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+				Status: v1.PipelineRunStatus{
+					Status: succeededCondition(corev1.ConditionUnknown, "Running"),
+					PipelineRunStatusFields: v1.PipelineRunStatusFields{
+						StartTime: &start,
+						PipelineSpec: &v1.PipelineSpec{Tasks: []v1.PipelineTask{
+							{Name: "build"},
+							{Name: "test", RunAfter: []string{"build"}},
+						}},
+						ChildReferences: []v1.ChildStatusReference{
+							{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-build", PipelineTaskName: "build"},
+						},
+					},
+				},
+			},
+		},
+		TaskRuns: []*v1.TaskRun{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "release-build",
+					Namespace: "default",
+					Labels:    map[string]string{"tekton.dev/pipelineRun": "release"},
+				},
+				Status: v1.TaskRunStatus{Status: succeededCondition(corev1.ConditionUnknown, "Running")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "lint", Namespace: "default"},
+				Status: v1.TaskRunStatus{
+					Status: succeededCondition(corev1.ConditionUnknown, "Running"),
+					TaskRunStatusFields: v1.TaskRunStatusFields{
+						StartTime: &start,
+						Steps:     []v1.StepState{{Name: "golangci-lint", ContainerState: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}},
+					},
+				},
+			},
+		},
+	}
+
+	ctx, informers := ttesting.SetupFakeContext(t)
+	clients, _ := test.SeedTestData(t, ctx, data)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := controller.StartInformers(ctx.Done(), informers...); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var messages []string
+	ss, cs := newSessionWithClientOptions(t, ctx, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, _ *mcp.ClientSession, params *mcp.ProgressNotificationParams) {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, params.Message)
+		},
+	})
+	defer ss.Close()
+	defer cs.Close()
+
+	t.Run("pipelinerun", func(t *testing.T) {
+		go func() {
+			// Let the tool report the initial state
+			time.Sleep(100 * time.Millisecond)
+			build, _ := clients.Pipeline.TektonV1().TaskRuns("default").Get(ctx, "release-build", metav1.GetOptions{})
+			build.Status.Status = succeededCondition(corev1.ConditionTrue, "Succeeded")
+			_, _ = clients.Pipeline.TektonV1().TaskRuns("default").UpdateStatus(ctx, build, metav1.UpdateOptions{})
+
+			time.Sleep(100 * time.Millisecond)
+			completion := metav1.NewTime(start.Add(3 * time.Minute))
+			pr, _ := clients.Pipeline.TektonV1().PipelineRuns("default").Get(ctx, "release", metav1.GetOptions{})
+			pr.Status.Status = succeededCondition(corev1.ConditionFalse, "Failed")
+			pr.Status.CompletionTime = &completion
+			_, _ = clients.Pipeline.TektonV1().PipelineRuns("default").UpdateStatus(ctx, pr, metav1.UpdateOptions{})
+		}()
+
+		params := &mcp.CallToolParams{
+			// SetProgressToken does not allocate the meta map
+			Meta:      mcp.Meta{},
+			Name:      "wait_for_run",
+			Arguments: map[string]any{"name": "release", "timeout": "10s"},
+		}
+		params.SetProgressToken("release")
+		response, err := cs.CallTool(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, _ := response.Content[0].(*mcp.TextContent)
+		expected := `PipelineRun default/release completed: Succeeded=False (Failed)
+Duration: 3m0s
+PipelineTask build TaskRun release-build: Succeeded`
+		if diff := cmp.Diff(expected, content.Text); diff != "" {
+			t.Errorf("wait_for_run mismatch (-want +got):\n%s", diff)
+		}
+
+		// Notifications are handled asynchronously by the client
+		expectedMessages := []string{
+			"PipelineTask build TaskRun release-build: Running",
+			"PipelineTask build TaskRun release-build: Succeeded",
+		}
+		for range 50 {
+			mu.Lock()
+			n := len(messages)
+			mu.Unlock()
+			if n >= len(expectedMessages) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if diff := cmp.Diff(expectedMessages, messages); diff != "" {
+			t.Errorf("progress notifications mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("taskrun timeout", func(t *testing.T) {
+		response, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "wait_for_run",
+			Arguments: map[string]any{"kind": "taskrun", "name": "lint", "timeout": "100ms"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, _ := response.Content[0].(*mcp.TextContent)
+		for _, expected := range []string{
+			"Timed out after 100ms waiting for TaskRun default/lint not completed: Succeeded=Unknown (Running)",
+			"Step golangci-lint: Running",
+		} {
+			if !strings.Contains(content.Text, expected) {
+				t.Errorf("expected response to contain %q, got %q", expected, content.Text)
+			}
+		}
+	})
+
+	t.Run("unknown run", func(t *testing.T) {
+		_, err := cs.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "wait_for_run",
+			Arguments: map[string]any{"name": "nonexistent"},
+		})
+		if err == nil || !strings.Contains(err.Error(), "failed to get PipelineRun default/nonexistent") {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// workspaceParam is the input used by the run creation tools to bind a
+// workspace to a single volume source.
+type workspaceParam struct {
+	Name                  string                    `json:"name"`
+	SubPath               string                    `json:"subPath,omitempty"`
+	PersistentVolumeClaim string                    `json:"persistentVolumeClaim,omitempty"`
+	VolumeClaimTemplate   *volumeClaimTemplateParam `json:"volumeClaimTemplate,omitempty"`
+	ConfigMap             string                    `json:"configMap,omitempty"`
+	Secret                string                    `json:"secret,omitempty"`
+	EmptyDir              bool                      `json:"emptyDir,omitempty"`
+	Projected             []projectedSourceParam    `json:"projected,omitempty"`
+	CSI                   *csiParam                 `json:"csi,omitempty"`
+}
+
+type volumeClaimTemplateParam struct {
+	Storage          string   `json:"storage"`
+	StorageClassName string   `json:"storageClassName,omitempty"`
+	AccessModes      []string `json:"accessModes,omitempty"`
+}
+
+type projectedSourceParam struct {
+	ConfigMap string `json:"configMap,omitempty"`
+	Secret    string `json:"secret,omitempty"`
+}
+
+type csiParam struct {
+	Driver               string            `json:"driver"`
+	ReadOnly             bool              `json:"readOnly,omitempty"`
+	VolumeAttributes     map[string]string `json:"volumeAttributes,omitempty"`
+	NodePublishSecretRef string            `json:"nodePublishSecretRef,omitempty"`
+}
+
+// workspaceDeclaration is a workspace declared by a Pipeline or a Task.
+type workspaceDeclaration struct {
+	name     string
+	optional bool
+}
+
+func pipelineWorkspaces(spec *v1.PipelineSpec) []workspaceDeclaration {
+	declared := make([]workspaceDeclaration, 0, len(spec.Workspaces))
+	for _, w := range spec.Workspaces {
+		declared = append(declared, workspaceDeclaration{name: w.Name, optional: w.Optional})
+	}
+	return declared
+}
+
+func taskWorkspaces(spec *v1.TaskSpec) []workspaceDeclaration {
+	declared := make([]workspaceDeclaration, 0, len(spec.Workspaces))
+	for _, w := range spec.Workspaces {
+		declared = append(declared, workspaceDeclaration{name: w.Name, optional: w.Optional})
+	}
+	return declared
+}
+
+// workspacesDescriptions documents the workspace bindings of a run creation
+// tool.
+func workspacesDescriptions(property *jsonschema.Schema) {
+	property.Description = "Workspace bindings, each one with a name and a single volume source"
+	workspace := property.Items
+	workspace.Properties["name"].Description = "Name of the declared workspace"
+	workspace.Properties["subPath"].Description = "Sub directory of the volume to use"
+	workspace.Properties["persistentVolumeClaim"].Description = "Name of an existing PersistentVolumeClaim"
+	workspace.Properties["volumeClaimTemplate"].Description = "PersistentVolumeClaim created for the run and deleted with it"
+	workspace.Properties["volumeClaimTemplate"].Properties["storage"].Description = "Requested storage size (e.g. 1Gi)"
+	workspace.Properties["volumeClaimTemplate"].Properties["storageClassName"].Description = "Storage class of the claim (cluster default if empty)"
+	workspace.Properties["volumeClaimTemplate"].Properties["accessModes"].Description = "Access modes of the claim (ReadWriteOnce if empty)"
+	workspace.Properties["volumeClaimTemplate"].Required = []string{"storage"}
+	workspace.Properties["configMap"].Description = "Name of a ConfigMap"
+	workspace.Properties["secret"].Description = "Name of a Secret"
+	workspace.Properties["emptyDir"].Description = "Use an emptyDir volume"
+	workspace.Properties["projected"].Description = "ConfigMaps and Secrets projected into a single volume"
+	workspace.Properties["projected"].Items.Required = nil
+	workspace.Properties["csi"].Description = "CSI ephemeral volume"
+	workspace.Properties["csi"].Properties["driver"].Description = "Name of the CSI driver"
+	workspace.Properties["csi"].Properties["nodePublishSecretRef"].Description = "Name of the Secret passed to the CSI driver"
+	workspace.Properties["csi"].Required = []string{"driver"}
+	workspace.Required = []string{"name"}
+}
+
+// bindWorkspaces converts the workspace params to Tekton workspace bindings
+// and checks them against the declared workspaces: every workspace must be
+// bound unless optional, and only declared workspaces can be bound. The
+// unbound optional workspaces are returned as notes.
+func bindWorkspaces(declared []workspaceDeclaration, params []workspaceParam) ([]v1.WorkspaceBinding, []string, error) {
+	bindings, err := workspaceBindings(params)
+	errs := []error{err}
+	bound := make(map[string]bool, len(bindings))
+	for _, binding := range bindings {
+		bound[binding.Name] = true
+	}
+
+	var notes []string
+	known := make(map[string]bool, len(declared))
+	for _, d := range declared {
+		known[d.name] = true
+		switch {
+		case bound[d.name]:
+		case d.optional:
+			notes = append(notes, fmt.Sprintf("optional workspace %q is not bound", d.name))
+		default:
+			errs = append(errs, fmt.Errorf("missing binding for workspace %q", d.name))
+		}
+	}
+	for _, w := range params {
+		if w.Name != "" && !known[w.Name] {
+			errs = append(errs, fmt.Errorf("unknown workspace %q", w.Name))
+		}
+	}
+
+	return bindings, notes, errors.Join(errs...)
+}
+
+// workspaceBindings converts the workspace params to Tekton workspace
+// bindings, without checking them against the declared workspaces, when
+// these are not known.
+func workspaceBindings(params []workspaceParam) ([]v1.WorkspaceBinding, error) {
+	var errs []error
+	bound := make(map[string]bool, len(params))
+	bindings := make([]v1.WorkspaceBinding, 0, len(params))
+	for _, w := range params {
+		binding, err := w.binding()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if bound[w.Name] {
+			errs = append(errs, fmt.Errorf("workspace %q is bound more than once", w.Name))
+			continue
+		}
+		bound[w.Name] = true
+		bindings = append(bindings, binding)
+	}
+
+	if len(bindings) == 0 {
+		bindings = nil
+	}
+	return bindings, errors.Join(errs...)
+}
+
+// binding returns the Tekton workspace binding of a workspace param, which
+// must set exactly one volume source.
+func (w workspaceParam) binding() (v1.WorkspaceBinding, error) {
+	binding := v1.WorkspaceBinding{Name: w.Name, SubPath: w.SubPath}
+	if w.Name == "" {
+		return binding, errors.New("workspace name is required")
+	}
+
+	sources := 0
+	if w.PersistentVolumeClaim != "" {
+		binding.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: w.PersistentVolumeClaim}
+		sources++
+	}
+	if w.VolumeClaimTemplate != nil {
+		claim, err := w.VolumeClaimTemplate.claim()
+		if err != nil {
+			return binding, fmt.Errorf("workspace %q: %w", w.Name, err)
+		}
+		binding.VolumeClaimTemplate = claim
+		sources++
+	}
+	if w.ConfigMap != "" {
+		binding.ConfigMap = &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: w.ConfigMap}}
+		sources++
+	}
+	if w.Secret != "" {
+		binding.Secret = &corev1.SecretVolumeSource{SecretName: w.Secret}
+		sources++
+	}
+	if w.EmptyDir {
+		binding.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		sources++
+	}
+	if len(w.Projected) > 0 {
+		projected, err := projectedVolume(w.Projected)
+		if err != nil {
+			return binding, fmt.Errorf("workspace %q: %w", w.Name, err)
+		}
+		binding.Projected = projected
+		sources++
+	}
+	if w.CSI != nil {
+		if w.CSI.Driver == "" {
+			return binding, fmt.Errorf("workspace %q: csi driver is required", w.Name)
+		}
+		binding.CSI = &corev1.CSIVolumeSource{
+			Driver:           w.CSI.Driver,
+			ReadOnly:         &w.CSI.ReadOnly,
+			VolumeAttributes: w.CSI.VolumeAttributes,
+		}
+		if w.CSI.NodePublishSecretRef != "" {
+			binding.CSI.NodePublishSecretRef = &corev1.LocalObjectReference{Name: w.CSI.NodePublishSecretRef}
+		}
+		sources++
+	}
+
+	switch {
+	case sources == 0:
+		return binding, fmt.Errorf("workspace %q has no volume source", w.Name)
+	case sources > 1:
+		return binding, fmt.Errorf("workspace %q has more than one volume source", w.Name)
+	}
+	return binding, nil
+}
+
+func (p volumeClaimTemplateParam) claim() (*corev1.PersistentVolumeClaim, error) {
+	storage, err := resource.ParseQuantity(p.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid volumeClaimTemplate storage %q: %w", p.Storage, err)
+	}
+
+	accessModes := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	if len(p.AccessModes) > 0 {
+		accessModes = accessModes[:0]
+		for _, mode := range p.AccessModes {
+			accessModes = append(accessModes, corev1.PersistentVolumeAccessMode(mode))
+		}
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: accessModes,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: storage},
+			},
+		},
+	}
+	if p.StorageClassName != "" {
+		claim.Spec.StorageClassName = &p.StorageClassName
+	}
+	return claim, nil
+}
+
+func projectedVolume(sources []projectedSourceParam) (*corev1.ProjectedVolumeSource, error) {
+	projected := &corev1.ProjectedVolumeSource{}
+	for _, source := range sources {
+		switch {
+		case source.ConfigMap != "" && source.Secret != "":
+			return nil, errors.New("each projected source must set either configMap or secret")
+		case source.ConfigMap != "":
+			projected.Sources = append(projected.Sources, corev1.VolumeProjection{
+				ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: source.ConfigMap}},
+			})
+		case source.Secret != "":
+			projected.Sources = append(projected.Sources, corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: source.Secret}},
+			})
+		default:
+			return nil, errors.New("each projected source must set either configMap or secret")
+		}
+	}
+	return projected, nil
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestBindWorkspaces(t *testing.T) {
+	storageClass := "fast"
+	readOnly := true
+	declared := []workspaceDeclaration{
+		{name: "source"},
+		{name: "config"},
+		{name: "credentials"},
+		{name: "cache"},
+		{name: "certs"},
+		{name: "docker-config", optional: true},
+	}
+	params := []workspaceParam{
+		{Name: "source", VolumeClaimTemplate: &volumeClaimTemplateParam{Storage: "1Gi", StorageClassName: storageClass}},
+		{Name: "config", ConfigMap: "settings", SubPath: "app"},
+		{Name: "credentials", Projected: []projectedSourceParam{{Secret: "git"}, {ConfigMap: "known-hosts"}}},
+		{Name: "cache", EmptyDir: true},
+		{Name: "certs", CSI: &csiParam{Driver: "secrets-store.csi.k8s.io", ReadOnly: true, VolumeAttributes: map[string]string{"provider": "vault"}}},
+	}
+
+	bindings, notes, err := bindWorkspaces(declared, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []v1.WorkspaceBinding{
+		{
+			Name: "source",
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaim{Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources:        corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+				StorageClassName: &storageClass,
+			}},
+		},
+		{
+			Name:      "config",
+			SubPath:   "app",
+			ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}},
+		},
+		{
+			Name: "credentials",
+			Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "git"}}},
+				{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "known-hosts"}}},
+			}},
+		},
+		{
+			Name:     "cache",
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+		{
+			Name: "certs",
+			CSI: &corev1.CSIVolumeSource{
+				Driver:           "secrets-store.csi.k8s.io",
+				ReadOnly:         &readOnly,
+				VolumeAttributes: map[string]string{"provider": "vault"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, bindings); diff != "" {
+		t.Errorf("bindWorkspaces mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{`optional workspace "docker-config" is not bound`}, notes); diff != "" {
+		t.Errorf("bindWorkspaces notes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBindWorkspacesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   []workspaceParam
+		expected string
+	}{
+		{
+			name:     "invalid storage",
+			params:   []workspaceParam{{Name: "source", VolumeClaimTemplate: &volumeClaimTemplateParam{Storage: "a lot"}}},
+			expected: `workspace "source": invalid volumeClaimTemplate storage "a lot"`,
+		},
+		{
+			name:     "empty projected source",
+			params:   []workspaceParam{{Name: "source", Projected: []projectedSourceParam{{}}}},
+			expected: "each projected source must set either configMap or secret",
+		},
+		{
+			name:     "csi without driver",
+			params:   []workspaceParam{{Name: "source", CSI: &csiParam{}}},
+			expected: `workspace "source": csi driver is required`,
+		},
+		{
+			name:     "bound twice",
+			params:   []workspaceParam{{Name: "source", EmptyDir: true}, {Name: "source", Secret: "creds"}},
+			expected: `workspace "source" is bound more than once`,
+		},
+		{
+			name:     "missing name",
+			params:   []workspaceParam{{Name: "source", EmptyDir: true}, {EmptyDir: true}},
+			expected: "workspace name is required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := bindWorkspaces([]workspaceDeclaration{{name: "source"}}, test.params)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Fatalf("expected error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Package trace exports the timeline of a PipelineRun in the Chrome Trace
+// Event format, viewable in Perfetto or chrome://tracing.
+package trace
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tektoncd/mcp-server/internal/scope"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// Phases of the trace events
+	phaseComplete = "X"
+	phaseMetadata = "M"
+
+	pid = 1
+)
+
+// Event is a trace event of the Chrome Trace Event format. Times are in
+// microseconds.
+type Event struct {
+	Name      string         `json:"name"`
+	Category  string         `json:"cat,omitempty"`
+	Phase     string         `json:"ph"`
+	Timestamp int64          `json:"ts"`
+	Duration  int64          `json:"dur,omitempty"`
+	PID       int            `json:"pid"`
+	TID       int            `json:"tid"`
+	Args      map[string]any `json:"args,omitempty"`
+}
+
+// Trace is a trace in the JSON Object Format of the Chrome Trace Event format.
+type Trace struct {
+	TraceEvents     []Event `json:"traceEvents"`
+	DisplayTimeUnit string  `json:"displayTimeUnit"`
+}
+
+// PipelineRun returns the trace of a PipelineRun from the informer caches, as
+// JSON.
+func PipelineRun(ctx context.Context, namespace, name string) ([]byte, error) {
+	pr, err := scope.PipelineRun(ctx, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PipelineRun %s/%s: %w", namespace, name, err)
+	}
+	if pr.Status.StartTime == nil {
+		return nil, fmt.Errorf("PipelineRun %s/%s has not started yet", namespace, name)
+	}
+
+	var taskRuns []*v1.TaskRun
+	for _, child := range pr.Status.ChildReferences {
+		if child.Kind != "TaskRun" {
+			continue
+		}
+		// TaskRuns may have been pruned, the trace is built from the
+		// remaining ones
+		if tr, err := scope.TaskRun(ctx, namespace, child.Name); err == nil {
+			taskRuns = append(taskRuns, tr)
+		}
+	}
+
+	data, err := json.Marshal(New(pr, taskRuns, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trace to JSON: %w", err)
+	}
+	return data, nil
+}
+
+// New builds the trace of a started PipelineRun. The PipelineRun and each of
+// its TaskRuns are a track. The slice of a TaskRun contains the time it was
+// queued, until its first step started, followed by the slices of its steps.
+// The runs and steps still running end now.
+func New(pr *v1.PipelineRun, taskRuns []*v1.TaskRun, now time.Time) *Trace {
+	pipelineTasks := map[string]string{}
+	for _, child := range pr.Status.ChildReferences {
+		pipelineTasks[child.Name] = child.PipelineTaskName
+	}
+
+	trace := &Trace{DisplayTimeUnit: "ms"}
+	trace.TraceEvents = append(trace.TraceEvents,
+		metadata("process_name", 0, map[string]any{"name": fmt.Sprintf("PipelineRun %s/%s", pr.Namespace, pr.Name)}),
+		metadata("thread_name", 0, map[string]any{"name": pr.Name}),
+		slice(pr.Name, "pipelinerun", 0, pr.Status.StartTime.Time, runEnd(pr.Status.CompletionTime, now), statusArgs(pr.Status.GetCondition(apis.ConditionSucceeded))),
+	)
+
+	sorted := slices.Clone(taskRuns)
+	slices.SortStableFunc(sorted, func(a, b *v1.TaskRun) int {
+		return cmp.Or(taskRunStart(a).Compare(taskRunStart(b)), cmp.Compare(a.Name, b.Name))
+	})
+	for i, tr := range sorted {
+		tid := i + 1
+		pipelineTask := pipelineTasks[tr.Name]
+		start := taskRunStart(tr)
+		end := runEnd(tr.Status.CompletionTime, now)
+
+		args := statusArgs(tr.Status.GetCondition(apis.ConditionSucceeded))
+		args["taskRun"] = tr.Name
+		if tr.Status.PodName != "" {
+			args["pod"] = tr.Status.PodName
+		}
+		trace.TraceEvents = append(trace.TraceEvents,
+			metadata("thread_name", tid, map[string]any{"name": fmt.Sprintf("%s (%s)", pipelineTask, tr.Name)}),
+			metadata("thread_sort_index", tid, map[string]any{"sort_index": tid}),
+			slice(pipelineTask, "taskrun", tid, start, end, args),
+		)
+
+		var firstStep time.Time
+		var steps []Event
+		for _, step := range tr.Status.Steps {
+			stepStart, stepEnd := StepTimes(step, now)
+			if stepStart.IsZero() {
+				continue
+			}
+			if firstStep.IsZero() || stepStart.Before(firstStep) {
+				firstStep = stepStart
+			}
+			args := map[string]any{"container": step.Container}
+			if step.Terminated != nil {
+				args["exitCode"] = step.Terminated.ExitCode
+				args["reason"] = step.Terminated.Reason
+			}
+			steps = append(steps, slice(step.Name, "step", tid, stepStart, stepEnd, args))
+		}
+		if firstStep.IsZero() {
+			firstStep = end
+		}
+		if firstStep.After(start) {
+			trace.TraceEvents = append(trace.TraceEvents, slice("queued", "queue", tid, start, firstStep, nil))
+		}
+		trace.TraceEvents = append(trace.TraceEvents, steps...)
+	}
+	return trace
+}
+
+func metadata(name string, tid int, args map[string]any) Event {
+	return Event{Name: name, Phase: phaseMetadata, PID: pid, TID: tid, Args: args}
+}
+
+func slice(name, category string, tid int, start, end time.Time, args map[string]any) Event {
+	return Event{
+		Name:      name,
+		Category:  category,
+		Phase:     phaseComplete,
+		Timestamp: start.UnixMicro(),
+		Duration:  max(end.Sub(start).Microseconds(), 0),
+		PID:       pid,
+		TID:       tid,
+		Args:      args,
+	}
+}
+
+func statusArgs(c *apis.Condition) map[string]any {
+	if c == nil {
+		return map[string]any{"status": "Unknown", "reason": "Pending"}
+	}
+	return map[string]any{"status": string(c.Status), "reason": c.Reason}
+}
+
+func runEnd(completion *metav1.Time, now time.Time) time.Time {
+	if completion == nil {
+		return now
+	}
+	return completion.Time
+}
+
+func taskRunStart(tr *v1.TaskRun) time.Time {
+	if tr.Status.StartTime != nil {
+		return tr.Status.StartTime.Time
+	}
+	return tr.CreationTimestamp.Time
+}
+
+// StepTimes returns the start and end of a step, the end being now while it
+// is running and the start being zero if it did not start.
+func StepTimes(step v1.StepState, now time.Time) (time.Time, time.Time) {
+	switch {
+	case step.Terminated != nil && !step.Terminated.StartedAt.IsZero():
+		return step.Terminated.StartedAt.Time, step.Terminated.FinishedAt.Time
+	case step.Running != nil && !step.Running.StartedAt.IsZero():
+		return step.Running.StartedAt.Time, now
+	}
+	return time.Time{}, time.Time{}
+}
@@ -0,0 +1,123 @@
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestNew(t *testing.T) {
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) *metav1.Time {
+		t := metav1.NewTime(base.Add(time.Duration(seconds) * time.Second))
+		return &t
+	}
+	micros := func(seconds int) int64 {
+		return base.Add(time.Duration(seconds) * time.Second).UnixMicro()
+	}
+	succeeded := duckv1.Status{Conditions: duckv1.Conditions{{
+		Type:   apis.ConditionSucceeded,
+		Status: corev1.ConditionTrue,
+		Reason: "Succeeded",
+	}}}
+
+	pr := &v1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "default"},
+		Status: v1.PipelineRunStatus{
+			Status: duckv1.Status{Conditions: duckv1.Conditions{{
+				Type:   apis.ConditionSucceeded,
+				Status: corev1.ConditionUnknown,
+				Reason: "Running",
+			}}},
+			PipelineRunStatusFields: v1.PipelineRunStatusFields{
+				StartTime: at(0),
+				ChildReferences: []v1.ChildStatusReference{
+					{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-test", PipelineTaskName: "test"},
+					{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "release-build", PipelineTaskName: "build"},
+				},
+			},
+		},
+	}
+	taskRuns := []*v1.TaskRun{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "release-test", Namespace: "default"},
+			Status: v1.TaskRunStatus{
+				TaskRunStatusFields: v1.TaskRunStatusFields{
+					StartTime: at(60),
+					PodName:   "release-test-pod",
+					Steps: []v1.StepState{{
+						Name:           "unit",
+						Container:      "step-unit",
+						ContainerState: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: *at(70)}},
+					}},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "release-build", Namespace: "default"},
+			Status: v1.TaskRunStatus{
+				Status: succeeded,
+				TaskRunStatusFields: v1.TaskRunStatusFields{
+					StartTime:      at(1),
+					CompletionTime: at(50),
+					PodName:        "release-build-pod",
+					Steps: []v1.StepState{
+						{
+							Name:      "compile",
+							Container: "step-compile",
+							ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+								StartedAt: *at(11), FinishedAt: *at(40), Reason: "Completed",
+							}},
+						},
+						{
+							Name:      "push",
+							Container: "step-push",
+							ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+								StartedAt: *at(40), FinishedAt: *at(49), Reason: "Completed",
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := New(pr, taskRuns, base.Add(100*time.Second))
+	expected := &Trace{
+		DisplayTimeUnit: "ms",
+		TraceEvents: []Event{
+			{Name: "process_name", Phase: "M", PID: 1, TID: 0, Args: map[string]any{"name": "PipelineRun default/release"}},
+			{Name: "thread_name", Phase: "M", PID: 1, TID: 0, Args: map[string]any{"name": "release"}},
+			{Name: "release", Category: "pipelinerun", Phase: "X", Timestamp: micros(0), Duration: 100_000_000, PID: 1, TID: 0,
+				Args: map[string]any{"status": "Unknown", "reason": "Running"}},
+
+			{Name: "thread_name", Phase: "M", PID: 1, TID: 1, Args: map[string]any{"name": "build (release-build)"}},
+			{Name: "thread_sort_index", Phase: "M", PID: 1, TID: 1, Args: map[string]any{"sort_index": 1}},
+			{Name: "build", Category: "taskrun", Phase: "X", Timestamp: micros(1), Duration: 49_000_000, PID: 1, TID: 1,
+				Args: map[string]any{"status": "True", "reason": "Succeeded", "taskRun": "release-build", "pod": "release-build-pod"}},
+			{Name: "queued", Category: "queue", Phase: "X", Timestamp: micros(1), Duration: 10_000_000, PID: 1, TID: 1},
+			{Name: "compile", Category: "step", Phase: "X", Timestamp: micros(11), Duration: 29_000_000, PID: 1, TID: 1,
+				Args: map[string]any{"container": "step-compile", "exitCode": int32(0), "reason": "Completed"}},
+			{Name: "push", Category: "step", Phase: "X", Timestamp: micros(40), Duration: 9_000_000, PID: 1, TID: 1,
+				Args: map[string]any{"container": "step-push", "exitCode": int32(0), "reason": "Completed"}},
+
+			{Name: "thread_name", Phase: "M", PID: 1, TID: 2, Args: map[string]any{"name": "test (release-test)"}},
+			{Name: "thread_sort_index", Phase: "M", PID: 1, TID: 2, Args: map[string]any{"sort_index": 2}},
+			{Name: "test", Category: "taskrun", Phase: "X", Timestamp: micros(60), Duration: 40_000_000, PID: 1, TID: 2,
+				Args: map[string]any{"status": "Unknown", "reason": "Pending", "taskRun": "release-test", "pod": "release-test-pod"}},
+			{Name: "queued", Category: "queue", Phase: "X", Timestamp: micros(60), Duration: 10_000_000, PID: 1, TID: 2},
+			{Name: "unit", Category: "step", Phase: "X", Timestamp: micros(70), Duration: 30_000_000, PID: 1, TID: 2,
+				Args: map[string]any{"container": "step-unit"}},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("trace mismatch (-want +got):\n%s", diff)
+	}
+}